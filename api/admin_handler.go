@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/service"
+	jsonutil "pansou/util/json"
+)
+
+// AdminPlugins 插件启用状态
+type AdminPlugins struct {
+	Enabled   []string `json:"enabled"`   // 当前启用的插件
+	Available []string `json:"available"` // 所有已注册的插件
+}
+
+// AdminChannels 默认搜索频道
+type AdminChannels struct {
+	Channels []string `json:"channels"`
+}
+
+// AdminPluginsUpdate 修改启用插件的请求体
+type AdminPluginsUpdate struct {
+	Plugins []string `json:"plugins"`
+}
+
+// GetAdminPluginsHandler 获取启用的插件和所有可用插件
+func GetAdminPluginsHandler(c *gin.Context) {
+	writeAdminResponse(c, currentAdminPlugins())
+}
+
+// UpdateAdminPluginsHandler 替换启用的插件列表，立即对新的搜索生效
+func UpdateAdminPluginsHandler(c *gin.Context) {
+	var req AdminPluginsUpdate
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+	if req.Plugins == nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "缺少plugins参数"))
+		return
+	}
+
+	if err := searchService.SetEnabledPlugins(req.Plugins); err != nil {
+		abortAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, currentAdminPlugins())
+}
+
+// GetAdminChannelsHandler 获取默认搜索频道
+func GetAdminChannelsHandler(c *gin.Context) {
+	writeAdminResponse(c, AdminChannels{Channels: config.GetDefaultChannels()})
+}
+
+// UpdateAdminChannelsHandler 替换默认搜索频道，立即对未指定频道的搜索生效
+func UpdateAdminChannelsHandler(c *gin.Context) {
+	var req AdminChannels
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+
+	if err := searchService.SetDefaultChannels(req.Channels); err != nil {
+		abortAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, AdminChannels{Channels: config.GetDefaultChannels()})
+}
+
+// GetAdminConfigHandler 获取当前生效的配置，敏感信息已脱敏
+func GetAdminConfigHandler(c *gin.Context) {
+	writeAdminResponse(c, config.EffectiveConfig())
+}
+
+// currentAdminPlugins 当前插件启用状态，名称按字母排序
+func currentAdminPlugins() AdminPlugins {
+	result := AdminPlugins{Enabled: []string{}, Available: []string{}}
+	if config.AppConfig.AsyncPluginEnabled && searchService.GetPluginManager() != nil {
+		result.Enabled = searchService.GetPluginManager().PluginNames()
+	}
+	for _, p := range plugin.GetRegisteredPlugins() {
+		result.Available = append(result.Available, p.Name())
+	}
+	sort.Strings(result.Enabled)
+	sort.Strings(result.Available)
+	return result
+}
+
+// bindAdminRequest 解析管理接口的JSON请求体，失败时返回400
+func bindAdminRequest(c *gin.Context, req interface{}) bool {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "读取请求数据失败: "+err.Error()))
+		return false
+	}
+	if err := jsonutil.Unmarshal(data, req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "无效的请求参数: "+err.Error()))
+		return false
+	}
+	return true
+}
+
+// abortAdminError 参数错误返回400，保存状态文件等失败返回500
+func abortAdminError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrUnknownPlugin) || errors.Is(err, service.ErrEmptyChannels) || errors.Is(err, service.ErrPluginsDisabled) {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, model.NewErrorResponse(500, err.Error()))
+}
+
+// writeAdminResponse 输出成功响应
+func writeAdminResponse(c *gin.Context, data interface{}) {
+	jsonData, _ := jsonutil.Marshal(model.NewSuccessResponse(data))
+	c.Data(http.StatusOK, "application/json", jsonData)
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/model"
+	jsonutil "pansou/util/json"
+	"pansou/util/ratelimit"
+)
+
+// gin上下文中的键名
+const (
+	apiKeyContextKey  = "api_key" // 当前请求的API Key
+	refreshContextKey = "refresh" // 当前请求是否强制刷新
+)
+
+// APIKeyUsage 单个API Key的用量统计
+type APIKeyUsage struct {
+	Name      string     `json:"name,omitempty"`
+	Key       string     `json:"key"` // 脱敏后的Key
+	Admin     bool       `json:"admin"`
+	Requests  int64      `json:"requests"`            // 通过认证的请求数
+	Searches  int64      `json:"searches"`            // 通过配额检查的搜索次数
+	Refreshes int64      `json:"refreshes"`           // 通过配额检查的refresh=true次数
+	Rejected  int64      `json:"rejected"`            // 因超出配额被拒绝的次数
+	LastUsed  *time.Time `json:"last_used,omitempty"` // 从未使用时为空
+}
+
+// APIKeyAuth API Key认证与配额
+// 未配置任何Key时不启用，所有中间件直接放行
+type APIKeyAuth struct {
+	mu    sync.Mutex
+	keys  map[string]config.APIKey
+	usage map[string]*APIKeyUsage
+
+	searchLimiter  *ratelimit.KeyedLimiter
+	refreshLimiter *ratelimit.KeyedLimiter
+}
+
+// 保存API Key认证的实例
+var apiKeyAuth *APIKeyAuth
+
+// NewAPIKeyAuth 根据配置创建API Key认证
+func NewAPIKeyAuth() *APIKeyAuth {
+	search, refresh := config.GetAPIKeyRateLimits()
+	auth := &APIKeyAuth{
+		keys:           make(map[string]config.APIKey),
+		usage:          make(map[string]*APIKeyUsage),
+		searchLimiter:  ratelimit.NewKeyedLimiter(search, time.Minute),
+		refreshLimiter: ratelimit.NewKeyedLimiter(refresh, time.Minute),
+	}
+	for _, key := range config.AppConfig.APIKeys {
+		auth.keys[key.Key] = key
+		auth.usage[key.Key] = &APIKeyUsage{Name: key.Name, Key: config.RedactSecret(key.Key), Admin: key.Admin}
+	}
+	return auth
+}
+
+// UpdateLimits 配置重新加载后更新每个Key的配额
+func (a *APIKeyAuth) UpdateLimits() {
+	search, refresh := config.GetAPIKeyRateLimits()
+	a.searchLimiter.SetCapacity(search)
+	a.refreshLimiter.SetCapacity(refresh)
+}
+
+// Enabled 是否启用认证
+func (a *APIKeyAuth) Enabled() bool {
+	return len(a.keys) > 0
+}
+
+// Authenticate 认证中间件：校验请求携带的API Key，无效时返回401
+// Key可通过X-API-Key头、Authorization: Bearer头或api_key查询参数传递
+func (a *APIKeyAuth) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Enabled() {
+			c.Next()
+			return
+		}
+
+		key := extractAPIKey(c)
+		if _, exists := a.keys[key]; !exists || key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, model.NewErrorResponse(401, "缺少或无效的API Key"))
+			return
+		}
+
+		a.mu.Lock()
+		usage := a.usage[key]
+		usage.Requests++
+		now := time.Now()
+		usage.LastUsed = &now
+		a.mu.Unlock()
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// SearchQuota 配额中间件：按Key限制每分钟搜索次数和refresh=true次数，超出时返回429
+// 需在Authenticate之后使用
+func (a *APIKeyAuth) SearchQuota() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Enabled() {
+			c.Next()
+			return
+		}
+		key := c.GetString(apiKeyContextKey)
+
+		if allowed, wait := a.searchLimiter.Allow(key); !allowed {
+			a.reject(c, key, wait, "搜索请求过于频繁，请稍后重试")
+			return
+		}
+
+		refresh := isRefreshRequest(c)
+		if refresh {
+			if allowed, wait := a.refreshLimiter.Allow(key); !allowed {
+				a.reject(c, key, wait, "强制刷新过于频繁，请稍后重试或去掉refresh参数")
+				return
+			}
+		}
+
+		a.mu.Lock()
+		usage := a.usage[key]
+		usage.Searches++
+		if refresh {
+			usage.Refreshes++
+		}
+		a.mu.Unlock()
+
+		c.Next()
+	}
+}
+
+// RequireAdmin 管理接口中间件：只允许管理员Key访问，未启用认证时管理接口不可用
+func (a *APIKeyAuth) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Enabled() {
+			c.AbortWithStatusJSON(http.StatusForbidden, model.NewErrorResponse(403, "未启用API Key认证，管理接口不可用"))
+			return
+		}
+
+		key := extractAPIKey(c)
+		apiKey, exists := a.keys[key]
+		if !exists || key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, model.NewErrorResponse(401, "缺少或无效的API Key"))
+			return
+		}
+		if !apiKey.Admin {
+			c.AbortWithStatusJSON(http.StatusForbidden, model.NewErrorResponse(403, "该API Key没有管理权限"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// UsageHandler 返回各API Key的用量统计
+func (a *APIKeyAuth) UsageHandler(c *gin.Context) {
+	a.mu.Lock()
+	usages := make([]APIKeyUsage, 0, len(a.usage))
+	for _, usage := range a.usage {
+		usages = append(usages, *usage)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Requests > usages[j].Requests
+	})
+
+	search, refresh := config.GetAPIKeyRateLimits()
+	jsonData, _ := jsonutil.Marshal(model.NewSuccessResponse(gin.H{
+		"search_per_minute":  search,
+		"refresh_per_minute": refresh,
+		"keys":               usages,
+	}))
+	c.Data(http.StatusOK, "application/json", jsonData)
+}
+
+// reject 记录拒绝次数并返回429
+func (a *APIKeyAuth) reject(c *gin.Context, key string, wait time.Duration, message string) {
+	a.mu.Lock()
+	a.usage[key].Rejected++
+	a.mu.Unlock()
+
+	abortTooManyRequests(c, wait, message)
+}
+
+// abortTooManyRequests 返回429并设置Retry-After（秒，向上取整）
+func abortTooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, model.NewErrorResponse(429, message))
+}
+
+// extractAPIKey 从请求头或查询参数中获取API Key
+func extractAPIKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+		return key
+	}
+	if authorization := c.GetHeader("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	return strings.TrimSpace(c.Query("api_key"))
+}
+
+// isRefreshRequest 判断搜索请求是否要求强制刷新，结果保存在上下文中供后续中间件复用
+// POST请求需要读取请求体，读取后恢复以便处理函数再次读取
+func isRefreshRequest(c *gin.Context) bool {
+	if refresh, exists := c.Get(refreshContextKey); exists {
+		return refresh.(bool)
+	}
+
+	refresh := false
+	if c.Request.Method == http.MethodGet {
+		refresh = c.Query("refresh") == "true"
+	} else if data, err := io.ReadAll(c.Request.Body); err == nil {
+		c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+		var body struct {
+			Refresh bool `json:"refresh"`
+		}
+		if jsonutil.Unmarshal(data, &body) == nil {
+			refresh = body.Refresh
+		}
+	}
+
+	c.Set(refreshContextKey, refresh)
+	return refresh
+}
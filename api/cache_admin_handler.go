@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pansou/model"
+	"pansou/service"
+)
+
+// AdminCacheRequest 预热缓存的请求体，参数含义与搜索接口相同
+type AdminCacheRequest struct {
+	Keyword  string   `json:"kw"`
+	Channels []string `json:"channels"`
+	Plugins  []string `json:"plugins"`
+}
+
+// GetAdminCacheHandler 查看关键词在指定频道和插件下的缓存状态
+func GetAdminCacheHandler(c *gin.Context) {
+	req := parseAdminCacheQuery(c)
+	entries, err := searchService.InspectCache(req.Keyword, req.Channels, req.Plugins)
+	if err != nil {
+		abortCacheAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, entries)
+}
+
+// DeleteAdminCacheHandler 删除关键词在指定频道和插件下的缓存
+func DeleteAdminCacheHandler(c *gin.Context) {
+	req := parseAdminCacheQuery(c)
+	result, err := searchService.InvalidateKeyword(req.Keyword, req.Channels, req.Plugins)
+	if err != nil {
+		abortCacheAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, result)
+}
+
+// DeleteAdminPluginCacheHandler 删除包含指定插件结果的缓存
+func DeleteAdminPluginCacheHandler(c *gin.Context) {
+	result, err := searchService.InvalidatePlugin(c.Param("name"))
+	if err != nil {
+		abortCacheAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, result)
+}
+
+// ClearAdminCacheHandler 清空所有缓存
+func ClearAdminCacheHandler(c *gin.Context) {
+	result, err := searchService.ClearCache()
+	if err != nil {
+		abortCacheAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, result)
+}
+
+// GetAdminCacheStatsHandler 各层缓存的条目数和各分片的字节数
+func GetAdminCacheStatsHandler(c *gin.Context) {
+	stats, err := searchService.CacheStats()
+	if err != nil {
+		abortCacheAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, stats)
+}
+
+// GetAdminCacheTrendingHandler 热门关键词及其衰减后的搜索次数，未启用缓存预热时为空列表
+func GetAdminCacheTrendingHandler(c *gin.Context) {
+	writeAdminResponse(c, searchService.TrendingKeywords())
+}
+
+// WarmAdminCacheHandler 强制刷新搜索一次，把最新结果写入缓存
+func WarmAdminCacheHandler(c *gin.Context) {
+	var req AdminCacheRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+	result, err := searchService.WarmCache(c.Request.Context(), req.Keyword, req.Channels, req.Plugins)
+	if errors.Is(err, context.Canceled) {
+		c.AbortWithStatus(499)
+		return
+	}
+	if err != nil {
+		abortCacheAdminError(c, err)
+		return
+	}
+	writeAdminResponse(c, result)
+}
+
+// parseAdminCacheQuery 从URL参数解析kw、channels和plugins，列表参数使用英文逗号分隔
+func parseAdminCacheQuery(c *gin.Context) AdminCacheRequest {
+	return AdminCacheRequest{
+		Keyword:  c.Query("kw"),
+		Channels: splitQueryList(c.Query("channels")),
+		Plugins:  splitQueryList(c.Query("plugins")),
+	}
+}
+
+// splitQueryList 按英文逗号分隔，去除空白和空项
+func splitQueryList(value string) []string {
+	var items []string
+	for _, part := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
+// abortCacheAdminError 未启用缓存返回503，参数错误返回400，其他错误返回500
+func abortCacheAdminError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrCacheDisabled) {
+		c.JSON(http.StatusServiceUnavailable, model.NewErrorResponse(503, err.Error()))
+		return
+	}
+	if errors.Is(err, service.ErrEmptyQueryKeyword) {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "缺少kw参数"))
+		return
+	}
+	if errors.Is(err, service.ErrTooManyColdSearches) {
+		abortTooManyRequests(c, coldSearchRetryAfter, err.Error())
+		return
+	}
+	abortAdminError(c, err)
+}
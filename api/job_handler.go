@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pansou/model"
+	"pansou/service"
+	jsonutil "pansou/util/json"
+)
+
+// 保存异步搜索任务管理器的实例
+var searchJobManager *service.SearchJobManager
+
+// SetSearchJobManager 设置异步搜索任务管理器实例
+func SetSearchJobManager(manager *service.SearchJobManager) {
+	searchJobManager = manager
+}
+
+// CreateSearchJobHandler 创建异步搜索任务
+// 请求体与POST /api/search相同，立即返回任务ID，结果通过GET /api/search/jobs/:id轮询获取
+func CreateSearchJobHandler(c *gin.Context) {
+	var req model.SearchRequest
+
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "读取请求数据失败: "+err.Error()))
+		return
+	}
+	if err := jsonutil.Unmarshal(data, &req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "无效的请求参数: "+err.Error()))
+		return
+	}
+	service.NormalizeSearchRequest(&req)
+
+	if strings.TrimSpace(req.Keyword) == "" {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "关键词不能为空"))
+		return
+	}
+
+	job, err := searchJobManager.Create(req.Keyword, req.Channels, req.Concurrency, req.ForceRefresh, req.SourceType, req.Plugins, req.CloudTypes, req.Sort, req.Ext)
+	if errors.Is(err, service.ErrEmptyQueryKeyword) {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, model.NewErrorResponse(503, err.Error()))
+		return
+	}
+
+	jsonData, _ := jsonutil.Marshal(model.NewSuccessResponse(job))
+	c.Data(http.StatusAccepted, "application/json", jsonData)
+}
+
+// GetSearchJobHandler 获取异步搜索任务的当前快照
+func GetSearchJobHandler(c *gin.Context) {
+	job, exists := searchJobManager.Get(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, model.NewErrorResponse(404, "任务不存在或已过期"))
+		return
+	}
+
+	jsonData, _ := jsonutil.Marshal(model.NewSuccessResponse(job))
+	c.Data(http.StatusOK, "application/json", jsonData)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pansou/mcp"
+	"pansou/model"
+	jsonutil "pansou/util/json"
+)
+
+// 保存MCP服务的实例
+var mcpServer *mcp.Server
+
+// SetMCPServer 设置MCP服务实例
+func SetMCPServer(server *mcp.Server) {
+	mcpServer = server
+}
+
+// MCPHandler MCP的Streamable HTTP传输
+// 客户端POST JSON-RPC消息，请求直接以JSON响应；只包含通知或响应时返回202
+func MCPHandler(c *gin.Context) {
+	if c.Request.Method != http.MethodPost {
+		// 不提供服务端主动推送的SSE流
+		c.Header("Allow", http.MethodPost)
+		c.JSON(http.StatusMethodNotAllowed, model.NewErrorResponse(405, "仅支持POST请求"))
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "读取请求数据失败: "+err.Error()))
+		return
+	}
+
+	response := mcpServer.HandleMessage(data)
+	if response == nil {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+// MCPSearchOnly 只对调用search工具的MCP请求执行限流和配额中间件
+// initialize、tools/list等请求不计入搜索次数；search工具参数中refresh为true时按强制刷新计数。
+// 一次请求只计一次搜索，因此批量请求中最多允许一次search调用
+func MCPSearchOnly(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		searches, refresh := inspectMCPSearch(c)
+		if searches == 0 {
+			c.Next()
+			return
+		}
+		if searches > 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, model.NewErrorResponse(400, "批量请求中最多包含一次search工具调用"))
+			return
+		}
+
+		c.Set(refreshContextKey, refresh)
+		handler(c)
+	}
+}
+
+// inspectMCPSearch 统计MCP请求中search工具调用的次数，以及是否有调用要求强制刷新
+// 读取后恢复请求体，供后续处理函数使用
+func inspectMCPSearch(c *gin.Context) (int, bool) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return 0, false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+	var messages []json.RawMessage
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if jsonutil.Unmarshal(trimmed, &messages) != nil {
+			return 0, false
+		}
+	} else {
+		messages = []json.RawMessage{trimmed}
+	}
+
+	searches, refresh := 0, false
+	for _, message := range messages {
+		var request struct {
+			Method string `json:"method"`
+			Params struct {
+				Name      string `json:"name"`
+				Arguments struct {
+					Refresh bool `json:"refresh"`
+				} `json:"arguments"`
+			} `json:"params"`
+		}
+		if jsonutil.Unmarshal(message, &request) != nil {
+			continue
+		}
+		if request.Method == "tools/call" && request.Params.Name == "search" {
+			searches++
+			refresh = refresh || request.Params.Arguments.Refresh
+		}
+	}
+	return searches, refresh
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+	"pansou/util/metrics"
+)
+
+func init() {
+	metrics.NewGaugeFunc("pansou_goroutines", "当前goroutine数量", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	metrics.NewGaugeFunc("pansou_heap_alloc_bytes", "堆上已分配且仍在使用的字节数", func() float64 {
+		var memStats runtime.MemStats
+		runtime.ReadMemStats(&memStats)
+		return float64(memStats.HeapAlloc)
+	})
+}
+
+// MetricsHandler 以Prometheus text格式输出指标
+func MetricsHandler(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", metrics.ContentType)
+	metrics.Default.WriteText(c.Writer)
+}
@@ -0,0 +1,492 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"pansou/model"
+	"pansou/plugin"
+	jsonutil "pansou/util/json"
+	"pansou/util/schema"
+)
+
+// OpenAPI文档缓存，首次请求时生成
+var (
+	openAPIOnce sync.Once
+	openAPIData []byte
+)
+
+// 响应模型的字段说明
+var (
+	searchResponseDescriptions = map[string]string{
+		"total":          "结果总数：res=merge时为链接总数，否则为results数量",
+		"results":        "搜索结果，res=all或results时返回",
+		"merged_by_type": "按网盘类型分组的链接，键为网盘类型",
+		"sources":        "各数据源诊断信息，仅debug模式返回",
+		"next_cursor":    "下一页游标，分页时返回，没有更多数据时为空",
+		"cache_age":      "结果来自缓存时距写入缓存的秒数",
+		"stale":          "缓存已超过有效期（CACHE_TTL），返回的是过期缓存，后台刷新中",
+	}
+	mergedLinkDescriptions = map[string]string{
+		"note":   "资源标题",
+		"source": "数据来源：tg:频道名 或 plugin:插件名",
+		"images": "TG消息中的图片链接",
+	}
+	searchResultDescriptions = map[string]string{
+		"unique_id": "全局唯一ID，插件结果格式为 插件名-ID",
+		"images":    "TG消息中的图片链接",
+	}
+)
+
+// OpenAPIHandler 返回OpenAPI 3文档
+func OpenAPIHandler(c *gin.Context) {
+	openAPIOnce.Do(func() {
+		openAPIData, _ = jsonutil.Marshal(buildOpenAPISpec())
+	})
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIData)
+}
+
+// APIDocsHandler Swagger UI页面
+func APIDocsHandler(c *gin.Context) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, swaggerUIPage)
+}
+
+// buildOpenAPISpec 生成OpenAPI文档
+// 请求/响应结构由model中的类型反射生成，GET查询参数由SearchRequest字段生成
+func buildOpenAPISpec() map[string]interface{} {
+	gen := schema.NewGenerator("#/components/schemas/").
+		Describe(model.SearchRequest{}, model.SearchRequestDescriptions).
+		Describe(model.SearchResponse{}, searchResponseDescriptions).
+		Describe(model.MergedLink{}, mergedLinkDescriptions).
+		Describe(model.SearchResult{}, searchResultDescriptions)
+
+	searchRequestRef := gen.Schema(model.SearchRequest{})
+	searchResponseRef := gen.Schema(model.SearchResponse{})
+	searchJobRef := gen.Schema(model.SearchJob{})
+	searchEventRef := gen.Schema(model.SearchEvent{})
+	errorRef := gen.Schema(model.Response{})
+
+	healthSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status":          map[string]interface{}{"type": "string"},
+			"plugins_enabled": map[string]interface{}{"type": "boolean"},
+			"plugin_count":    map[string]interface{}{"type": "integer", "description": "插件数量，仅插件启用时返回"},
+			"plugins":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "插件列表，仅插件启用时返回"},
+			"plugin_health":   map[string]interface{}{"type": "array", "items": gen.Schema(plugin.PluginHealth{}), "description": "各插件的成功率、耗时和熔断状态，仅插件启用时返回"},
+			"channels":        map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"channels_count":  map[string]interface{}{"type": "integer"},
+		},
+	}
+
+	searchQueryParams := searchQueryParameters()
+	errorResponses := map[string]interface{}{
+		"400": jsonResponse("参数错误", errorRef),
+		"500": jsonResponse("服务器错误", errorRef),
+	}
+
+	adminErrorResponses := withResponses(errorResponses,
+		"401", jsonResponse("缺少或无效的API Key", errorRef),
+		"403", jsonResponse("没有管理权限", errorRef))
+
+	cacheAdminResponses := withResponses(adminErrorResponses,
+		"503", jsonResponse("未启用缓存", errorRef))
+	cacheQueryParams := []interface{}{
+		map[string]interface{}{
+			"name": "kw", "in": "query", "required": true, "description": "搜索关键词",
+			"schema": map[string]interface{}{"type": "string"},
+		},
+		map[string]interface{}{
+			"name": "channels", "in": "query", "description": "频道列表，英文逗号分隔，不指定时使用默认频道",
+			"schema": map[string]interface{}{"type": "string"},
+		},
+		map[string]interface{}{
+			"name": "plugins", "in": "query", "description": "插件列表，英文逗号分隔，不指定时表示全部启用的插件",
+			"schema": map[string]interface{}{"type": "string"},
+		},
+	}
+	cacheInvalidationRef := gen.Schema(model.CacheInvalidation{})
+
+	paths := map[string]interface{}{
+		"/api/search": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "搜索（GET）",
+				"operationId": "searchGet",
+				"parameters":  searchQueryParams,
+				"responses":   withResponses(errorResponses, "200", jsonResponse("搜索结果", envelope(searchResponseRef))),
+			},
+			"post": map[string]interface{}{
+				"summary":     "搜索（POST）",
+				"operationId": "searchPost",
+				"requestBody": jsonRequestBody(searchRequestRef),
+				"responses":   withResponses(errorResponses, "200", jsonResponse("搜索结果", envelope(searchResponseRef))),
+			},
+		},
+		"/api/search/stream": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "流式搜索（Server-Sent Events）",
+				"description": "每个TG频道或插件完成时推送source事件，全部完成后推送done事件，事件data为SearchEvent",
+				"operationId": "searchStream",
+				"parameters":  searchQueryParams,
+				"responses": withResponses(errorResponses, "200", map[string]interface{}{
+					"description": "事件流",
+					"content": map[string]interface{}{
+						"text/event-stream": map[string]interface{}{"schema": searchEventRef},
+					},
+				}),
+			},
+		},
+		"/api/search/jobs": map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "创建异步搜索任务",
+				"operationId": "createSearchJob",
+				"requestBody": jsonRequestBody(searchRequestRef),
+				"responses": withResponses(errorResponses,
+					"202", jsonResponse("任务已创建", envelope(searchJobRef)),
+					"503", jsonResponse("任务数已达上限", errorRef)),
+			},
+		},
+		"/api/search/jobs/{id}": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "查询异步搜索任务",
+				"operationId": "getSearchJob",
+				"parameters": []interface{}{
+					map[string]interface{}{
+						"name": "id", "in": "path", "required": true,
+						"schema": map[string]interface{}{"type": "string"},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": jsonResponse("任务快照", envelope(searchJobRef)),
+					"404": jsonResponse("任务不存在或已过期", errorRef),
+				},
+			},
+		},
+		"/api/admin/usage": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "API Key用量统计",
+				"description": "需要带admin标记的API Key",
+				"operationId": "adminUsage",
+				"responses": map[string]interface{}{
+					"200": jsonResponse("各Key用量", envelope(map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"search_per_minute":  map[string]interface{}{"type": "integer"},
+							"refresh_per_minute": map[string]interface{}{"type": "integer"},
+							"keys":               map[string]interface{}{"type": "array", "items": gen.Schema(APIKeyUsage{})},
+						},
+					})),
+					"401": jsonResponse("缺少或无效的API Key", errorRef),
+					"403": jsonResponse("没有管理权限", errorRef),
+				},
+			},
+		},
+		"/api/admin/plugins": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "获取启用的插件",
+				"description": "需要带admin标记的API Key",
+				"operationId": "adminGetPlugins",
+				"responses":   withResponses(adminErrorResponses, "200", jsonResponse("插件启用状态", envelope(gen.Schema(AdminPlugins{})))),
+			},
+			"put": map[string]interface{}{
+				"summary":     "替换启用的插件",
+				"description": "立即对新的搜索生效，配置STATE_FILE时保存并在重启后恢复",
+				"operationId": "adminUpdatePlugins",
+				"requestBody": jsonRequestBody(gen.Schema(AdminPluginsUpdate{})),
+				"responses":   withResponses(adminErrorResponses, "200", jsonResponse("修改后的插件启用状态", envelope(gen.Schema(AdminPlugins{})))),
+			},
+		},
+		"/api/admin/channels": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "获取默认搜索频道",
+				"description": "需要带admin标记的API Key",
+				"operationId": "adminGetChannels",
+				"responses":   withResponses(adminErrorResponses, "200", jsonResponse("默认搜索频道", envelope(gen.Schema(AdminChannels{})))),
+			},
+			"put": map[string]interface{}{
+				"summary":     "替换默认搜索频道",
+				"description": "立即对未指定频道的搜索生效，配置STATE_FILE时保存并在重启后恢复",
+				"operationId": "adminUpdateChannels",
+				"requestBody": jsonRequestBody(gen.Schema(AdminChannels{})),
+				"responses":   withResponses(adminErrorResponses, "200", jsonResponse("修改后的默认搜索频道", envelope(gen.Schema(AdminChannels{})))),
+			},
+		},
+		"/api/admin/cache": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "查看关键词的缓存状态",
+				"description": "返回TG和插件搜索缓存键在各层中的大小、写入时间和剩余有效期",
+				"operationId": "adminGetCache",
+				"parameters":  cacheQueryParams,
+				"responses": withResponses(cacheAdminResponses, "200", jsonResponse("缓存状态", envelope(map[string]interface{}{
+					"type": "array", "items": gen.Schema(model.CacheEntry{}),
+				}))),
+			},
+			"delete": map[string]interface{}{
+				"summary":     "删除关键词的缓存",
+				"description": "删除指定频道和插件下的缓存键，以及各插件内部缓存的该关键词结果",
+				"operationId": "adminDeleteCache",
+				"parameters":  cacheQueryParams,
+				"responses":   withResponses(cacheAdminResponses, "200", jsonResponse("删除的缓存", envelope(cacheInvalidationRef))),
+			},
+		},
+		"/api/admin/cache/plugins/{name}": map[string]interface{}{
+			"delete": map[string]interface{}{
+				"summary":     "删除包含指定插件结果的缓存",
+				"description": "遍历内存和本地磁盘缓存，只存在于远程缓存中的键不会被删除",
+				"operationId": "adminDeletePluginCache",
+				"parameters": []interface{}{
+					map[string]interface{}{
+						"name": "name", "in": "path", "required": true,
+						"schema": map[string]interface{}{"type": "string"},
+					},
+				},
+				"responses": withResponses(cacheAdminResponses, "200", jsonResponse("删除的缓存", envelope(cacheInvalidationRef))),
+			},
+		},
+		"/api/admin/cache/clear": map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "清空所有缓存",
+				"description": "清空内存、磁盘、远程缓存（仅本服务前缀的键）和插件内部缓存",
+				"operationId": "adminClearCache",
+				"responses":   withResponses(cacheAdminResponses, "200", jsonResponse("清空结果", envelope(cacheInvalidationRef))),
+			},
+		},
+		"/api/admin/cache/stats": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "缓存统计",
+				"description": "各层的条目数和字节数，内存和磁盘缓存包括各分片的统计",
+				"operationId": "adminCacheStats",
+				"responses":   withResponses(cacheAdminResponses, "200", jsonResponse("缓存统计", envelope(gen.Schema(model.CacheStats{})))),
+			},
+		},
+		"/api/admin/cache/trending": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "热门关键词",
+				"description": "缓存预热使用的热门搜索及其衰减后的搜索次数，未启用缓存预热（CACHE_WARMER_ENABLED）时为空列表",
+				"operationId": "adminCacheTrending",
+				"responses": withResponses(adminErrorResponses, "200", jsonResponse("热门关键词", envelope(map[string]interface{}{
+					"type": "array", "items": gen.Schema(model.TrendingKeyword{}),
+				}))),
+			},
+		},
+		"/api/admin/cache/warm": map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "预热缓存",
+				"description": "强制刷新搜索一次并写入缓存",
+				"operationId": "adminWarmCache",
+				"requestBody": jsonRequestBody(gen.Schema(AdminCacheRequest{})),
+				"responses": withResponses(cacheAdminResponses,
+					"200", jsonResponse("搜索结果数和耗时", envelope(gen.Schema(model.CacheWarmResult{}))),
+					"429", jsonResponse("实时搜索请求过多", errorRef)),
+			},
+		},
+		"/api/admin/config": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "获取当前生效的配置",
+				"description": "键为下划线风格的配置字段名，API Key、代理密码和插件的敏感配置项已脱敏",
+				"operationId": "adminGetConfig",
+				"responses":   withResponses(adminErrorResponses, "200", jsonResponse("当前配置", envelope(map[string]interface{}{"type": "object"}))),
+			},
+		},
+		"/api/health": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "健康检查",
+				"operationId": "health",
+				"responses": map[string]interface{}{
+					"200": jsonResponse("服务状态", healthSchema),
+				},
+			},
+		},
+		"/api/openapi.json": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "OpenAPI文档",
+				"operationId": "openapi",
+				"responses": map[string]interface{}{
+					"200": jsonResponse("OpenAPI 3文档", map[string]interface{}{"type": "object"}),
+				},
+			},
+		},
+		"/api/docs": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "Swagger UI文档页面",
+				"operationId": "apiDocs",
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "HTML页面",
+						"content":     map[string]interface{}{"text/html": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+					},
+				},
+			},
+		},
+		"/metrics": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "Prometheus指标",
+				"description": "Prometheus text格式的指标，包括HTTP请求、插件搜索、缓存和Go运行时指标",
+				"operationId": "metrics",
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Prometheus text格式",
+						"content":     map[string]interface{}{"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+					},
+				},
+			},
+		},
+		"/mcp": map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "MCP服务（不支持SSE推送）",
+				"description": "不提供服务端主动推送的SSE流，固定返回405",
+				"operationId": "mcpGet",
+				"responses": map[string]interface{}{
+					"405": jsonResponse("仅支持POST请求", errorRef),
+				},
+			},
+			"post": map[string]interface{}{
+				"summary":     "MCP服务（Streamable HTTP）",
+				"description": "JSON-RPC 2.0消息，支持initialize、tools/list、tools/call等方法",
+				"operationId": "mcp",
+				"requestBody": jsonRequestBody(map[string]interface{}{"type": "object"}),
+				"responses": map[string]interface{}{
+					"200": jsonResponse("JSON-RPC响应", map[string]interface{}{"type": "object"}),
+					"202": map[string]interface{}{"description": "消息只包含通知，无响应内容"},
+				},
+			},
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "PanSou API",
+			"description": "网盘资源搜索API，支持TG频道和插件搜索",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": gen.Components(),
+		},
+	}
+}
+
+// searchQueryParameters 由SearchRequest字段生成GET查询参数
+// 数组参数使用英文逗号分隔，ext为JSON字符串
+func searchQueryParameters() []interface{} {
+	params := []interface{}{}
+	t := reflect.TypeOf(model.SearchRequest{})
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		param := map[string]interface{}{
+			"name": name,
+			"in":   "query",
+		}
+		if description, ok := model.SearchRequestDescriptions[name]; ok {
+			param["description"] = description
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Slice:
+			param["schema"] = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+			param["style"] = "form"
+			param["explode"] = false
+		case reflect.Map:
+			param["schema"] = map[string]interface{}{"type": "string"}
+			param["description"] = "JSON格式的" + model.SearchRequestDescriptions[name]
+		case reflect.Bool:
+			param["schema"] = map[string]interface{}{"type": "boolean"}
+		case reflect.Int:
+			param["schema"] = map[string]interface{}{"type": "integer"}
+		default:
+			param["schema"] = map[string]interface{}{"type": "string"}
+		}
+		params = append(params, param)
+
+		// kw兼容keyword参数名
+		if name == "kw" {
+			params = append(params, map[string]interface{}{
+				"name":        "keyword",
+				"in":          "query",
+				"description": "kw的别名",
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return params
+}
+
+// envelope 生成model.Response包装后的成功响应Schema
+func envelope(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"code":    map[string]interface{}{"type": "integer", "description": "0表示成功"},
+			"message": map[string]interface{}{"type": "string"},
+			"data":    data,
+		},
+	}
+}
+
+// jsonResponse 生成JSON响应定义
+func jsonResponse(description string, schemaDef map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemaDef},
+		},
+	}
+}
+
+// jsonRequestBody 生成JSON请求体定义
+func jsonRequestBody(schemaDef map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"required": true,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemaDef},
+		},
+	}
+}
+
+// withResponses 在公共响应基础上追加状态码及响应，参数为状态码和响应交替出现
+func withResponses(base map[string]interface{}, pairs ...interface{}) map[string]interface{} {
+	responses := make(map[string]interface{}, len(base)+len(pairs)/2)
+	for code, response := range base {
+		responses[code] = response
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		responses[pairs[i].(string)] = pairs[i+1]
+	}
+	return responses
+}
+
+// swaggerUIPage Swagger UI页面，静态资源从CDN加载
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>PanSou API 文档</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({
+        url: "/api/openapi.json",
+        dom_id: "#swagger-ui",
+        deepLinking: true
+      });
+    };
+  </script>
+</body>
+</html>
+`
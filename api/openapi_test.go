@@ -0,0 +1,54 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"pansou/config"
+)
+
+// 不需要出现在API文档中的页面和静态资源路由
+var undocumentedRoutes = map[string]bool{
+	"GET /ads.txt":           true,
+	"GET /robots.txt":        true,
+	"GET /sitemap.xml":       true,
+	"GET /privacy.html":      true,
+	"GET /terms.html":        true,
+	"GET /static/*filepath":  true,
+	"HEAD /static/*filepath": true,
+}
+
+var routeParamPattern = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	config.Init()
+	r := SetupRouter(nil)
+
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		key := route.Method + " " + route.Path
+		if undocumentedRoutes[key] {
+			continue
+		}
+		routes[route.Method+" "+routeParamPattern.ReplaceAllString(route.Path, "{$1}")] = true
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range buildOpenAPISpec()["paths"].(map[string]interface{}) {
+		for method := range item.(map[string]interface{}) {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	for route := range routes {
+		if !documented[route] {
+			t.Errorf("route %s is not documented in the OpenAPI spec", route)
+		}
+	}
+	for route := range documented {
+		if !routes[route] {
+			t.Errorf("OpenAPI spec documents %s, which is not a registered route", route)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/util/ratelimit"
+)
+
+// coldSearchRetryAfter 冷搜索达到上限时建议客户端等待的时间
+const coldSearchRetryAfter = 5 * time.Second
+
+// ClientRateLimiter 按客户端IP限流
+// 客户端IP由gin的ClientIP获取，只有来自TRUSTED_PROXIES的请求才采用X-Forwarded-For
+type ClientRateLimiter struct {
+	searchLimiter  *ratelimit.KeyedLimiter
+	refreshLimiter *ratelimit.KeyedLimiter
+}
+
+// 保存客户端限流器的实例
+var clientRateLimiter *ClientRateLimiter
+
+// NewClientRateLimiter 根据配置创建客户端限流器
+func NewClientRateLimiter() *ClientRateLimiter {
+	search, refresh := config.GetIPRateLimits()
+	return &ClientRateLimiter{
+		searchLimiter:  ratelimit.NewKeyedLimiter(search, time.Minute),
+		refreshLimiter: ratelimit.NewKeyedLimiter(refresh, time.Minute),
+	}
+}
+
+// UpdateLimits 配置重新加载后更新每个IP的限制
+func (l *ClientRateLimiter) UpdateLimits() {
+	search, refresh := config.GetIPRateLimits()
+	l.searchLimiter.SetCapacity(search)
+	l.refreshLimiter.SetCapacity(refresh)
+}
+
+// SearchLimit 限流中间件：按IP限制每分钟搜索次数，refresh=true额外受更严格的限制，超出时返回429
+func (l *ClientRateLimiter) SearchLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+
+		if allowed, wait := l.searchLimiter.Allow(ip); !allowed {
+			abortTooManyRequests(c, wait, "请求过于频繁，请稍后重试")
+			return
+		}
+		if _, refresh := config.GetIPRateLimits(); refresh > 0 && isRefreshRequest(c) {
+			if allowed, wait := l.refreshLimiter.Allow(ip); !allowed {
+				abortTooManyRequests(c, wait, "强制刷新过于频繁，请稍后重试或去掉refresh参数")
+				return
+			}
+		}
+		c.Next()
+	}
+}
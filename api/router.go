@@ -1,9 +1,12 @@
 package api
 
 import (
+	"fmt"
 	"strings"
 	"github.com/gin-gonic/gin"
 	"pansou/config"
+	"pansou/mcp"
+	"pansou/plugin"
 	"pansou/service"
 	"pansou/util"
 )
@@ -12,6 +15,19 @@ import (
 func SetupRouter(searchService *service.SearchService) *gin.Engine {
 	// 设置搜索服务
 	SetSearchService(searchService)
+	// 设置异步搜索任务管理器
+	SetSearchJobManager(service.NewSearchJobManager(searchService))
+	// 设置MCP服务
+	SetMCPServer(mcp.NewServer(searchService))
+	// 设置API Key认证，未配置Key时不启用
+	apiKeyAuth = NewAPIKeyAuth()
+	// 设置客户端IP限流
+	clientRateLimiter = NewClientRateLimiter()
+	// 重新加载配置后更新限流参数
+	config.OnReload(func(changed map[string]bool) {
+		apiKeyAuth.UpdateLimits()
+		clientRateLimiter.UpdateLimits()
+	})
 	
 	// 设置为生产模式
 	gin.SetMode(gin.ReleaseMode)
@@ -19,6 +35,12 @@ func SetupRouter(searchService *service.SearchService) *gin.Engine {
 	// 创建默认路由
 	r := gin.Default()
 	
+	// 只信任来自指定代理的X-Forwarded-For，客户端IP用于日志和限流
+	if err := r.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		fmt.Printf("⚠️ TRUSTED_PROXIES配置无效，不信任任何代理: %v\n", err)
+		r.SetTrustedProxies(nil)
+	}
+	
 	// 添加中间件
 	r.Use(CORSMiddleware())
 	r.Use(LoggerMiddleware())
@@ -64,14 +86,48 @@ func SetupRouter(searchService *service.SearchService) *gin.Engine {
 	api := r.Group("/api")
 	{
 		// 搜索接口 - 支持POST和GET两种方式
-		api.POST("/search", SearchHandler)
-		api.GET("/search", SearchHandler) // 添加GET方式支持
+		api.POST("/search", clientRateLimiter.SearchLimit(), apiKeyAuth.Authenticate(), apiKeyAuth.SearchQuota(), SearchHandler)
+		api.GET("/search", clientRateLimiter.SearchLimit(), apiKeyAuth.Authenticate(), apiKeyAuth.SearchQuota(), SearchHandler) // 添加GET方式支持
+		
+		// 流式搜索接口 - Server-Sent Events，按来源推送部分结果
+		api.GET("/search/stream", clientRateLimiter.SearchLimit(), apiKeyAuth.Authenticate(), apiKeyAuth.SearchQuota(), SearchStreamHandler)
+		
+		// 异步搜索任务接口 - 创建任务后轮询获取合并快照
+		api.POST("/search/jobs", clientRateLimiter.SearchLimit(), apiKeyAuth.Authenticate(), apiKeyAuth.SearchQuota(), CreateSearchJobHandler)
+		api.GET("/search/jobs/:id", apiKeyAuth.Authenticate(), GetSearchJobHandler)
+		
+		// 管理接口 - 需要管理员API Key
+		admin := api.Group("/admin", apiKeyAuth.RequireAdmin())
+		{
+			admin.GET("/usage", apiKeyAuth.UsageHandler)
+			admin.GET("/config", GetAdminConfigHandler)
+			
+			// 运行时启用/停用插件和修改默认频道
+			admin.GET("/plugins", GetAdminPluginsHandler)
+			admin.PUT("/plugins", UpdateAdminPluginsHandler)
+			admin.GET("/channels", GetAdminChannelsHandler)
+			admin.PUT("/channels", UpdateAdminChannelsHandler)
+			
+			// 缓存管理：查看、删除、清空、统计、热门关键词和预热
+			admin.GET("/cache", GetAdminCacheHandler)
+			admin.DELETE("/cache", DeleteAdminCacheHandler)
+			admin.DELETE("/cache/plugins/:name", DeleteAdminPluginCacheHandler)
+			admin.POST("/cache/clear", ClearAdminCacheHandler)
+			admin.GET("/cache/stats", GetAdminCacheStatsHandler)
+			admin.GET("/cache/trending", GetAdminCacheTrendingHandler)
+			admin.POST("/cache/warm", WarmAdminCacheHandler)
+		}
+		
+		// API文档 - OpenAPI 3文档及Swagger UI页面
+		api.GET("/openapi.json", OpenAPIHandler)
+		api.GET("/docs", APIDocsHandler)
 		
 		// 健康检查接口
 		api.GET("/health", func(c *gin.Context) {
 			// 根据配置决定是否返回插件信息
 			pluginCount := 0
 			pluginNames := []string{}
+			pluginHealth := []plugin.PluginHealth{}
 			pluginsEnabled := config.AppConfig.AsyncPluginEnabled
 			
 			if pluginsEnabled && searchService != nil && searchService.GetPluginManager() != nil {
@@ -80,10 +136,11 @@ func SetupRouter(searchService *service.SearchService) *gin.Engine {
 				for _, p := range plugins {
 					pluginNames = append(pluginNames, p.Name())
 				}
+				pluginHealth = searchService.GetPluginManager().PluginHealth()
 			}
 			
 			// 获取频道信息
-			channels := config.AppConfig.DefaultChannels
+			channels := config.GetDefaultChannels()
 			channelsCount := len(channels)
 			
 			response := gin.H{
@@ -97,12 +154,20 @@ func SetupRouter(searchService *service.SearchService) *gin.Engine {
 			if pluginsEnabled {
 				response["plugin_count"] = pluginCount
 				response["plugins"] = pluginNames
+				response["plugin_health"] = pluginHealth
 			}
 			
 			c.JSON(200, response)
 		})
 	}
 	
+	// Prometheus指标
+	r.GET("/metrics", MetricsHandler)
+	
+	// MCP服务 - Streamable HTTP传输，search工具调用与搜索接口共用限流和配额
+	r.POST("/mcp", MCPSearchOnly(clientRateLimiter.SearchLimit()), apiKeyAuth.Authenticate(), MCPSearchOnly(apiKeyAuth.SearchQuota()), MCPHandler)
+	r.GET("/mcp", MCPHandler)
+	
 	// 静态文件服务 - 提供CSS、JS、图片等静态资源
 	r.Static("/static", "./static")
 	
@@ -112,14 +177,19 @@ func SetupRouter(searchService *service.SearchService) *gin.Engine {
 		
 		// 如果是API请求但没有匹配到路由，返回404 JSON响应
 		if strings.HasPrefix(path, "/api") {
+			// 可用接口列表从已注册的路由生成，完整说明见/api/openapi.json
+			availableEndpoints := []string{}
+			for _, route := range r.Routes() {
+				if strings.HasPrefix(route.Path, "/api") {
+					availableEndpoints = append(availableEndpoints, route.Method+" "+route.Path)
+				}
+			}
+			
 			c.JSON(404, gin.H{
 				"error": "API endpoint not found",
 				"path": path,
-				"available_endpoints": []string{
-					"GET /api/health",
-					"GET /api/search",
-					"POST /api/search",
-				},
+				"available_endpoints": availableEndpoints,
+				"docs": "/api/docs",
 			})
 			return
 		}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pansou/model"
+	"pansou/service"
+	jsonutil "pansou/util/json"
+)
+
+// SearchStreamHandler 流式搜索处理函数（Server-Sent Events）
+// 参数与GET /api/search相同，每个TG频道/插件完成时推送一个source事件，最后推送done事件
+func SearchStreamHandler(c *gin.Context) {
+	req, err := parseSearchQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, err.Error()))
+		return
+	}
+	service.NormalizeSearchRequest(&req)
+
+	if strings.TrimSpace(req.Keyword) == "" {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "关键词不能为空"))
+		return
+	}
+
+	// 设置SSE响应头，禁止代理缓冲
+	c.Header("Content-Type", "text/event-stream; charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// 客户端断开时ctx取消，搜索随之中止
+	ctx := c.Request.Context()
+	clientGone := ctx.Done()
+
+	searchService.SearchStream(ctx, req.Keyword, req.Channels, req.Concurrency, req.ForceRefresh, req.SourceType, req.Plugins, req.CloudTypes, req.Sort, req.Ext, func(event model.SearchEvent) {
+		// 客户端已断开，不再写入
+		select {
+		case <-clientGone:
+			return
+		default:
+		}
+
+		data, err := jsonutil.Marshal(event)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, data)
+		c.Writer.Flush()
+	})
+}
@@ -1,12 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,10 +20,22 @@ type Config struct {
 	ProxyURL           string
 	UseProxy           bool
 	// 缓存相关配置
-	CacheEnabled    bool
-	CachePath       string
-	CacheMaxSizeMB  int
-	CacheTTLMinutes int
+	CacheEnabled         bool
+	CachePath            string
+	CacheMaxSizeMB       int
+	CacheTTLMinutes      int
+	CacheStaleTTLMinutes int // 缓存超过CacheTTLMinutes后仍可返回的时长，期间在后台刷新，0表示不返回过期缓存
+	// 远程缓存配置
+	CacheRemoteURL      string // Redis协议的远程缓存地址，为空时不启用
+	CacheRemoteMode     string // l2：替代本地磁盘缓存；l3：在本地磁盘缓存之后作为多个实例共享的缓存
+	CacheRemotePrefix   string // 远程缓存的键前缀
+	CacheRemoteCompress bool   // 是否压缩远程缓存中1KB以上的值
+	// 热门关键词缓存预热配置
+	CacheWarmerEnabled     bool          // 是否定期预热热门关键词的缓存
+	CacheWarmerTopN        int           // 预热搜索次数最多的前N个关键词
+	CacheWarmerInterval    time.Duration // 检查热门关键词缓存的间隔
+	CacheWarmerConcurrency int           // 同时进行的预热搜索数
+	CacheWarmerHalfLife    time.Duration // 搜索次数的半衰期，越早的搜索权重越低
 	// 压缩相关配置
 	EnableCompression bool
 	MinSizeToCompress int // 最小压缩大小（字节）
@@ -45,29 +59,192 @@ type Config struct {
 	HTTPWriteTimeout time.Duration // 写入超时
 	HTTPIdleTimeout  time.Duration // 空闲超时
 	HTTPMaxConns     int           // 最大连接数
+	// 异步搜索任务配置
+	SearchJobTTL      time.Duration // 任务完成后的保留时间
+	SearchJobMaxCount int           // 最多同时保留的任务数
+	// API Key认证配置
+	APIKeys                []APIKey // 允许访问的API Key，为空时不启用认证
+	APIKeySearchPerMinute  int      // 每个Key每分钟最多搜索次数（0表示不限制）
+	APIKeyRefreshPerMinute int      // 每个Key每分钟最多refresh=true次数（0表示不限制）
+	// 客户端限流配置
+	IPSearchPerMinute  int      // 每个客户端IP每分钟最多搜索次数（0表示不限制）
+	IPRefreshPerMinute int      // 每个客户端IP每分钟最多refresh=true次数（0表示不限制）
+	TrustedProxies     []string // 受信任的代理IP或CIDR，只有来自这些地址的X-Forwarded-For才会被采用
+	MaxColdSearches    int      // 同时进行的冷搜索（缓存未命中）上限（0表示不限制）
+	// 插件熔断配置
+	CircuitFailureThreshold int           // 连续失败多少次后熔断插件（0表示不熔断）
+	CircuitOpenDuration     time.Duration // 熔断后多久放行试探请求
+	// 运行时状态配置
+	StateFile string // 管理接口修改的插件和频道设置的保存路径，为空时不保存
+	// 声明式插件配置
+	PluginsDir string // 声明式插件定义所在的目录，启动时注册其中的插件
+	// 结果排序配置
+	RankWeights RankWeights // 相关性排序中各信号的权重
+	// 关键词匹配配置
+	KeywordMatchPinyin   bool // 是否允许用全拼或拼音首字母匹配中文标题
+	KeywordMatchDistance int  // 关键词模糊匹配允许的最大编辑距离（0表示不启用）
 
 }
 
+// APIKey API Key配置
+type APIKey struct {
+	Key   string // 密钥
+	Name  string // 名称，用于用量统计展示
+	Admin bool   // 是否允许访问管理接口
+}
+
+// RankWeights 相关性排序中各信号的权重，综合得分为各信号（0~1）的加权和
+type RankWeights struct {
+	Relevance float64 // 标题和内容与关键词的文本相关性
+	Recency   float64 // 发布时间的新旧
+	Source    float64 // 数据来源（插件等级）的优先级
+	Links     float64 // 链接质量
+}
+
 // 全局配置实例
 var AppConfig *Config
 
+// runtimeLock 保护运行时可修改的配置项（reloadableFields，以及管理接口修改的默认频道）
+// 这些配置项需通过下面的Get函数读取，其余配置项在启动后不再修改，可以直接读取AppConfig
+var runtimeLock sync.RWMutex
+
+// GetDefaultChannels 获取默认搜索频道，运行时可能被管理接口修改
+// 返回的切片不能修改
+func GetDefaultChannels() []string {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.DefaultChannels
+}
+
+// SetDefaultChannels 替换默认搜索频道
+func SetDefaultChannels(channels []string) {
+	runtimeLock.Lock()
+	defer runtimeLock.Unlock()
+	AppConfig.DefaultChannels = channels
+}
+
+// GetDefaultConcurrency 获取默认并发数
+func GetDefaultConcurrency() int {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.DefaultConcurrency
+}
+
+// GetEnabledPlugins 获取配置中启用的插件列表，nil表示未配置
+// 返回的切片不能修改
+func GetEnabledPlugins() []string {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.EnabledPlugins
+}
+
+// GetPluginTimeout 获取插件超时时间
+func GetPluginTimeout() time.Duration {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.PluginTimeout
+}
+
+// GetAsyncResponseTimeout 获取异步插件的响应超时时间
+func GetAsyncResponseTimeout() time.Duration {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.AsyncResponseTimeoutDur
+}
+
+// SetAsyncResponseTimeout 修改异步插件的响应超时时间
+func SetAsyncResponseTimeout(timeout time.Duration) {
+	runtimeLock.Lock()
+	defer runtimeLock.Unlock()
+	AppConfig.AsyncResponseTimeoutDur = timeout
+	AppConfig.AsyncResponseTimeout = int(timeout / time.Second)
+}
+
+// GetAsyncCacheTTL 获取异步插件缓存的有效期
+func GetAsyncCacheTTL() time.Duration {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return time.Duration(AppConfig.AsyncCacheTTLHours) * time.Hour
+}
+
+// GetAPIKeyRateLimits 获取每个API Key每分钟的搜索次数和强制刷新次数上限
+func GetAPIKeyRateLimits() (search int, refresh int) {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.APIKeySearchPerMinute, AppConfig.APIKeyRefreshPerMinute
+}
+
+// GetIPRateLimits 获取每个客户端IP每分钟的搜索次数和强制刷新次数上限
+func GetIPRateLimits() (search int, refresh int) {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.IPSearchPerMinute, AppConfig.IPRefreshPerMinute
+}
+
+// GetRankWeights 获取相关性排序的权重
+func GetRankWeights() RankWeights {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.RankWeights
+}
+
+// GetKeywordMatch 获取关键词匹配是否允许拼音，以及模糊匹配允许的最大编辑距离
+func GetKeywordMatch() (pinyin bool, maxDistance int) {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return AppConfig.KeywordMatchPinyin, AppConfig.KeywordMatchDistance
+}
+
+// snapshot 复制当前配置，用于需要遍历全部配置项的场景
+func snapshot() Config {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return *AppConfig
+}
+
 // 初始化配置
+// 配置来自环境变量和配置文件（-config参数或CONFIG_FILE），环境变量优先
 func Init() {
+	if err := loadConfigFile(ConfigFile()); err != nil {
+		fmt.Printf("%v，仅使用环境变量配置\n", err)
+	}
+	
+	AppConfig = loadConfig()
+	loadedConfig = *AppConfig
+	
+	// 应用GC配置
+	applyGCSettings()
+}
+
+// loadConfig 根据当前的环境变量和配置文件生成配置
+func loadConfig() *Config {
 	proxyURL := getProxyURL()
 	pluginTimeoutSeconds := getPluginTimeout()
 	asyncResponseTimeoutSeconds := getAsyncResponseTimeout()
 	
-	AppConfig = &Config{
+	return &Config{
 		DefaultChannels:    getDefaultChannels(),
 		DefaultConcurrency: getDefaultConcurrency(),
 		Port:               getPort(),
 		ProxyURL:           proxyURL,
 		UseProxy:           proxyURL != "",
 		// 缓存相关配置
-		CacheEnabled:    getCacheEnabled(),
-		CachePath:       getCachePath(),
-		CacheMaxSizeMB:  getCacheMaxSize(),
-		CacheTTLMinutes: getCacheTTL(),
+		CacheEnabled:         getCacheEnabled(),
+		CachePath:            getCachePath(),
+		CacheMaxSizeMB:       getCacheMaxSize(),
+		CacheTTLMinutes:      getCacheTTL(),
+		CacheStaleTTLMinutes: getCacheStaleTTL(),
+		// 远程缓存配置
+		CacheRemoteURL:      Getenv("CACHE_REMOTE_URL"),
+		CacheRemoteMode:     getCacheRemoteMode(),
+		CacheRemotePrefix:   getCacheRemotePrefix(),
+		CacheRemoteCompress: getCacheRemoteCompress(),
+		// 热门关键词缓存预热配置
+		CacheWarmerEnabled:     getCacheWarmerEnabled(),
+		CacheWarmerTopN:        getCacheWarmerTopN(),
+		CacheWarmerInterval:    getCacheWarmerInterval(),
+		CacheWarmerConcurrency: getCacheWarmerConcurrency(),
+		CacheWarmerHalfLife:    getCacheWarmerHalfLife(),
 		// 压缩相关配置
 		EnableCompression: getEnableCompression(),
 		MinSizeToCompress: getMinSizeToCompress(),
@@ -91,16 +268,36 @@ func Init() {
 		HTTPWriteTimeout: getHTTPWriteTimeout(),
 		HTTPIdleTimeout:  getHTTPIdleTimeout(),
 		HTTPMaxConns:     getHTTPMaxConns(),
-
+		// 异步搜索任务配置
+		SearchJobTTL:      getSearchJobTTL(),
+		SearchJobMaxCount: getSearchJobMaxCount(),
+		// API Key认证配置
+		APIKeys:                getAPIKeys(),
+		APIKeySearchPerMinute:  getAPIKeySearchPerMinute(),
+		APIKeyRefreshPerMinute: getAPIKeyRefreshPerMinute(),
+		// 客户端限流配置
+		IPSearchPerMinute:  getIPSearchPerMinute(),
+		IPRefreshPerMinute: getIPRefreshPerMinute(),
+		TrustedProxies:     getTrustedProxies(),
+		MaxColdSearches:    getMaxColdSearches(),
+		// 插件熔断配置
+		CircuitFailureThreshold: getCircuitFailureThreshold(),
+		CircuitOpenDuration:     getCircuitOpenDuration(),
+		// 运行时状态配置
+		StateFile: Getenv("STATE_FILE"),
+		// 声明式插件配置
+		PluginsDir: getPluginsDir(),
+		// 结果排序配置
+		RankWeights: getRankWeights(),
+		// 关键词匹配配置
+		KeywordMatchPinyin:   getKeywordMatchPinyin(),
+		KeywordMatchDistance: getKeywordMatchDistance(),
 	}
-	
-	// 应用GC配置
-	applyGCSettings()
 }
 
 // 从环境变量获取默认频道列表，如果未设置则使用默认值
 func getDefaultChannels() []string {
-	channelsEnv := os.Getenv("CHANNELS")
+	channelsEnv := Getenv("CHANNELS")
 	if channelsEnv == "" {
 		return []string{"tgsearchers3"}
 	}
@@ -109,7 +306,7 @@ func getDefaultChannels() []string {
 
 // 从环境变量获取默认并发数，如果未设置则使用基于环境变量的简单计算
 func getDefaultConcurrency() int {
-	concurrencyEnv := os.Getenv("CONCURRENCY")
+	concurrencyEnv := Getenv("CONCURRENCY")
 	if concurrencyEnv != "" {
 		concurrency, err := strconv.Atoi(concurrencyEnv)
 		if err == nil && concurrency > 0 {
@@ -122,7 +319,7 @@ func getDefaultConcurrency() int {
 	channelCount := len(getDefaultChannels())
 	
 	// 估计插件数（从环境变量或默认值，实际在应用启动后会根据真实插件数调整）
-	pluginCountEnv := os.Getenv("PLUGIN_COUNT")
+	pluginCountEnv := Getenv("PLUGIN_COUNT")
 	pluginCount := 0
 	if pluginCountEnv != "" {
 		count, err := strconv.Atoi(pluginCountEnv)
@@ -153,13 +350,13 @@ func UpdateDefaultConcurrency(pluginCount int) {
 	}
 	
 	// 只有当未通过环境变量指定并发数时才进行调整
-	concurrencyEnv := os.Getenv("CONCURRENCY")
+	concurrencyEnv := Getenv("CONCURRENCY")
 	if concurrencyEnv != "" {
 		return
 	}
 	
 	// 计算频道数
-	channelCount := len(AppConfig.DefaultChannels)
+	channelCount := len(GetDefaultChannels())
 	
 	// 计算并发数 = 频道数 + 插件数（插件禁用时为0）+ 10
 	concurrency := channelCount + pluginCount + 10
@@ -168,12 +365,14 @@ func UpdateDefaultConcurrency(pluginCount int) {
 	}
 	
 	// 更新配置
+	runtimeLock.Lock()
 	AppConfig.DefaultConcurrency = concurrency
+	runtimeLock.Unlock()
 }
 
 // 从环境变量获取服务端口，如果未设置则使用默认值
 func getPort() string {
-	port := os.Getenv("PORT")
+	port := Getenv("PORT")
 	if port == "" {
 		return "8888"
 	}
@@ -182,12 +381,12 @@ func getPort() string {
 
 // 从环境变量获取SOCKS5代理URL，如果未设置则返回空字符串
 func getProxyURL() string {
-	return os.Getenv("PROXY")
+	return Getenv("PROXY")
 }
 
 // 从环境变量获取是否启用缓存，如果未设置则默认启用
 func getCacheEnabled() bool {
-	enabled := os.Getenv("CACHE_ENABLED")
+	enabled := Getenv("CACHE_ENABLED")
 	if enabled == "" {
 		return true
 	}
@@ -196,7 +395,7 @@ func getCacheEnabled() bool {
 
 // 从环境变量获取缓存路径，如果未设置则使用默认路径
 func getCachePath() string {
-	path := os.Getenv("CACHE_PATH")
+	path := Getenv("CACHE_PATH")
 	if path == "" {
 		// 默认在当前目录下创建cache文件夹
 		defaultPath, err := filepath.Abs("./cache")
@@ -210,7 +409,7 @@ func getCachePath() string {
 
 // 从环境变量获取缓存最大大小(MB)，如果未设置则使用默认值
 func getCacheMaxSize() int {
-	sizeEnv := os.Getenv("CACHE_MAX_SIZE")
+	sizeEnv := Getenv("CACHE_MAX_SIZE")
 	if sizeEnv == "" {
 		return 100 // 默认100MB
 	}
@@ -223,7 +422,7 @@ func getCacheMaxSize() int {
 
 // 从环境变量获取缓存TTL(分钟)，如果未设置则使用默认值
 func getCacheTTL() int {
-	ttlEnv := os.Getenv("CACHE_TTL")
+	ttlEnv := Getenv("CACHE_TTL")
 	if ttlEnv == "" {
 		return 60 // 默认60分钟
 	}
@@ -234,9 +433,70 @@ func getCacheTTL() int {
 	return ttl
 }
 
+// 从环境变量获取过期缓存的可用时长(分钟)，如果未设置则不返回过期缓存
+func getCacheStaleTTL() int {
+	ttlEnv := Getenv("CACHE_STALE_TTL")
+	if ttlEnv == "" {
+		return 0
+	}
+	ttl, err := strconv.Atoi(ttlEnv)
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// CacheSoftTTL 主缓存的有效期，超过后返回的缓存视为过期
+func CacheSoftTTL() time.Duration {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return time.Duration(AppConfig.CacheTTLMinutes) * time.Minute
+}
+
+// CacheStaleTTL 主缓存过期后仍可返回（同时在后台刷新）的时长，0表示不返回过期缓存
+func CacheStaleTTL() time.Duration {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return time.Duration(AppConfig.CacheStaleTTLMinutes) * time.Minute
+}
+
+// CacheHardTTL 主缓存的保存时长，超过后不再返回，需要重新搜索
+func CacheHardTTL() time.Duration {
+	runtimeLock.RLock()
+	defer runtimeLock.RUnlock()
+	return time.Duration(AppConfig.CacheTTLMinutes+AppConfig.CacheStaleTTLMinutes) * time.Minute
+}
+
+// 从环境变量获取远程缓存模式，如果未设置或无效则作为共享的第三级缓存
+func getCacheRemoteMode() string {
+	mode := strings.ToLower(strings.TrimSpace(Getenv("CACHE_REMOTE_MODE")))
+	if mode == "l2" {
+		return "l2"
+	}
+	return "l3"
+}
+
+// 从环境变量获取远程缓存键前缀，如果未设置则使用默认值
+func getCacheRemotePrefix() string {
+	prefix, exists := lookupEnv("CACHE_REMOTE_PREFIX")
+	if !exists {
+		return "pansou:"
+	}
+	return prefix
+}
+
+// 从环境变量获取是否压缩远程缓存，如果未设置则默认启用
+func getCacheRemoteCompress() bool {
+	enabled := Getenv("CACHE_REMOTE_COMPRESS")
+	if enabled == "" {
+		return true
+	}
+	return enabled != "false" && enabled != "0"
+}
+
 // 从环境变量获取是否启用压缩，如果未设置则默认禁用
 func getEnableCompression() bool {
-	enabled := os.Getenv("ENABLE_COMPRESSION")
+	enabled := Getenv("ENABLE_COMPRESSION")
 	if enabled == "" {
 		return false // 默认禁用，因为通常由Nginx等处理
 	}
@@ -245,7 +505,7 @@ func getEnableCompression() bool {
 
 // 从环境变量获取最小压缩大小，如果未设置则使用默认值
 func getMinSizeToCompress() int {
-	sizeEnv := os.Getenv("MIN_SIZE_TO_COMPRESS")
+	sizeEnv := Getenv("MIN_SIZE_TO_COMPRESS")
 	if sizeEnv == "" {
 		return 1024 // 默认1KB
 	}
@@ -258,7 +518,7 @@ func getMinSizeToCompress() int {
 
 // 从环境变量获取GC百分比，如果未设置则使用默认值
 func getGCPercent() int {
-	percentEnv := os.Getenv("GC_PERCENT")
+	percentEnv := Getenv("GC_PERCENT")
 	if percentEnv == "" {
 		return 50 // 默认50% - 优化内存管理，更频繁的GC避免内存暴涨
 	}
@@ -271,7 +531,7 @@ func getGCPercent() int {
 
 // 从环境变量获取是否优化内存，如果未设置则默认启用
 func getOptimizeMemory() bool {
-	enabled := os.Getenv("OPTIMIZE_MEMORY")
+	enabled := Getenv("OPTIMIZE_MEMORY")
 	if enabled == "" {
 		return true // 默认启用
 	}
@@ -280,7 +540,7 @@ func getOptimizeMemory() bool {
 
 // 从环境变量获取插件超时时间（秒），如果未设置则使用默认值
 func getPluginTimeout() int {
-	timeoutEnv := os.Getenv("PLUGIN_TIMEOUT")
+	timeoutEnv := Getenv("PLUGIN_TIMEOUT")
 	if timeoutEnv == "" {
 		return 30 // 默认30秒
 	}
@@ -293,7 +553,7 @@ func getPluginTimeout() int {
 
 // 从环境变量获取是否启用异步插件，如果未设置则默认启用
 func getAsyncPluginEnabled() bool {
-	enabled := os.Getenv("ASYNC_PLUGIN_ENABLED")
+	enabled := Getenv("ASYNC_PLUGIN_ENABLED")
 	if enabled == "" {
 		return true // 默认启用
 	}
@@ -305,7 +565,7 @@ func getAsyncPluginEnabled() bool {
 // 返回[]string{}表示设置为空（不启用任何插件）
 // 返回具体列表表示启用指定插件
 func getEnabledPlugins() []string {
-	plugins, exists := os.LookupEnv("ENABLED_PLUGINS")
+	plugins, exists := lookupEnv("ENABLED_PLUGINS")
 	if !exists {
 		// 未设置环境变量时返回nil，表示不启用任何插件
 		return nil
@@ -330,7 +590,7 @@ func getEnabledPlugins() []string {
 
 // 从环境变量获取异步响应超时时间（秒），如果未设置则使用默认值
 func getAsyncResponseTimeout() int {
-	timeoutEnv := os.Getenv("ASYNC_RESPONSE_TIMEOUT")
+	timeoutEnv := Getenv("ASYNC_RESPONSE_TIMEOUT")
 	if timeoutEnv == "" {
 		return 4 // 默认4秒
 	}
@@ -343,7 +603,7 @@ func getAsyncResponseTimeout() int {
 
 // 从环境变量获取最大后台工作者数量，如果未设置则自动计算
 func getAsyncMaxBackgroundWorkers() int {
-	sizeEnv := os.Getenv("ASYNC_MAX_BACKGROUND_WORKERS")
+	sizeEnv := Getenv("ASYNC_MAX_BACKGROUND_WORKERS")
 	if sizeEnv != "" {
 		size, err := strconv.Atoi(sizeEnv)
 		if err == nil && size > 0 {
@@ -366,7 +626,7 @@ func getAsyncMaxBackgroundWorkers() int {
 
 // 从环境变量获取最大后台任务数量，如果未设置则自动计算
 func getAsyncMaxBackgroundTasks() int {
-	sizeEnv := os.Getenv("ASYNC_MAX_BACKGROUND_TASKS")
+	sizeEnv := Getenv("ASYNC_MAX_BACKGROUND_TASKS")
 	if sizeEnv != "" {
 		size, err := strconv.Atoi(sizeEnv)
 		if err == nil && size > 0 {
@@ -388,7 +648,7 @@ func getAsyncMaxBackgroundTasks() int {
 
 // 从环境变量获取异步缓存有效期（小时），如果未设置则使用默认值
 func getAsyncCacheTTLHours() int {
-	ttlEnv := os.Getenv("ASYNC_CACHE_TTL_HOURS")
+	ttlEnv := Getenv("ASYNC_CACHE_TTL_HOURS")
 	if ttlEnv == "" {
 		return 1 // 默认1小时
 	}
@@ -401,7 +661,7 @@ func getAsyncCacheTTLHours() int {
 
 // 从环境变量获取HTTP读取超时，如果未设置则自动计算
 func getHTTPReadTimeout() time.Duration {
-	timeoutEnv := os.Getenv("HTTP_READ_TIMEOUT")
+	timeoutEnv := Getenv("HTTP_READ_TIMEOUT")
 	if timeoutEnv != "" {
 		timeout, err := strconv.Atoi(timeoutEnv)
 		if err == nil && timeout > 0 {
@@ -427,7 +687,7 @@ func getHTTPReadTimeout() time.Duration {
 
 // 从环境变量获取HTTP写入超时，如果未设置则自动计算
 func getHTTPWriteTimeout() time.Duration {
-	timeoutEnv := os.Getenv("HTTP_WRITE_TIMEOUT")
+	timeoutEnv := Getenv("HTTP_WRITE_TIMEOUT")
 	if timeoutEnv != "" {
 		timeout, err := strconv.Atoi(timeoutEnv)
 		if err == nil && timeout > 0 {
@@ -453,7 +713,7 @@ func getHTTPWriteTimeout() time.Duration {
 
 // 从环境变量获取HTTP空闲超时，如果未设置则自动计算
 func getHTTPIdleTimeout() time.Duration {
-	timeoutEnv := os.Getenv("HTTP_IDLE_TIMEOUT")
+	timeoutEnv := Getenv("HTTP_IDLE_TIMEOUT")
 	if timeoutEnv != "" {
 		timeout, err := strconv.Atoi(timeoutEnv)
 		if err == nil && timeout > 0 {
@@ -467,7 +727,7 @@ func getHTTPIdleTimeout() time.Duration {
 
 // 从环境变量获取HTTP最大连接数，如果未设置则自动计算
 func getHTTPMaxConns() int {
-	maxConnsEnv := os.Getenv("HTTP_MAX_CONNS")
+	maxConnsEnv := Getenv("HTTP_MAX_CONNS")
 	if maxConnsEnv != "" {
 		maxConns, err := strconv.Atoi(maxConnsEnv)
 		if err == nil && maxConns > 0 {
@@ -488,9 +748,309 @@ func getHTTPMaxConns() int {
 	return maxConns
 }
 
+// 从环境变量获取异步搜索任务保留时间（分钟），如果未设置则使用默认值
+func getSearchJobTTL() time.Duration {
+	ttlEnv := Getenv("SEARCH_JOB_TTL")
+	if ttlEnv == "" {
+		return 10 * time.Minute // 默认10分钟
+	}
+	ttl, err := strconv.Atoi(ttlEnv)
+	if err != nil || ttl <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(ttl) * time.Minute
+}
+
+// 从环境变量获取最多保留的异步搜索任务数，如果未设置则使用默认值
+func getSearchJobMaxCount() int {
+	maxEnv := Getenv("SEARCH_JOB_MAX")
+	if maxEnv == "" {
+		return 1000 // 默认1000个
+	}
+	maxCount, err := strconv.Atoi(maxEnv)
+	if err != nil || maxCount <= 0 {
+		return 1000
+	}
+	return maxCount
+}
+
+// 从环境变量API_KEYS（逗号分隔）和API_KEYS_FILE指定的文件（每行一个）加载API Key
+// 每项格式为 key[:名称][:admin]，文件中以#开头的行为注释
+func getAPIKeys() []APIKey {
+	var entries []string
+	if keysEnv := Getenv("API_KEYS"); keysEnv != "" {
+		entries = append(entries, strings.Split(keysEnv, ",")...)
+	}
+	if keysFile := Getenv("API_KEYS_FILE"); keysFile != "" {
+		data, err := os.ReadFile(keysFile)
+		if err != nil {
+			fmt.Printf("读取API Key文件失败: %s | 错误: %v\n", keysFile, err)
+		} else {
+			entries = append(entries, strings.Split(string(data), "\n")...)
+		}
+	}
+
+	keys := make([]APIKey, 0)
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		key := APIKey{Key: strings.TrimSpace(parts[0])}
+		if key.Key == "" || seen[key.Key] {
+			continue
+		}
+		for _, part := range parts[1:] {
+			part = strings.TrimSpace(part)
+			if strings.EqualFold(part, "admin") {
+				key.Admin = true
+			} else if part != "" {
+				key.Name = part
+			}
+		}
+		seen[key.Key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// 从环境变量获取每个API Key每分钟搜索次数，如果未设置则使用默认值
+func getAPIKeySearchPerMinute() int {
+	rateEnv := Getenv("API_KEY_SEARCH_RATE")
+	if rateEnv == "" {
+		return 60 // 默认每分钟60次
+	}
+	rate, err := strconv.Atoi(rateEnv)
+	if err != nil || rate < 0 {
+		return 60
+	}
+	return rate
+}
+
+// 从环境变量获取每个API Key每分钟强制刷新次数，如果未设置则使用默认值
+func getAPIKeyRefreshPerMinute() int {
+	rateEnv := Getenv("API_KEY_REFRESH_RATE")
+	if rateEnv == "" {
+		return 5 // 默认每分钟5次
+	}
+	rate, err := strconv.Atoi(rateEnv)
+	if err != nil || rate < 0 {
+		return 5
+	}
+	return rate
+}
+
+// 从环境变量获取每个客户端IP每分钟最多搜索次数，如果未设置则不限制
+func getIPSearchPerMinute() int {
+	rateEnv := Getenv("IP_SEARCH_RATE")
+	if rateEnv == "" {
+		return 0
+	}
+	rate, err := strconv.Atoi(rateEnv)
+	if err != nil || rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+// 从环境变量获取每个客户端IP每分钟最多强制刷新次数，如果未设置则不限制
+func getIPRefreshPerMinute() int {
+	rateEnv := Getenv("IP_REFRESH_RATE")
+	if rateEnv == "" {
+		return 0
+	}
+	rate, err := strconv.Atoi(rateEnv)
+	if err != nil || rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+// 从环境变量获取受信任的代理列表，如果未设置则只信任本机
+func getTrustedProxies() []string {
+	proxiesEnv := Getenv("TRUSTED_PROXIES")
+	if proxiesEnv == "" {
+		return []string{"127.0.0.1", "::1"}
+	}
+	proxies := make([]string, 0)
+	for _, proxy := range strings.Split(proxiesEnv, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// 从环境变量获取同时进行的冷搜索上限，如果未设置则不限制
+func getMaxColdSearches() int {
+	maxEnv := Getenv("MAX_COLD_SEARCHES")
+	if maxEnv == "" {
+		return 0
+	}
+	max, err := strconv.Atoi(maxEnv)
+	if err != nil || max < 0 {
+		return 0
+	}
+	return max
+}
+
+// 从环境变量获取插件熔断的连续失败阈值，如果未设置则使用默认值
+func getCircuitFailureThreshold() int {
+	thresholdEnv := Getenv("CIRCUIT_FAILURE_THRESHOLD")
+	if thresholdEnv == "" {
+		return 5 // 默认连续失败5次
+	}
+	threshold, err := strconv.Atoi(thresholdEnv)
+	if err != nil || threshold < 0 {
+		return 5
+	}
+	return threshold
+}
+
+// 从环境变量获取插件熔断持续时间（秒），如果未设置则使用默认值
+func getCircuitOpenDuration() time.Duration {
+	durationEnv := Getenv("CIRCUIT_OPEN_SECONDS")
+	if durationEnv == "" {
+		return 60 * time.Second // 默认60秒
+	}
+	seconds, err := strconv.Atoi(durationEnv)
+	if err != nil || seconds <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// 从环境变量获取热门关键词缓存预热开关，如果未设置则不启用
+func getCacheWarmerEnabled() bool {
+	enabled := Getenv("CACHE_WARMER_ENABLED")
+	return enabled == "true" || enabled == "1"
+}
+
+// 从环境变量获取预热的热门关键词数量，如果未设置则使用默认值
+func getCacheWarmerTopN() int {
+	topEnv := Getenv("CACHE_WARMER_TOP_N")
+	if topEnv == "" {
+		return 20 // 默认前20个
+	}
+	top, err := strconv.Atoi(topEnv)
+	if err != nil || top <= 0 {
+		return 20
+	}
+	return top
+}
+
+// 从环境变量获取预热检查间隔（分钟），如果未设置则使用默认值
+func getCacheWarmerInterval() time.Duration {
+	intervalEnv := Getenv("CACHE_WARMER_INTERVAL")
+	if intervalEnv == "" {
+		return 10 * time.Minute // 默认10分钟
+	}
+	minutes, err := strconv.Atoi(intervalEnv)
+	if err != nil || minutes <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// 从环境变量获取同时进行的预热搜索数，如果未设置则使用默认值
+func getCacheWarmerConcurrency() int {
+	concurrencyEnv := Getenv("CACHE_WARMER_CONCURRENCY")
+	if concurrencyEnv == "" {
+		return 2 // 默认2个
+	}
+	concurrency, err := strconv.Atoi(concurrencyEnv)
+	if err != nil || concurrency <= 0 {
+		return 2
+	}
+	return concurrency
+}
+
+// 从环境变量获取搜索次数的半衰期（分钟），如果未设置则使用默认值
+func getCacheWarmerHalfLife() time.Duration {
+	halfLifeEnv := Getenv("CACHE_WARMER_HALF_LIFE")
+	if halfLifeEnv == "" {
+		return 60 * time.Minute // 默认60分钟
+	}
+	minutes, err := strconv.Atoi(halfLifeEnv)
+	if err != nil || minutes <= 0 {
+		return 60 * time.Minute
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// 从环境变量获取声明式插件目录，如果未设置则使用默认值
+func getPluginsDir() string {
+	dir := Getenv("PLUGINS_DIR")
+	if dir == "" {
+		return "./plugins"
+	}
+	return dir
+}
+
+// 从环境变量RANK_WEIGHTS获取相关性排序的权重，格式为 信号:权重，逗号分隔
+// 如 relevance:0.6,recency:0.2,source:0.15,links:0.05，未指定或无效的信号使用默认权重
+func getRankWeights() RankWeights {
+	weights := RankWeights{
+		Relevance: 0.6,
+		Recency:   0.2,
+		Source:    0.15,
+		Links:     0.05,
+	}
+	for _, entry := range strings.Split(Getenv("RANK_WEIGHTS"), ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || weight < 0 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "relevance":
+			weights.Relevance = weight
+		case "recency":
+			weights.Recency = weight
+		case "source":
+			weights.Source = weight
+		case "links":
+			weights.Links = weight
+		}
+	}
+	return weights
+}
+
+// 从环境变量获取拼音匹配开关，如果未设置则使用默认值
+func getKeywordMatchPinyin() bool {
+	pinyinEnv := Getenv("KEYWORD_MATCH_PINYIN")
+	if pinyinEnv == "" {
+		return false // 默认不启用
+	}
+	enabled, err := strconv.ParseBool(pinyinEnv)
+	if err != nil {
+		return false
+	}
+	return enabled
+}
+
+// 从环境变量获取关键词模糊匹配的最大编辑距离，如果未设置则使用默认值
+func getKeywordMatchDistance() int {
+	distanceEnv := Getenv("KEYWORD_MATCH_DISTANCE")
+	if distanceEnv == "" {
+		return 0 // 默认不启用模糊匹配
+	}
+	distance, err := strconv.Atoi(distanceEnv)
+	if err != nil || distance < 0 {
+		return 0
+	}
+	return distance
+}
+
 // 从环境变量获取异步插件日志开关，如果未设置则使用默认值
 func getAsyncLogEnabled() bool {
-	logEnv := os.Getenv("ASYNC_LOG_ENABLED")
+	logEnv := Getenv("ASYNC_LOG_ENABLED")
 	if logEnv == "" {
 		return true // 默认启用日志
 	}
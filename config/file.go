@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置文件中的插件配置段
+const pluginSectionKey = "PLUGINS"
+
+var (
+	// 配置文件路径，为空时只使用环境变量
+	configFile string
+
+	// fileLock 保护从配置文件读取的值
+	fileLock sync.RWMutex
+	// 配置文件中的值，键为对应的环境变量名（如CACHE_TTL）
+	fileValues = map[string]string{}
+	// 配置文件plugins段中各插件的配置，插件名和键均为小写
+	filePluginSections = map[string]map[string]string{}
+)
+
+// SetConfigFile 设置配置文件路径，需在Init之前调用
+// 未设置时使用环境变量CONFIG_FILE
+func SetConfigFile(path string) {
+	configFile = path
+}
+
+// ConfigFile 当前使用的配置文件路径，未使用配置文件时为空
+func ConfigFile() string {
+	if configFile != "" {
+		return configFile
+	}
+	return os.Getenv("CONFIG_FILE")
+}
+
+// Getenv 获取配置项，环境变量优先，未设置或为空时使用配置文件中同名的值
+func Getenv(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	fileLock.RLock()
+	defer fileLock.RUnlock()
+	return fileValues[name]
+}
+
+// lookupEnv 同Getenv，但区分未设置和设置为空
+func lookupEnv(name string) (string, bool) {
+	if value, exists := os.LookupEnv(name); exists {
+		return value, true
+	}
+	fileLock.RLock()
+	defer fileLock.RUnlock()
+	value, exists := fileValues[name]
+	return value, exists
+}
+
+// PluginSetting 获取插件的配置项
+// 环境变量PLUGIN_<插件名>_<键>优先，其次为配置文件plugins段中的值
+func PluginSetting(pluginName, key string) (string, bool) {
+	envName := "PLUGIN_" + envKey(pluginName) + "_" + envKey(key)
+	if value := os.Getenv(envName); value != "" {
+		return value, true
+	}
+
+	fileLock.RLock()
+	defer fileLock.RUnlock()
+	value, exists := filePluginSections[strings.ToLower(pluginName)][strings.ToLower(key)]
+	return value, exists
+}
+
+// PluginSettings 获取配置文件中各插件的配置段
+func PluginSettings() map[string]map[string]string {
+	fileLock.RLock()
+	defer fileLock.RUnlock()
+	return filePluginSections
+}
+
+// loadConfigFile 读取配置文件并替换当前的文件配置值，path为空时清空
+// 读取或解析失败时保留原有的值
+func loadConfigFile(path string) error {
+	values := map[string]string{}
+	sections := map[string]map[string]string{}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取配置文件失败: %w", err)
+		}
+		raw, err := parseConfigFile(path, data)
+		if err != nil {
+			return fmt.Errorf("解析配置文件失败: %w", err)
+		}
+		if values, sections, err = flattenConfig(raw); err != nil {
+			return fmt.Errorf("解析配置文件失败: %w", err)
+		}
+	}
+
+	fileLock.Lock()
+	fileValues = values
+	filePluginSections = sections
+	fileLock.Unlock()
+	return nil
+}
+
+// parseConfigFile 按扩展名解析配置文件，.toml为TOML，其余按YAML解析
+func parseConfigFile(path string, data []byte) (map[string]interface{}, error) {
+	raw := map[string]interface{}{}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// flattenConfig 将配置文件内容转换为环境变量形式的键值
+// 键不区分大小写，横线等同于下划线；列表转换为逗号分隔的字符串
+func flattenConfig(raw map[string]interface{}) (map[string]string, map[string]map[string]string, error) {
+	values := make(map[string]string, len(raw))
+	sections := make(map[string]map[string]string)
+
+	for key, value := range raw {
+		name := envKey(key)
+		if name == pluginSectionKey {
+			plugins, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("plugins必须是以插件名为键的对象")
+			}
+			for pluginName, pluginValue := range plugins {
+				settings, ok := pluginValue.(map[string]interface{})
+				if !ok {
+					return nil, nil, fmt.Errorf("plugins.%s必须是对象", pluginName)
+				}
+				section := make(map[string]string, len(settings))
+				for settingKey, settingValue := range settings {
+					str, err := configValueString(settingValue)
+					if err != nil {
+						return nil, nil, fmt.Errorf("plugins.%s.%s: %w", pluginName, settingKey, err)
+					}
+					section[strings.ToLower(settingKey)] = str
+				}
+				sections[strings.ToLower(pluginName)] = section
+			}
+			continue
+		}
+
+		str, err := configValueString(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", key, err)
+		}
+		values[name] = str
+	}
+	return values, sections, nil
+}
+
+// configValueString 将配置值转换为环境变量形式的字符串
+func configValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := configValueString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("不支持嵌套对象")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// envKey 配置文件键转换为环境变量名
+func envKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(key), "-", "_"))
+}
+
+// fileConfigKeys 配置文件中设置的键，按字母排序
+func fileConfigKeys() []string {
+	fileLock.RLock()
+	defer fileLock.RUnlock()
+
+	keys := make([]string, 0, len(fileValues))
+	for key := range fileValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
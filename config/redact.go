@@ -0,0 +1,110 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 插件配置中名称包含这些词的配置项视为敏感信息
+var secretSettingWords = []string{"key", "token", "secret", "password", "cookie", "auth"}
+
+// EffectiveConfig 返回当前生效的配置，API Key、代理和远程缓存的密码以及插件的敏感配置项已脱敏
+// 键为下划线风格的字段名，时长输出为字符串（如30s）
+func EffectiveConfig() map[string]interface{} {
+	result := make(map[string]interface{})
+
+	current := snapshot()
+	value := reflect.ValueOf(&current).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		switch field.Name {
+		case "APIKeys":
+			keys := make([]map[string]interface{}, 0, len(current.APIKeys))
+			for _, key := range current.APIKeys {
+				keys = append(keys, map[string]interface{}{
+					"key":   RedactSecret(key.Key),
+					"name":  key.Name,
+					"admin": key.Admin,
+				})
+			}
+			result[snakeCase(field.Name)] = keys
+		case "ProxyURL":
+			result[snakeCase(field.Name)] = redactURL(current.ProxyURL)
+		case "CacheRemoteURL":
+			result[snakeCase(field.Name)] = redactURL(current.CacheRemoteURL)
+		default:
+			if d, ok := value.Field(i).Interface().(time.Duration); ok {
+				result[snakeCase(field.Name)] = d.String()
+			} else {
+				result[snakeCase(field.Name)] = value.Field(i).Interface()
+			}
+		}
+	}
+
+	plugins := make(map[string]map[string]string)
+	for name, settings := range PluginSettings() {
+		redacted := make(map[string]string, len(settings))
+		for key, setting := range settings {
+			if isSecretSetting(key) {
+				setting = RedactSecret(setting)
+			}
+			redacted[key] = setting
+		}
+		plugins[name] = redacted
+	}
+	result["plugins"] = plugins
+	result["config_file"] = ConfigFile()
+	result["file_keys"] = fileConfigKeys()
+	return result
+}
+
+// RedactSecret 脱敏密钥，只保留前4位
+func RedactSecret(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// redactURL 隐藏URL中的密码
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "****"
+	}
+	return u.Redacted()
+}
+
+// isSecretSetting 判断插件配置项是否为敏感信息
+func isSecretSetting(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range secretSettingWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// snakeCase 将字段名转换为下划线风格，连续大写视为一个缩写（如HTTPReadTimeout转为http_read_timeout）
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
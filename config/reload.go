@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// loadedConfig 上次从环境变量和配置文件加载的配置
+// 重新加载时与之比较，只应用配置文件中实际修改过的配置项，不覆盖管理接口在运行时做的修改
+var loadedConfig Config
+
+// 可以在运行时重新加载的配置项，其余配置项修改后需要重启才能生效
+var reloadableFields = map[string]bool{
+	"DefaultChannels":         true,
+	"DefaultConcurrency":      true,
+	"EnabledPlugins":          true,
+	"PluginTimeoutSeconds":    true,
+	"PluginTimeout":           true,
+	"AsyncResponseTimeout":    true,
+	"AsyncResponseTimeoutDur": true,
+	"CacheTTLMinutes":         true,
+	"CacheStaleTTLMinutes":    true,
+	"AsyncCacheTTLHours":      true,
+	"APIKeySearchPerMinute":   true,
+	"APIKeyRefreshPerMinute":  true,
+	"IPSearchPerMinute":       true,
+	"IPRefreshPerMinute":      true,
+	"RankWeights":             true,
+	"KeywordMatchPinyin":      true,
+	"KeywordMatchDistance":    true,
+}
+
+var (
+	// reloadLock 保证同一时间只有一次重新加载
+	reloadLock sync.Mutex
+	// 重新加载后的回调
+	reloadHooks []func(changed map[string]bool)
+)
+
+// OnReload 注册配置重新加载后的回调，changed为发生变化并已生效的配置项（Config字段名）
+// 回调在重新加载的过程中同步执行，不能再调用Reload
+func OnReload(hook func(changed map[string]bool)) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	reloadHooks = append(reloadHooks, hook)
+}
+
+// Reload 重新读取配置文件，应用可以在运行时修改的配置项
+// 返回已生效的配置项，以及修改了但需要重启才能生效的配置项
+func Reload() (applied []string, ignored []string, err error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	path := ConfigFile()
+	if path == "" {
+		return nil, nil, errors.New("未使用配置文件")
+	}
+	if err := loadConfigFile(path); err != nil {
+		return nil, nil, err
+	}
+
+	next := loadConfig()
+	changed := make(map[string]bool)
+
+	// 可重新加载的配置项由runtimeLock保护，读取方通过Get函数获取
+	runtimeLock.Lock()
+	oldValue := reflect.ValueOf(&loadedConfig).Elem()
+	newValue := reflect.ValueOf(next).Elem()
+	current := reflect.ValueOf(AppConfig).Elem()
+	for i := 0; i < newValue.NumField(); i++ {
+		name := newValue.Type().Field(i).Name
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+		if !reloadableFields[name] {
+			ignored = append(ignored, name)
+			continue
+		}
+
+		current.Field(i).Set(newValue.Field(i))
+		changed[name] = true
+		applied = append(applied, name)
+	}
+	runtimeLock.Unlock()
+	loadedConfig = *next
+
+	if len(changed) > 0 {
+		for _, hook := range reloadHooks {
+			hook(changed)
+		}
+	}
+
+	sort.Strings(applied)
+	sort.Strings(ignored)
+	return applied, ignored, nil
+}
+
+// ReloadAndLog 重新加载配置并输出结果
+func ReloadAndLog(reason string) {
+	applied, ignored, err := Reload()
+	if err != nil {
+		fmt.Printf("重新加载配置失败（%s）: %v\n", reason, err)
+		return
+	}
+	fmt.Printf("已重新加载配置（%s），生效的配置项: %v\n", reason, applied)
+	if len(ignored) > 0 {
+		fmt.Printf("以下配置项需要重启后生效: %v\n", ignored)
+	}
+}
+
+// WatchConfigFile 定期检查配置文件，修改后自动重新加载，未使用配置文件时不检查
+func WatchConfigFile(interval time.Duration) {
+	path := ConfigFile()
+	if path == "" {
+		return
+	}
+
+	lastModified := configFileModTime(path)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			modified := configFileModTime(path)
+			if modified.IsZero() || modified.Equal(lastModified) {
+				continue
+			}
+			lastModified = modified
+			ReloadAndLog("配置文件已修改")
+		}
+	}()
+}
+
+// configFileModTime 配置文件的修改时间，文件不存在时返回零值
+func configFileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
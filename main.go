@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net"
@@ -18,7 +19,10 @@ import (
 
 	"pansou/api"
 	"pansou/config"
+	"pansou/mcp"
 	"pansou/plugin"
+	"pansou/plugin/declarative"
+	"pansou/plugin/plugincheck"
 	"pansou/service"
 	"pansou/util"
 	"pansou/util/cache"
@@ -74,7 +78,29 @@ import (
 // 全局缓存写入管理器
 var globalCacheWriteManager *cache.DelayedBatchWriteManager
 
+// configWatchInterval 检查配置文件是否修改的间隔
+const configWatchInterval = 5 * time.Second
+
 func main() {
+	// -config 指定配置文件（YAML或TOML），未指定时使用环境变量CONFIG_FILE
+	configFile := flag.String("config", "", "配置文件路径（YAML或TOML），环境变量优先于配置文件")
+	flag.Parse()
+	config.SetConfigFile(*configFile)
+
+	// 子命令：pansou mcp 以stdio方式提供MCP服务；pansou plugins check 检查插件是否可用
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "mcp":
+			runMCPStdio()
+			return
+		case "plugins":
+			os.Exit(runPluginsCommand(flag.Args()[1:]))
+		default:
+			fmt.Fprintf(os.Stderr, "未知的子命令: %s\n用法: pansou [-config 配置文件] [mcp | plugins check]\n", flag.Arg(0))
+			os.Exit(2)
+		}
+	}
+
 	// 初始化应用
 	initApp()
 
@@ -115,16 +141,30 @@ func initApp() {
 
 	// 确保异步插件系统初始化
 	plugin.InitAsyncPluginSystem()
+
+	// 注册插件目录中的声明式插件，需在创建搜索服务之前
+	loadDeclarativePlugins()
 }
 
-// startServer 启动Web服务器
-func startServer() {
+// loadDeclarativePlugins 注册插件目录中的声明式插件
+func loadDeclarativePlugins() {
+	loaded, errs := declarative.LoadDir(config.AppConfig.PluginsDir)
+	for _, err := range errs {
+		log.Printf("加载声明式插件失败: %v", err)
+	}
+	if len(loaded) > 0 {
+		fmt.Printf("已加载声明式插件: %s\n", strings.Join(loaded, ", "))
+	}
+}
+
+// newSearchService 注册插件并创建搜索服务
+func newSearchService() (*service.SearchService, *plugin.PluginManager) {
 	// 初始化插件管理器
 	pluginManager := plugin.NewPluginManager()
 
 	// 注册全局插件（根据配置过滤）
 	if config.AppConfig.AsyncPluginEnabled {
-		pluginManager.RegisterGlobalPluginsWithFilter(config.AppConfig.EnabledPlugins)
+		pluginManager.RegisterGlobalPluginsWithFilter(config.GetEnabledPlugins())
 	}
 
 	// 更新默认并发数（如果插件被禁用则使用0）
@@ -134,8 +174,130 @@ func startServer() {
 	}
 	config.UpdateDefaultConcurrency(pluginCount)
 
-	// 初始化搜索服务
+	// 初始化搜索服务，并恢复通过管理接口保存的插件和频道设置
 	searchService := service.NewSearchService(pluginManager)
+	if err := searchService.LoadRuntimeState(); err != nil {
+		log.Printf("恢复运行时状态失败: %v", err)
+	}
+	config.OnReload(searchService.ApplyConfigReload)
+
+	// 启用时定期预热热门关键词的缓存
+	searchService.StartCacheWarmer()
+	return searchService, pluginManager
+}
+
+// runMCPStdio 以stdio方式提供MCP服务，标准输入关闭时退出
+func runMCPStdio() {
+	// stdout专用于MCP协议消息，其余日志输出全部转到stderr
+	protocolOut := os.Stdout
+	os.Stdout = os.Stderr
+	log.SetOutput(os.Stderr)
+
+	initApp()
+	searchService, _ := newSearchService()
+	server := mcp.NewServer(searchService)
+
+	if err := server.ServeStdio(os.Stdin, protocolOut); err != nil {
+		log.Printf("MCP服务异常退出: %v", err)
+	}
+
+	flushCaches()
+}
+
+// runPluginsCommand 执行plugins子命令，返回进程退出码
+// pansou plugins check [-keywords 关键词,...] [-timeout 30s] [-json] [-all] [插件名...]
+// 未指定插件名时检查ENABLED_PLUGINS中的插件（未设置时或指定-all时检查所有已注册的插件），
+// 有插件不正常时退出码为1，便于在定时任务中告警
+func runPluginsCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "用法: pansou plugins check [-keywords 关键词,...] [-timeout 30s] [-json] [-all] [插件名...]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("plugins check", flag.ContinueOnError)
+	keywords := fs.String("keywords", strings.Join(plugincheck.DefaultKeywords, ","), "测试关键词，多个用逗号分隔")
+	timeout := fs.Duration("timeout", 30*time.Second, "每次搜索的超时")
+	concurrency := fs.Int("concurrency", 8, "同时检查的插件数")
+	jsonOutput := fs.Bool("json", false, "以JSON格式输出报告")
+	all := fs.Bool("all", false, "检查所有已注册的插件，忽略ENABLED_PLUGINS")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	// stdout只输出报告，插件日志全部转到stderr
+	reportOut := os.Stdout
+	os.Stdout = os.Stderr
+	log.SetOutput(os.Stderr)
+
+	config.Init()
+	util.InitHTTPClient()
+	plugin.InitAsyncPluginSystem()
+	loadDeclarativePlugins()
+
+	plugins, unknown := pluginsToCheck(fs.Args(), *all)
+	if len(unknown) > 0 {
+		fmt.Fprintf(os.Stderr, "未知的插件: %s\n", strings.Join(unknown, ", "))
+		return 2
+	}
+	if len(plugins) == 0 {
+		fmt.Fprintln(os.Stderr, "没有要检查的插件")
+		return 2
+	}
+
+	var keywordList []string
+	for _, keyword := range strings.Split(*keywords, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywordList = append(keywordList, keyword)
+		}
+	}
+
+	report := plugincheck.Run(plugins, plugincheck.Options{
+		Keywords:    keywordList,
+		Timeout:     *timeout,
+		Concurrency: *concurrency,
+	})
+
+	var err error
+	if *jsonOutput {
+		err = report.WriteJSON(reportOut)
+	} else {
+		err = report.WriteTable(reportOut)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "输出报告失败: %v\n", err)
+		return 2
+	}
+	if !report.Healthy() {
+		return 1
+	}
+	return 0
+}
+
+// pluginsToCheck 按名称选择要检查的插件，返回未注册的名称
+func pluginsToCheck(names []string, all bool) ([]plugin.AsyncSearchPlugin, []string) {
+	if len(names) == 0 && !all && len(config.GetEnabledPlugins()) > 0 {
+		names = config.GetEnabledPlugins()
+	}
+	if len(names) == 0 {
+		return plugin.GetRegisteredPlugins(), nil
+	}
+
+	var plugins []plugin.AsyncSearchPlugin
+	var unknown []string
+	for _, name := range names {
+		if p, ok := plugin.GetPluginByName(name); ok {
+			plugins = append(plugins, p)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	return plugins, unknown
+}
+
+// startServer 启动Web服务器
+func startServer() {
+	// 初始化搜索服务
+	searchService, pluginManager := newSearchService()
 
 	// 设置路由
 	router := api.SetupRouter(searchService)
@@ -159,6 +321,16 @@ func startServer() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	// 收到SIGHUP或配置文件修改后重新加载配置
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			config.ReloadAndLog("收到SIGHUP")
+		}
+	}()
+	config.WatchConfigFile(configWatchInterval)
+
 	// 在单独的goroutine中启动服务器
 	go func() {
 		// 如果设置了最大连接数，使用限制监听器
@@ -188,7 +360,26 @@ func startServer() {
 	<-quit
 	fmt.Println("正在关闭服务器...")
 
+	// 停止缓存预热，避免关闭过程中继续发起搜索
+	searchService.StopCacheWarmer()
+
 	// 优先保存缓存数据到磁盘（数据安全第一）
+	flushCaches()
+
+	// 设置关闭超时时间
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// 优雅关闭服务器
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatalf("服务器关闭异常: %v", err)
+	}
+
+	fmt.Println("服务器已安全关闭")
+}
+
+// flushCaches 退出前将缓存数据保存到磁盘
+func flushCaches() {
 	// 增加关闭超时时间，确保数据有足够时间保存
 	shutdownTimeout := 10 * time.Second
 	
@@ -204,17 +395,6 @@ func startServer() {
 			log.Printf("内存缓存同步失败: %v", err)
 		} 
 	}
-
-	// 设置关闭超时时间
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	// 优雅关闭服务器
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("服务器关闭异常: %v", err)
-	}
-
-	fmt.Println("服务器已安全关闭")
 }
 
 // printServiceInfo 打印服务信息
@@ -230,17 +410,17 @@ func printServiceInfo(port string, pluginManager *plugin.PluginManager) {
 	}
 
 	// 输出并发信息
-	if os.Getenv("CONCURRENCY") != "" {
-		fmt.Printf("默认并发数: %d (由环境变量CONCURRENCY指定)\n", config.AppConfig.DefaultConcurrency)
+	if config.Getenv("CONCURRENCY") != "" {
+		fmt.Printf("默认并发数: %d (由环境变量CONCURRENCY指定)\n", config.GetDefaultConcurrency())
 	} else {
-		channelCount := len(config.AppConfig.DefaultChannels)
+		channelCount := len(config.GetDefaultChannels())
 		pluginCount := 0
 		// 只有插件启用时才计算插件数
 		if config.AppConfig.AsyncPluginEnabled && pluginManager != nil {
 			pluginCount = len(pluginManager.GetPlugins())
 		}
 		fmt.Printf("默认并发数: %d (= 频道数%d + 插件数%d + 10)\n",
-			config.AppConfig.DefaultConcurrency, channelCount, pluginCount)
+			config.GetDefaultConcurrency(), channelCount, pluginCount)
 	}
 
 	// 输出缓存信息
@@ -266,21 +446,21 @@ func printServiceInfo(port string, pluginManager *plugin.PluginManager) {
 
 	// 输出HTTP服务器配置信息
 	readTimeoutMsg := ""
-	if os.Getenv("HTTP_READ_TIMEOUT") != "" {
+	if config.Getenv("HTTP_READ_TIMEOUT") != "" {
 		readTimeoutMsg = "(由环境变量指定)"
 	} else {
 		readTimeoutMsg = "(自动计算)"
 	}
 
 	writeTimeoutMsg := ""
-	if os.Getenv("HTTP_WRITE_TIMEOUT") != "" {
+	if config.Getenv("HTTP_WRITE_TIMEOUT") != "" {
 		writeTimeoutMsg = "(由环境变量指定)"
 	} else {
 		writeTimeoutMsg = "(自动计算)"
 	}
 
 	maxConnsMsg := ""
-	if os.Getenv("HTTP_MAX_CONNS") != "" {
+	if config.Getenv("HTTP_MAX_CONNS") != "" {
 		maxConnsMsg = "(由环境变量指定)"
 	} else {
 		cpuCount := runtime.NumCPU()
@@ -297,7 +477,7 @@ func printServiceInfo(port string, pluginManager *plugin.PluginManager) {
 	if config.AppConfig.AsyncPluginEnabled {
 		// 检查工作者数量是否由环境变量指定
 		workersMsg := ""
-		if os.Getenv("ASYNC_MAX_BACKGROUND_WORKERS") != "" {
+		if config.Getenv("ASYNC_MAX_BACKGROUND_WORKERS") != "" {
 			workersMsg = "(由环境变量指定)"
 		} else {
 			cpuCount := runtime.NumCPU()
@@ -306,7 +486,7 @@ func printServiceInfo(port string, pluginManager *plugin.PluginManager) {
 
 		// 检查任务数量是否由环境变量指定
 		tasksMsg := ""
-		if os.Getenv("ASYNC_MAX_BACKGROUND_TASKS") != "" {
+		if config.Getenv("ASYNC_MAX_BACKGROUND_TASKS") != "" {
 			tasksMsg = "(由环境变量指定)"
 		} else {
 			tasksMsg = "(自动计算: 工作者数量 × 5)"
@@ -342,10 +522,10 @@ func printServiceInfo(port string, pluginManager *plugin.PluginManager) {
 			}
 		} else {
 			// 区分不同的情况
-			if config.AppConfig.EnabledPlugins == nil {
+			if config.GetEnabledPlugins() == nil {
 				fmt.Println("未设置插件列表 (ENABLED_PLUGINS)，未加载任何插件")
-			} else if len(config.AppConfig.EnabledPlugins) > 0 {
-				fmt.Printf("未找到指定的插件: %s\n", strings.Join(config.AppConfig.EnabledPlugins, ", "))
+			} else if len(config.GetEnabledPlugins()) > 0 {
+				fmt.Printf("未找到指定的插件: %s\n", strings.Join(config.GetEnabledPlugins(), ", "))
 			} else {
 				fmt.Println("插件列表为空 (ENABLED_PLUGINS=\"\")，未加载任何插件")
 			}
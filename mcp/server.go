@@ -0,0 +1,144 @@
+// Package mcp 内置的MCP（Model Context Protocol）服务
+// 支持stdio（pansou mcp）和HTTP（/mcp）两种传输方式，工具直接调用SearchService
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"pansou/service"
+	jsonutil "pansou/util/json"
+)
+
+// Server MCP服务，与传输方式无关，负责处理JSON-RPC消息
+type Server struct {
+	searchService *service.SearchService
+	tools         []tool
+}
+
+// NewServer 创建MCP服务
+func NewServer(searchService *service.SearchService) *Server {
+	return &Server{
+		searchService: searchService,
+		tools:         buildTools(),
+	}
+}
+
+// HandleMessage 处理一条JSON-RPC消息（单个请求或批量请求）
+// 返回需要发送给客户端的响应，消息全部为通知时返回nil
+func (s *Server) HandleMessage(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	// 批量请求
+	if trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := jsonutil.Unmarshal(trimmed, &batch); err != nil {
+			return marshalResponse(newErrorResponse(nil, codeParseError, "解析请求失败: "+err.Error()))
+		}
+		if len(batch) == 0 {
+			return marshalResponse(newErrorResponse(nil, codeInvalidRequest, "批量请求不能为空"))
+		}
+
+		responses := make([]rpcResponse, 0, len(batch))
+		for _, item := range batch {
+			if response, ok := s.handleSingle(item); ok {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		data, _ := jsonutil.Marshal(responses)
+		return data
+	}
+
+	if response, ok := s.handleSingle(trimmed); ok {
+		return marshalResponse(response)
+	}
+	return nil
+}
+
+// handleSingle 处理单个请求，通知不需要响应时返回false
+func (s *Server) handleSingle(data []byte) (rpcResponse, bool) {
+	var req rpcRequest
+	if err := jsonutil.Unmarshal(data, &req); err != nil {
+		return newErrorResponse(nil, codeParseError, "解析请求失败: "+err.Error()), true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return newErrorResponse(req.ID, codeInvalidRequest, "无效的JSON-RPC请求"), !req.isNotification()
+	}
+
+	result, rpcErr := s.dispatch(req)
+	if req.isNotification() {
+		return rpcResponse{}, false
+	}
+	if rpcErr != nil {
+		return newErrorResponse(req.ID, rpcErr.Code, rpcErr.Message), true
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}, true
+}
+
+// dispatch 根据方法名分发请求
+func (s *Server) dispatch(req rpcRequest) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if len(req.Params) > 0 {
+			if err := jsonutil.Unmarshal(req.Params, &params); err != nil {
+				return nil, &rpcError{Code: codeInvalidParams, Message: "无效的参数: " + err.Error()}
+			}
+		}
+		return map[string]interface{}{
+			"protocolVersion": negotiateProtocolVersion(params.ProtocolVersion),
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{},
+			},
+			"serverInfo": map[string]interface{}{
+				"name":    serverName,
+				"version": serverVersion,
+			},
+		}, nil
+
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+
+	case "ping":
+		return map[string]interface{}{}, nil
+
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+
+	case "tools/call":
+		var params toolCallParams
+		if err := jsonutil.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "无效的参数: " + err.Error()}
+		}
+		return s.callTool(params)
+
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("不支持的方法: %s", req.Method)}
+	}
+}
+
+// negotiateProtocolVersion 客户端请求的版本受支持时使用该版本，否则使用最新版本
+func negotiateProtocolVersion(requested string) string {
+	for _, version := range supportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return supportedProtocolVersions[0]
+}
+
+// marshalResponse 序列化响应
+func marshalResponse(response rpcResponse) []byte {
+	data, err := jsonutil.Marshal(response)
+	if err != nil {
+		data, _ = jsonutil.Marshal(newErrorResponse(response.ID, codeInternalError, "序列化响应失败: "+err.Error()))
+	}
+	return data
+}
@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// maxStdioMessageSize stdio模式下单条消息的最大长度
+const maxStdioMessageSize = 16 * 1024 * 1024
+
+// ServeStdio 以stdio方式提供MCP服务：每行一条JSON-RPC消息，读到EOF时返回
+// 每条消息在独立的goroutine中处理，耗时的搜索不会阻塞ping等其他请求
+func (s *Server) ServeStdio(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxStdioMessageSize)
+
+	var writeLock sync.Mutex
+	var wg sync.WaitGroup
+
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response := s.HandleMessage(line)
+			if response == nil {
+				return
+			}
+
+			writeLock.Lock()
+			defer writeLock.Unlock()
+			out.Write(append(response, '\n'))
+		}()
+	}
+
+	wg.Wait()
+	return scanner.Err()
+}
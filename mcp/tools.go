@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/service"
+	"pansou/util/schema"
+	jsonutil "pansou/util/json"
+)
+
+// buildTools 构建工具列表
+func buildTools() []tool {
+	emptySchema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+
+	return []tool{
+		{
+			Name:        "search",
+			Description: "搜索网盘资源，支持多种网盘类型和搜索来源，可以搜索电影、电视剧、软件、文档等各类资源",
+			InputSchema: schema.NewGenerator("").Describe(model.SearchRequest{}, model.SearchRequestDescriptions).Object(model.SearchRequest{}),
+		},
+		{
+			Name:        "health",
+			Description: "检查服务健康状态，获取插件和频道信息",
+			InputSchema: emptySchema,
+		},
+		{
+			Name:        "list_plugins",
+			Description: "列出当前已启用的搜索插件及其优先级",
+			InputSchema: emptySchema,
+		},
+		{
+			Name:        "list_channels",
+			Description: "列出默认搜索的TG频道",
+			InputSchema: emptySchema,
+		},
+	}
+}
+
+// callTool 执行工具调用
+// 工具执行失败通过isError返回给客户端，而不是JSON-RPC错误
+func (s *Server) callTool(params toolCallParams) (interface{}, *rpcError) {
+	var (
+		result interface{}
+		err    error
+	)
+
+	switch params.Name {
+	case "search":
+		result, err = s.callSearch(params.Arguments)
+	case "health":
+		result = s.healthInfo()
+	case "list_plugins":
+		result = s.pluginList()
+	case "list_channels":
+		result = map[string]interface{}{"channels": config.GetDefaultChannels()}
+	default:
+		return nil, &rpcError{Code: codeInvalidParams, Message: "未知的工具: " + params.Name}
+	}
+
+	if err != nil {
+		return toolResult{
+			Content: []toolContent{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}, nil
+	}
+
+	text, err := jsonutil.MarshalString(result)
+	if err != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: "序列化结果失败: " + err.Error()}
+	}
+	return toolResult{Content: []toolContent{{Type: "text", Text: text}}}, nil
+}
+
+// callSearch 执行搜索工具，参数与POST /api/search的请求体相同
+func (s *Server) callSearch(arguments []byte) (model.SearchResponse, error) {
+	var req model.SearchRequest
+	if len(arguments) > 0 {
+		if err := jsonutil.Unmarshal(arguments, &req); err != nil {
+			return model.SearchResponse{}, errors.New("无效的搜索参数: " + err.Error())
+		}
+	}
+	service.NormalizeSearchRequest(&req)
+
+	if strings.TrimSpace(req.Keyword) == "" && req.Cursor == "" {
+		return model.SearchResponse{}, errors.New("关键词不能为空")
+	}
+	return s.searchService.SearchByRequest(context.Background(), req)
+}
+
+// healthInfo 返回与GET /api/health相同的健康信息
+func (s *Server) healthInfo() map[string]interface{} {
+	pluginsEnabled := config.AppConfig.AsyncPluginEnabled
+	channels := config.GetDefaultChannels()
+	info := map[string]interface{}{
+		"status":          "ok",
+		"plugins_enabled": pluginsEnabled,
+		"channels":        channels,
+		"channels_count":  len(channels),
+	}
+
+	if pluginsEnabled {
+		pluginNames := []string{}
+		for _, p := range s.plugins() {
+			pluginNames = append(pluginNames, p["name"].(string))
+		}
+		info["plugin_count"] = len(pluginNames)
+		info["plugins"] = pluginNames
+		if s.searchService != nil && s.searchService.GetPluginManager() != nil {
+			info["plugin_health"] = s.searchService.GetPluginManager().PluginHealth()
+		}
+	}
+	return info
+}
+
+// pluginList 返回已启用插件列表
+func (s *Server) pluginList() map[string]interface{} {
+	return map[string]interface{}{"plugins": s.plugins()}
+}
+
+// plugins 获取已启用插件的名称和优先级
+func (s *Server) plugins() []map[string]interface{} {
+	plugins := []map[string]interface{}{}
+	if !config.AppConfig.AsyncPluginEnabled || s.searchService == nil || s.searchService.GetPluginManager() == nil {
+		return plugins
+	}
+	for _, p := range s.searchService.GetPluginManager().GetPlugins() {
+		plugins = append(plugins, map[string]interface{}{
+			"name":     p.Name(),
+			"priority": p.Priority(),
+		})
+	}
+	return plugins
+}
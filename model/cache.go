@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// 缓存层
+const (
+	CacheLayerMemory = "memory" // 内存缓存
+	CacheLayerDisk   = "disk"   // 本地磁盘缓存
+	CacheLayerRemote = "remote" // Redis协议的远程缓存
+)
+
+// CacheLayerEntry 缓存项在某一层中的状态
+type CacheLayerEntry struct {
+	Layer        string    `json:"layer" sonic:"layer"`                               // memory、disk、remote
+	Size         int       `json:"size" sonic:"size"`                                 // 数据大小（字节），远程缓存为压缩后存储的大小
+	LastModified time.Time `json:"last_modified" sonic:"last_modified"`               // 写入时间
+	ExpiresAt    time.Time `json:"expires_at,omitempty" sonic:"expires_at,omitempty"` // 过期时间，不过期时为空
+	TTLSeconds   int64     `json:"ttl_seconds" sonic:"ttl_seconds"`                   // 剩余有效期（秒），-1表示不过期
+}
+
+// CacheEntry 一个搜索缓存键的状态
+type CacheEntry struct {
+	Type     string            `json:"type" sonic:"type"`                             // tg或plugin
+	Key      string            `json:"key" sonic:"key"`                               // 缓存键
+	Keyword  string            `json:"keyword" sonic:"keyword"`                       // 生成缓存键使用的关键词
+	Channels []string          `json:"channels,omitempty" sonic:"channels,omitempty"` // TG缓存对应的频道
+	Plugins  []string          `json:"plugins,omitempty" sonic:"plugins,omitempty"`   // 插件缓存对应的插件，为空表示全部启用的插件
+	Cached   bool              `json:"cached" sonic:"cached"`                         // 是否在任意一层中存在
+	Results  int               `json:"results" sonic:"results"`                       // 缓存的结果数，未缓存或无法解析时为0
+	Layers   []CacheLayerEntry `json:"layers" sonic:"layers"`                         // 存在该键的各层
+}
+
+// CacheShardStats 缓存分片的统计
+type CacheShardStats struct {
+	Items int   `json:"items" sonic:"items"`
+	Bytes int64 `json:"bytes" sonic:"bytes"`
+}
+
+// CacheLayerStats 缓存层的统计
+type CacheLayerStats struct {
+	Layer  string            `json:"layer" sonic:"layer"`
+	Items  int               `json:"items" sonic:"items"`
+	Bytes  int64             `json:"bytes" sonic:"bytes"`                       // 远程缓存不统计，为0
+	Shards []CacheShardStats `json:"shards,omitempty" sonic:"shards,omitempty"` // 各分片的统计
+}
+
+// CacheStats 缓存整体统计
+type CacheStats struct {
+	Layers           []CacheLayerStats `json:"layers" sonic:"layers"`
+	PluginAsyncItems int               `json:"plugin_async_items" sonic:"plugin_async_items"` // 插件内部异步缓存的条目数
+}
+
+// CacheInvalidation 删除缓存的结果
+type CacheInvalidation struct {
+	Keys          []string `json:"keys" sonic:"keys"`                     // 删除的主缓存键
+	PluginEntries int      `json:"plugin_entries" sonic:"plugin_entries"` // 删除的插件内部异步缓存条目数
+}
+
+// CacheWarmResult 预热缓存的结果
+type CacheWarmResult struct {
+	Keyword   string `json:"keyword" sonic:"keyword"`
+	Total     int    `json:"total" sonic:"total"`           // 链接总数，与默认的merge结果一致
+	ElapsedMs int64  `json:"elapsed_ms" sonic:"elapsed_ms"` // 搜索耗时（毫秒）
+}
+
+// TrendingKeyword 热门搜索及其按时间衰减的搜索次数
+type TrendingKeyword struct {
+	Keyword    string    `json:"keyword" sonic:"keyword"`
+	Channels   []string  `json:"channels,omitempty" sonic:"channels,omitempty"`
+	SourceType string    `json:"src" sonic:"src"`
+	Plugins    []string  `json:"plugins,omitempty" sonic:"plugins,omitempty"` // 为空表示全部启用的插件
+	Score      float64   `json:"score" sonic:"score"`                         // 衰减后的搜索次数
+	LastSearch time.Time `json:"last_search" sonic:"last_search"`
+}
@@ -11,4 +11,28 @@ type SearchRequest struct {
 	Plugins      []string               `json:"plugins"`                     // 指定搜索的插件列表，不指定则搜索全部插件
 	Ext          map[string]interface{} `json:"ext"`                         // 扩展参数，用于传递给插件的自定义参数
 	CloudTypes   []string               `json:"cloud_types"`                 // 指定返回的网盘类型列表，不指定则返回所有类型
-} 
+	Sort         string                 `json:"sort"`                        // 排序方式：source(默认，来源优先级)、relevance(综合相关性)、time(发布时间)
+	Debug        bool                   `json:"debug"`                       // 调试模式，响应中附带各数据源的诊断信息
+	Limit        int                    `json:"limit"`                       // 每页数量，大于0时启用分页
+	Offset       int                    `json:"offset"`                      // 分页偏移量
+	Cursor       string                 `json:"cursor"`                      // 分页游标，由上一页响应的next_cursor提供
+}
+
+// SearchRequestDescriptions 搜索请求各字段的说明，键为json字段名
+// 用于生成MCP工具参数和OpenAPI文档，新增字段时请同步补充
+var SearchRequestDescriptions = map[string]string{
+	"kw":          "搜索关键词，支持查询语法，如：\"速度与激情\" -枪版 type:quark",
+	"channels":    "TG频道列表，不指定则使用默认配置的频道",
+	"conc":        "并发搜索数量，0或不指定则自动计算",
+	"refresh":     "强制刷新，不使用缓存",
+	"res":         "结果类型：all(返回所有结果)、results(仅返回results)、merge(仅返回按网盘类型分组的结果)、all_with_meta(等同all并附带诊断信息)",
+	"src":         "数据来源类型：all(全部来源)、tg(仅Telegram)、plugin(仅插件)",
+	"plugins":     "插件列表，不指定则使用所有可用插件",
+	"ext":         "扩展参数，传递给插件的自定义参数，如：{\"title_en\": \"Fast and Furious\", \"is_all\": true}",
+	"cloud_types": "网盘类型过滤，支持：baidu、aliyun、quark、tianyi、uc、mobile、115、pikpak、xunlei、123、magnet、ed2k",
+	"sort":        "排序方式：source(默认，按来源优先级和发布时间分段排序)、relevance(按关键词相关性、发布时间、来源优先级和链接质量综合排序)、time(按发布时间从新到旧)",
+	"debug":       "调试模式，响应中附带各数据源的诊断信息",
+	"limit":       "每页数量，大于0时启用分页",
+	"offset":      "分页偏移量",
+	"cursor":      "分页游标，取自上一页结果的next_cursor",
+}
@@ -40,6 +40,29 @@ type SearchResponse struct {
 	Total        int           `json:"total" sonic:"total"`
 	Results      []SearchResult `json:"results,omitempty" sonic:"results,omitempty"`
 	MergedByType MergedLinks   `json:"merged_by_type,omitempty" sonic:"merged_by_type,omitempty"`
+	Sources      []SourceDiagnostic `json:"sources,omitempty" sonic:"sources,omitempty"` // 各数据源诊断信息，仅debug模式返回
+	NextCursor   string        `json:"next_cursor,omitempty" sonic:"next_cursor,omitempty"` // 下一页游标，分页时返回，没有更多数据时为空
+	CacheAge     int64         `json:"cache_age,omitempty" sonic:"cache_age,omitempty"` // 结果来自缓存时距写入缓存的秒数，TG和插件缓存取较早写入的一个
+	Stale        bool          `json:"stale,omitempty" sonic:"stale,omitempty"` // 缓存已超过有效期（CACHE_TTL），返回的是过期缓存，后台刷新中
+}
+
+// 数据源缓存状态
+const (
+	SourceCacheHit   = "hit"   // 命中缓存
+	SourceCacheMiss  = "miss"  // 未命中缓存，实际发起搜索
+	SourceCacheStale = "stale" // 命中已过期缓存，后台刷新中
+)
+
+// SourceDiagnostic 单个数据源（TG频道或插件）的诊断信息
+type SourceDiagnostic struct {
+	Source      string `json:"source" sonic:"source"`                   // 数据来源：tg:频道名 或 plugin:插件名
+	RawCount    int    `json:"raw_count" sonic:"raw_count"`             // 数据源返回的原始结果数
+	ResultCount int    `json:"result_count" sonic:"result_count"`       // 过滤后保留在results中的结果数
+	MergedCount int    `json:"merged_count" sonic:"merged_count"`       // 过滤去重后计入merged_by_type的链接数
+	Cache       string `json:"cache" sonic:"cache"`                     // 缓存状态：hit、miss、stale
+	ElapsedMs   int64  `json:"elapsed_ms" sonic:"elapsed_ms"`           // 耗时（毫秒），命中缓存时为0
+	Error       string `json:"error,omitempty" sonic:"error,omitempty"` // 错误信息
+	IsFinal     bool   `json:"is_final" sonic:"is_final"`               // 是否为最终结果
 }
 
 // Response API通用响应
@@ -0,0 +1,21 @@
+package model
+
+// 流式搜索事件类型
+const (
+	SearchEventSource = "source" // 单个TG频道或插件完成
+	SearchEventDone   = "done"   // 全部来源完成
+)
+
+// SearchEvent 流式搜索事件
+type SearchEvent struct {
+	Type         string      `json:"type" sonic:"type"`                                         // 事件类型：source、done
+	Source       string      `json:"source,omitempty" sonic:"source,omitempty"`                 // 数据来源：tg:频道名 或 plugin:插件名
+	Count        int         `json:"count" sonic:"count"`                                       // 该来源返回的结果数（done事件为全部结果数）
+	ElapsedMs    int64       `json:"elapsed_ms" sonic:"elapsed_ms"`                             // 该来源耗时（done事件为总耗时），单位毫秒
+	Error        string      `json:"error,omitempty" sonic:"error,omitempty"`                   // 错误信息
+	IsFinal      bool        `json:"is_final" sonic:"is_final"`                                 // 是否为该来源的最终结果
+	Cached       bool        `json:"cached,omitempty" sonic:"cached,omitempty"`                 // 是否来自缓存
+	Stale        bool        `json:"stale,omitempty" sonic:"stale,omitempty"`                   // 缓存已超过有效期，后台刷新中
+	Total        int         `json:"total" sonic:"total"`                                       // 截至当前累计的链接总数
+	MergedByType MergedLinks `json:"merged_by_type,omitempty" sonic:"merged_by_type,omitempty"` // source事件为本次新增链接，done事件为完整排序结果
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// 搜索任务状态
+const (
+	SearchJobRunning   = "running"   // 仍有数据源未完成
+	SearchJobCompleted = "completed" // 全部数据源已完成
+)
+
+// SearchJobSource 搜索任务中单个数据源的完成状态
+type SearchJobSource struct {
+	Source    string `json:"source" sonic:"source"`                   // 数据来源：tg:频道名 或 plugin:插件名
+	Count     int    `json:"count" sonic:"count"`                     // 该来源返回的结果数
+	IsFinal   bool   `json:"is_final" sonic:"is_final"`               // 是否为该来源的最终结果
+	Cached    bool   `json:"cached,omitempty" sonic:"cached,omitempty"` // 是否来自缓存
+	ElapsedMs int64  `json:"elapsed_ms" sonic:"elapsed_ms"`           // 该来源耗时（毫秒）
+	Error     string `json:"error,omitempty" sonic:"error,omitempty"` // 错误信息
+}
+
+// SearchJob 异步搜索任务
+type SearchJob struct {
+	ID           string            `json:"id" sonic:"id"`
+	Status       string            `json:"status" sonic:"status"` // running、completed
+	Keyword      string            `json:"keyword" sonic:"keyword"`
+	CreatedAt    time.Time         `json:"created_at" sonic:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at" sonic:"updated_at"`
+	ExpiresAt    time.Time         `json:"expires_at" sonic:"expires_at"` // 任务过期时间，过期后将被清理
+	Total        int               `json:"total" sonic:"total"`           // 当前快照中的链接总数
+	Sources      []SearchJobSource `json:"sources" sonic:"sources"`
+	MergedByType MergedLinks       `json:"merged_by_type,omitempty" sonic:"merged_by_type,omitempty"` // 当前合并快照，完成后为排序后的完整结果
+	Error        string            `json:"error,omitempty" sonic:"error,omitempty"`                   // 搜索失败时的错误信息，此时status为completed
+}
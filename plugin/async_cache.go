@@ -0,0 +1,33 @@
+package plugin
+
+import "strings"
+
+// DeleteAsyncCache 删除插件内部异步缓存中match返回true的条目，返回删除的条数
+// 异步缓存的键为“插件名:关键词”，关键词为插件收到的原始关键词
+func DeleteAsyncCache(match func(pluginName, keyword string) bool) int {
+	deleted := 0
+	apiResponseCache.Range(func(key, value interface{}) bool {
+		keyStr, ok := key.(string)
+		if !ok {
+			return true
+		}
+		name, keyword, _ := strings.Cut(keyStr, ":")
+		if match(name, keyword) {
+			apiResponseCache.Delete(key)
+			cacheAccessCount.Delete(key)
+			deleted++
+		}
+		return true
+	})
+	return deleted
+}
+
+// AsyncCacheSize 插件内部异步缓存的条目数
+func AsyncCacheSize() int {
+	size := 0
+	apiResponseCache.Range(func(key, value interface{}) bool {
+		size++
+		return true
+	})
+	return size
+}
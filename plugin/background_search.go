@@ -0,0 +1,63 @@
+package plugin
+
+import "sync"
+
+// backgroundSearch 同一插件、同一主缓存键下进行中的后台搜索
+type backgroundSearch struct {
+	running int
+	done    chan struct{} // 全部后台搜索完成时关闭
+}
+
+// 进行中的后台搜索（响应超时后转入后台的搜索、过期缓存的后台刷新），键为插件名加主缓存键
+var (
+	backgroundSearches     = make(map[string]*backgroundSearch)
+	backgroundSearchesLock sync.Mutex
+)
+
+// backgroundSearchKey 后台搜索的登记键
+func backgroundSearchKey(pluginName, mainCacheKey string) string {
+	return pluginName + "|" + mainCacheKey
+}
+
+// startBackgroundSearch 登记一个后台搜索，返回搜索结束（包括失败、没有可用工作槽）时调用的函数
+// 需要在返回非最终结果之前登记，调用方才能通过BackgroundSearchDone判断是否还会有后台结果
+func startBackgroundSearch(pluginName, mainCacheKey string) func() {
+	if mainCacheKey == "" {
+		return func() {}
+	}
+
+	key := backgroundSearchKey(pluginName, mainCacheKey)
+	backgroundSearchesLock.Lock()
+	search := backgroundSearches[key]
+	if search == nil {
+		search = &backgroundSearch{done: make(chan struct{})}
+		backgroundSearches[key] = search
+	}
+	search.running++
+	backgroundSearchesLock.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			backgroundSearchesLock.Lock()
+			defer backgroundSearchesLock.Unlock()
+			search.running--
+			if search.running == 0 {
+				delete(backgroundSearches, key)
+				close(search.done)
+			}
+		})
+	}
+}
+
+// BackgroundSearchDone 返回插件在该主缓存键下进行中的后台搜索全部结束时关闭的通道
+// 没有进行中的后台搜索时返回nil，此时插件返回的非最终结果（如过期缓存）之后不会再有更新
+// 后台搜索在结束前通过主缓存更新函数回写结果，因此通道关闭时结果已经写回
+func BackgroundSearchDone(pluginName, mainCacheKey string) <-chan struct{} {
+	backgroundSearchesLock.Lock()
+	defer backgroundSearchesLock.Unlock()
+	if search := backgroundSearches[backgroundSearchKey(pluginName, mainCacheKey)]; search != nil {
+		return search.done
+	}
+	return nil
+}
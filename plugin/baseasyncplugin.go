@@ -1,9 +1,9 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -209,6 +209,8 @@ type BaseAsyncPlugin struct {
 	finalUpdateTracker map[string]bool // 追踪已更新的最终结果缓存
 	finalUpdateMutex   sync.RWMutex  // 保护finalUpdateTracker的并发访问
 	skipServiceFilter  bool          // 是否跳过Service层的关键词过滤
+	searchObserver     func(time.Duration, error) // 每次实际搜索完成后的回调，用于健康统计和熔断
+	configured         configuredClients // 按插件配置的超时和代理创建的客户端
 }
 
 // NewBaseAsyncPlugin 创建基础异步插件
@@ -225,9 +227,9 @@ func NewBaseAsyncPlugin(name string, priority int) *BaseAsyncPlugin {
 	
 	// 如果配置已初始化，则使用配置中的值
 	if config.AppConfig != nil {
-		responseTimeout = config.AppConfig.AsyncResponseTimeoutDur
-		processingTimeout = config.AppConfig.PluginTimeout
-		cacheTTL = time.Duration(config.AppConfig.AsyncCacheTTLHours) * time.Hour
+		responseTimeout = config.GetAsyncResponseTimeout()
+		processingTimeout = config.GetPluginTimeout()
+		cacheTTL = config.GetAsyncCacheTTL()
 	}
 	
 	return &BaseAsyncPlugin{
@@ -259,9 +261,9 @@ func NewBaseAsyncPluginWithFilter(name string, priority int, skipServiceFilter b
 	
 	// 如果配置已初始化，则使用配置中的值
 	if config.AppConfig != nil {
-		responseTimeout = config.AppConfig.AsyncResponseTimeoutDur
-		processingTimeout = config.AppConfig.PluginTimeout
-		cacheTTL = time.Duration(config.AppConfig.AsyncCacheTTLHours) * time.Hour
+		responseTimeout = config.GetAsyncResponseTimeout()
+		processingTimeout = config.GetPluginTimeout()
+		cacheTTL = config.GetAsyncCacheTTL()
 	}
 	
 	return &BaseAsyncPlugin{
@@ -294,13 +296,21 @@ func (p *BaseAsyncPlugin) SetMainCacheUpdater(updater func(string, []model.Searc
 	p.mainCacheUpdater = updater
 }
 
+// SetSearchObserver 设置实际搜索完成后的回调
+func (p *BaseAsyncPlugin) SetSearchObserver(observer func(time.Duration, error)) {
+	p.searchObserver = observer
+}
+
 // Name 返回插件名称
 func (p *BaseAsyncPlugin) Name() string {
 	return p.name
 }
 
-// Priority 返回插件优先级
+// Priority 返回插件优先级，插件配置了priority时使用配置的值
 func (p *BaseAsyncPlugin) Priority() int {
+	if priority := p.PluginConfig().Priority; priority > 0 {
+		return priority
+	}
 	return p.priority
 }
 
@@ -321,7 +331,8 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		ext = make(map[string]interface{})
 	}
 	
-	now := time.Now()
+	// 记录每次实际搜索的耗时、结果数和错误
+	searchFunc = p.instrumentSearch(searchFunc)
 	
 	// 修改缓存键，确保包含插件名称
 	pluginSpecificCacheKey := fmt.Sprintf("%s:%s", p.name, keyword)
@@ -364,6 +375,26 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 	
 	recordCacheMiss()
 	
+	// 相同插件、相同主缓存键的并发请求合并为一次实际搜索，超时返回的部分结果同样共享
+	result, err, shared := asyncSearchFlight.Do(context.Background(), p.flightKey(mainCacheKey, keyword), func(context.Context) ([]model.SearchResult, error) {
+		return p.asyncSearchUncached(keyword, searchFunc, pluginSpecificCacheKey, mainCacheKey, ext)
+	})
+	if shared {
+		pluginSharedSearches.Inc(p.name)
+	}
+	return result, err
+}
+
+// asyncSearchUncached AsyncSearch未命中缓存时实际执行搜索
+func (p *BaseAsyncPlugin) asyncSearchUncached(
+	keyword string,
+	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
+	pluginSpecificCacheKey string,
+	mainCacheKey string,
+	ext map[string]interface{},
+) ([]model.SearchResult, error) {
+	now := time.Now()
+	
 	// 创建通道
 	resultChan := make(chan []model.SearchResult, 1)
 	errorChan := make(chan error, 1)
@@ -374,7 +405,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		// 尝试获取工作槽
 		if !acquireWorkerSlot() {
 			// 工作池已满，使用快速响应客户端直接处理
-			results, err := searchFunc(p.client, keyword, ext)
+			results, err := searchFunc(p.GetClient(), keyword, ext)
 			if err != nil {
 				select {
 				case errorChan <- err:
@@ -405,7 +436,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		defer releaseWorkerSlot()
 		
 		// 执行搜索
-		results, err := searchFunc(p.backgroundClient, keyword, ext)
+		results, err := searchFunc(p.getBackgroundClient(), keyword, ext)
 		
 		// 检查是否已经响应
 		select {
@@ -518,7 +549,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 	// 获取响应超时时间
 	responseTimeout := defaultAsyncResponseTimeout
 	if config.AppConfig != nil {
-		responseTimeout = config.AppConfig.AsyncResponseTimeoutDur
+		responseTimeout = config.GetAsyncResponseTimeout()
 	}
 	
 	// 等待响应超时或结果
@@ -531,6 +562,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		return nil, err
 	case <-time.After(responseTimeout):
 		// 插件响应超时，后台继续处理（优化完成，日志简化）
+		recordPluginTimeout(p.name)
 		
 		// 响应超时，返回空结果，后台继续处理
 		go func() {
@@ -573,12 +605,30 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	mainCacheKey string,
 	ext map[string]interface{},
 ) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(context.Background(), keyword, searchFunc, mainCacheKey, ext)
+}
+
+// AsyncSearchWithResultContext 支持取消的AsyncSearchWithResult
+// searchFunc收到的客户端绑定了ctx，ctx取消时其HTTP请求（包括详情页等后续请求）随之中止，方法立即返回ctx.Err()；
+// 响应超时后转入后台的搜索和缓存刷新不受ctx影响
+func (p *BaseAsyncPlugin) AsyncSearchWithResultContext(
+	ctx context.Context,
+	keyword string,
+	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
+	mainCacheKey string,
+	ext map[string]interface{},
+) (model.PluginSearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PluginSearchResult{}, err
+	}
+	
 	// 确保ext不为nil
 	if ext == nil {
 		ext = make(map[string]interface{})
 	}
 	
-	now := time.Now()
+	// 记录每次实际搜索的耗时、结果数和错误
+	searchFunc = p.instrumentSearch(searchFunc)
 	
 	// 修改缓存键，确保包含插件名称
 	pluginSpecificCacheKey := fmt.Sprintf("%s:%s", p.name, keyword)
@@ -613,8 +663,12 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 			
 			// 标记为部分过期
 			if time.Since(cachedResult.Timestamp) >= p.cacheTTL {
-				// 在后台刷新缓存
-				go p.refreshCacheInBackground(keyword, pluginSpecificCacheKey, searchFunc, cachedResult, mainCacheKey, ext)
+				// 在后台刷新缓存，登记后流式搜索据此等待刷新结果
+				finishRefresh := startBackgroundSearch(p.name, mainCacheKey)
+				go func() {
+					defer finishRefresh()
+					p.refreshCacheInBackground(keyword, pluginSpecificCacheKey, searchFunc, cachedResult, mainCacheKey, ext)
+				}()
 			}
 			
 			return model.PluginSearchResult{
@@ -629,25 +683,41 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	
 	recordCacheMiss()
 	
+	// 相同插件、相同主缓存键的并发请求合并为一次实际搜索，超时返回的部分结果同样共享
+	result, err, shared := searchWithResultFlight.Do(ctx, p.flightKey(mainCacheKey, keyword), func(ctx context.Context) (model.PluginSearchResult, error) {
+		return p.searchWithResultUncached(ctx, keyword, searchFunc, pluginSpecificCacheKey, mainCacheKey, ext)
+	})
+	if shared {
+		pluginSharedSearches.Inc(p.name)
+	}
+	return result, err
+}
+
+// searchWithResultUncached AsyncSearchWithResultContext未命中缓存时实际执行搜索，ctx在所有合并的请求都取消后才取消
+func (p *BaseAsyncPlugin) searchWithResultUncached(
+	ctx context.Context,
+	keyword string,
+	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
+	pluginSpecificCacheKey string,
+	mainCacheKey string,
+	ext map[string]interface{},
+) (model.PluginSearchResult, error) {
+	now := time.Now()
+	
 	// 创建通道
 	resultChan := make(chan []model.SearchResult, 1)
 	errorChan := make(chan error, 1)
-	doneChan := make(chan struct{})
+	
+	// 搜索在响应超时后转入后台继续执行，因此不直接绑定请求ctx（合并的请求全部返回后ctx即被取消）：
+	// 超时前请求取消时主动取消搜索，超时后与请求解绑，由客户端自身的超时限制
+	fetchCtx, cancelFetch := context.WithCancel(context.WithoutCancel(ctx))
 	
 	// 启动后台处理
 	go func() {
-		defer func() {
-			select {
-			case <-doneChan:
-			default:
-				close(doneChan)
-			}
-		}()
-		
 		// 尝试获取工作槽
 		if !acquireWorkerSlot() {
 			// 工作池已满，使用快速响应客户端直接处理
-			results, err := searchFunc(p.client, keyword, ext)
+			results, err := searchFunc(WithContext(fetchCtx, p.GetClient()), keyword, ext)
 			if err != nil {
 				select {
 				case errorChan <- err:
@@ -665,7 +735,7 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 		defer releaseWorkerSlot()
 		
 		// 使用长超时客户端进行搜索
-		results, err := searchFunc(p.backgroundClient, keyword, ext)
+		results, err := searchFunc(WithContext(fetchCtx, p.getBackgroundClient()), keyword, ext)
 		if err != nil {
 			select {
 			case errorChan <- err:
@@ -682,12 +752,12 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	// 等待结果或超时
 	responseTimeout := defaultAsyncResponseTimeout
 	if config.AppConfig != nil {
-		responseTimeout = config.AppConfig.AsyncResponseTimeoutDur
+		responseTimeout = config.GetAsyncResponseTimeout()
 	}
 	
 	select {
 	case results := <-resultChan:
-		// 不直接关闭，让defer处理
+		cancelFetch()
 		
 		// 缓存结果
 		apiResponseCache.Store(pluginSpecificCacheKey, cachedResponse{
@@ -716,12 +786,22 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 		}, nil
 		
 	case err := <-errorChan:
-		// 不直接关闭，让defer处理
+		cancelFetch()
 		return model.PluginSearchResult{}, err
 		
+	case <-ctx.Done():
+		// 请求已取消，中止进行中的HTTP请求，不再转入后台
+		cancelFetch()
+		return model.PluginSearchResult{}, ctx.Err()
+		
 	case <-time.After(responseTimeout):
-		// 🔥 超时处理：返回空结果，后台继续处理
-		go p.completeSearchInBackground(keyword, searchFunc, pluginSpecificCacheKey, mainCacheKey, doneChan, ext)
+		// 🔥 超时处理：返回空结果，已发起的搜索在后台继续，完成后更新缓存
+		recordPluginTimeout(p.name)
+		finishBackground := startBackgroundSearch(p.name, mainCacheKey)
+		go func() {
+			defer finishBackground()
+			p.completeSearchInBackground(resultChan, errorChan, cancelFetch, pluginSpecificCacheKey, mainCacheKey)
+		}()
 		
 		// 存储临时缓存（标记为不完整）
 		apiResponseCache.Store(pluginSpecificCacheKey, cachedResponse{
@@ -742,26 +822,20 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	}
 }
 
-// completeSearchInBackground 后台完成搜索
+// completeSearchInBackground 等待响应超时后仍在进行的搜索完成，并用结果更新缓存
 func (p *BaseAsyncPlugin) completeSearchInBackground(
-	keyword string,
-	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
+	resultChan <-chan []model.SearchResult,
+	errorChan <-chan error,
+	cancelFetch context.CancelFunc,
 	pluginCacheKey string,
 	mainCacheKey string,
-	doneChan chan struct{},
-	ext map[string]interface{},
 ) {
-	defer func() {
-		select {
-		case <-doneChan:
-		default:
-			close(doneChan)
-		}
-	}()
+	defer cancelFetch()
 	
-	// 执行完整搜索
-	results, err := searchFunc(p.backgroundClient, keyword, ext)
-	if err != nil {
+	var results []model.SearchResult
+	select {
+	case results = <-resultChan:
+	case <-errorChan:
 		return
 	}
 	
@@ -811,7 +885,7 @@ func (p *BaseAsyncPlugin) refreshCacheInBackground(
 	refreshStart := time.Now()
 	
 	// 执行搜索
-	results, err := searchFunc(p.backgroundClient, keyword, ext)
+	results, err := searchFunc(p.getBackgroundClient(), keyword, ext)
 	if err != nil || len(results) == 0 {
 		return
 	}
@@ -872,9 +946,9 @@ func (p *BaseAsyncPlugin) updateMainCacheWithFinal(cacheKey string, results []mo
 	
 	// 🔥 增强防重复更新机制 - 使用数据哈希确保真正的去重
 	// 生成结果数据的简单哈希标识
-	dataHash := fmt.Sprintf("%d_%d", len(results), results[0].UniqueID)
+	dataHash := fmt.Sprintf("%d_%s", len(results), results[0].UniqueID)
 	if len(results) > 1 {
-		dataHash += fmt.Sprintf("_%d", results[len(results)-1].UniqueID)
+		dataHash += fmt.Sprintf("_%s", results[len(results)-1].UniqueID)
 	}
 	updateKey := fmt.Sprintf("final_%s_%s_%s_%t", p.name, cacheKey, dataHash, isFinal)
 	
@@ -898,45 +972,29 @@ func (p *BaseAsyncPlugin) updateMainCacheWithFinal(cacheKey string, results []mo
 
 // FilterResultsByKeyword 根据关键词过滤搜索结果
 func (p *BaseAsyncPlugin) FilterResultsByKeyword(results []model.SearchResult, keyword string) []model.SearchResult {
-	if keyword == "" {
-		return results
-	}
-	
-	// 预估过滤后会保留80%的结果
-	filteredResults := make([]model.SearchResult, 0, len(results)*8/10)
-
-	// 将关键词转为小写，用于不区分大小写的比较
-	lowerKeyword := strings.ToLower(keyword)
-
-	// 将关键词按空格分割，用于支持多关键词搜索
-	keywords := strings.Fields(lowerKeyword)
-
-	for _, result := range results {
-		// 将标题和内容转为小写
-		lowerTitle := strings.ToLower(result.Title)
-		lowerContent := strings.ToLower(result.Content)
-
-		// 检查每个关键词是否在标题或内容中
-		matched := true
-		for _, kw := range keywords {
-			// 对于所有关键词，检查是否在标题或内容中
-			if !strings.Contains(lowerTitle, kw) && !strings.Contains(lowerContent, kw) {
-				matched = false
-				break
-			}
-		}
-
-		if matched {
-			filteredResults = append(filteredResults, result)
-		}
-	}
-
-	return filteredResults
+	return FilterResultsByKeyword(results, keyword)
 } 
 
 // GetClient 返回短超时客户端
 func (p *BaseAsyncPlugin) GetClient() *http.Client {
-	return p.client
+	client, _ := p.httpClients()
+	return client
+}
+
+// getBackgroundClient 返回长超时客户端
+func (p *BaseAsyncPlugin) getBackgroundClient() *http.Client {
+	_, client := p.httpClients()
+	return client
+}
+
+// SetTransport 替换插件HTTP客户端使用的Transport，用于测试时录制和回放请求
+func (p *BaseAsyncPlugin) SetTransport(transport http.RoundTripper) {
+	p.client.Transport = transport
+	p.backgroundClient.Transport = transport
+
+	p.configured.mu.Lock()
+	p.configured.signature = ""
+	p.configured.mu.Unlock()
 }
 
 // hasUpdatedFinalCache 检查是否已经更新过指定的最终结果缓存
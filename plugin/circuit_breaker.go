@@ -0,0 +1,248 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/util/metrics"
+)
+
+// 熔断状态
+const (
+	CircuitClosed   = "closed"    // 正常
+	CircuitOpen     = "open"      // 熔断中，搜索时跳过
+	CircuitHalfOpen = "half_open" // 熔断时间已过，放行一次试探请求
+)
+
+// healthWindowSize 统计成功率和耗时的滚动窗口大小
+const healthWindowSize = 20
+
+// ErrCircuitOpen 插件处于熔断状态
+var ErrCircuitOpen = errors.New("插件连续失败，已暂时熔断")
+
+// pluginCircuitState 插件熔断状态指标：0正常，1试探中，2熔断中
+var pluginCircuitState = metrics.NewGaugeVec("pansou_plugin_circuit_state",
+	"插件熔断状态：0正常，1试探中，2熔断中", "plugin")
+
+// PluginHealth 插件健康状态
+type PluginHealth struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`                // closed、open或half_open
+	SuccessRate         float64    `json:"success_rate"`         // 最近请求的成功率
+	AvgLatencyMs        int64      `json:"avg_latency_ms"`       // 最近请求的平均耗时
+	Samples             int        `json:"samples"`              // 参与统计的最近请求数
+	ConsecutiveFailures int        `json:"consecutive_failures"` // 连续失败次数
+	Timeouts            int        `json:"timeouts"`             // 最近请求中超时的次数
+	RetryAt             *time.Time `json:"retry_at,omitempty"`   // 熔断中时，下次放行试探请求的时间
+}
+
+// searchOutcome 一次实际搜索的结果
+type searchOutcome struct {
+	success bool
+	timeout bool
+	latency time.Duration
+}
+
+// pluginCircuit 单个插件的熔断器
+type pluginCircuit struct {
+	state               string
+	window              [healthWindowSize]searchOutcome
+	count               int // 窗口中的有效记录数
+	next                int // 下一条记录写入的位置
+	consecutiveFailures int
+	openedAt            time.Time
+	probeStartedAt      time.Time // 试探请求开始时间，零值表示没有进行中的试探
+}
+
+// CircuitBreaker 插件熔断器
+// 插件连续失败（包括超时）达到阈值后熔断，熔断期间搜索时跳过该插件；
+// 熔断时间过后放行一次试探请求，成功则恢复，失败则继续熔断。
+// 是否熔断只看连续失败次数，窗口内的成功率和耗时只用于健康状态展示，
+// 成功和失败交替出现的插件不会被熔断
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	circuits map[string]*pluginCircuit
+
+	failureThreshold int
+	openDuration     time.Duration
+	probeTimeout     time.Duration // 试探请求未上报结果（如命中插件缓存）时，多久后允许再次试探
+}
+
+// NewCircuitBreaker 根据配置创建熔断器
+func NewCircuitBreaker() *CircuitBreaker {
+	breaker := &CircuitBreaker{
+		circuits:         make(map[string]*pluginCircuit),
+		failureThreshold: 5,
+		openDuration:     60 * time.Second,
+		probeTimeout:     defaultPluginTimeout,
+	}
+	if config.AppConfig != nil {
+		breaker.failureThreshold = config.AppConfig.CircuitFailureThreshold
+		breaker.openDuration = config.AppConfig.CircuitOpenDuration
+		breaker.probeTimeout = config.GetPluginTimeout()
+	}
+	return breaker
+}
+
+// Track 登记插件，使其在尚未搜索时也出现在健康状态中
+func (b *CircuitBreaker) Track(name string) {
+	b.mu.Lock()
+	if _, exists := b.circuits[name]; !exists {
+		b.circuit(name)
+		pluginCircuitState.Set(0, name)
+	}
+	b.mu.Unlock()
+}
+
+// Allow 判断插件是否可以搜索
+func (b *CircuitBreaker) Allow(name string) bool {
+	if b == nil || b.failureThreshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circuit := b.circuit(name)
+	now := time.Now()
+	switch circuit.state {
+	case CircuitOpen:
+		if now.Sub(circuit.openedAt) < b.openDuration {
+			return false
+		}
+		b.setState(name, circuit, CircuitHalfOpen)
+		circuit.probeStartedAt = now
+		return true
+	case CircuitHalfOpen:
+		// 同一时间只放行一个试探请求
+		if !circuit.probeStartedAt.IsZero() && now.Sub(circuit.probeStartedAt) < b.probeTimeout {
+			return false
+		}
+		circuit.probeStartedAt = now
+		return true
+	default:
+		return true
+	}
+}
+
+// Record 记录一次实际搜索的结果，调用方取消的搜索不计入统计
+func (b *CircuitBreaker) Record(name string, latency time.Duration, err error) {
+	if b == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circuit := b.circuit(name)
+	outcome := searchOutcome{success: err == nil, timeout: isTimeoutError(err), latency: latency}
+	circuit.window[circuit.next] = outcome
+	circuit.next = (circuit.next + 1) % healthWindowSize
+	if circuit.count < healthWindowSize {
+		circuit.count++
+	}
+
+	if outcome.success {
+		circuit.consecutiveFailures = 0
+		if circuit.state != CircuitClosed {
+			b.setState(name, circuit, CircuitClosed)
+		}
+		return
+	}
+
+	circuit.consecutiveFailures++
+	switch {
+	case circuit.state == CircuitHalfOpen:
+		// 试探失败，重新熔断
+		circuit.openedAt = time.Now()
+		b.setState(name, circuit, CircuitOpen)
+	case circuit.state == CircuitClosed && b.failureThreshold > 0 && circuit.consecutiveFailures >= b.failureThreshold:
+		circuit.openedAt = time.Now()
+		b.setState(name, circuit, CircuitOpen)
+	}
+}
+
+// Health 返回各插件的健康状态，按名称排序
+func (b *CircuitBreaker) Health() []PluginHealth {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := make([]PluginHealth, 0, len(b.circuits))
+	for name, circuit := range b.circuits {
+		item := PluginHealth{
+			Name:                name,
+			State:               circuit.state,
+			Samples:             circuit.count,
+			ConsecutiveFailures: circuit.consecutiveFailures,
+			SuccessRate:         1,
+		}
+
+		if circuit.count > 0 {
+			successes := 0
+			var totalLatency time.Duration
+			for i := 0; i < circuit.count; i++ {
+				outcome := circuit.window[i]
+				if outcome.success {
+					successes++
+				}
+				if outcome.timeout {
+					item.Timeouts++
+				}
+				totalLatency += outcome.latency
+			}
+			item.SuccessRate = float64(successes) / float64(circuit.count)
+			item.AvgLatencyMs = (totalLatency / time.Duration(circuit.count)).Milliseconds()
+		}
+
+		if circuit.state == CircuitOpen {
+			retryAt := circuit.openedAt.Add(b.openDuration)
+			item.RetryAt = &retryAt
+		}
+		health = append(health, item)
+	}
+
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].Name < health[j].Name
+	})
+	return health
+}
+
+// circuit 获取插件的熔断器，调用方需持有b.mu
+func (b *CircuitBreaker) circuit(name string) *pluginCircuit {
+	circuit, exists := b.circuits[name]
+	if !exists {
+		circuit = &pluginCircuit{state: CircuitClosed}
+		b.circuits[name] = circuit
+	}
+	return circuit
+}
+
+// setState 切换状态并更新指标，调用方需持有b.mu
+func (b *CircuitBreaker) setState(name string, circuit *pluginCircuit, state string) {
+	circuit.state = state
+	circuit.probeStartedAt = time.Time{}
+
+	switch state {
+	case CircuitOpen:
+		pluginCircuitState.Set(2, name)
+	case CircuitHalfOpen:
+		pluginCircuitState.Set(1, name)
+	default:
+		pluginCircuitState.Set(0, name)
+	}
+}
+
+// isTimeoutError 判断是否为超时错误
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errSearchFailed = errors.New("搜索失败")
+
+// newTestCircuitBreaker 创建不依赖全局配置的熔断器
+func newTestCircuitBreaker(threshold int) *CircuitBreaker {
+	return &CircuitBreaker{
+		circuits:         make(map[string]*pluginCircuit),
+		failureThreshold: threshold,
+		openDuration:     time.Minute,
+		probeTimeout:     10 * time.Second,
+	}
+}
+
+// expireOpen 让熔断时间提前结束
+func expireOpen(b *CircuitBreaker, name string) {
+	b.circuits[name].openedAt = time.Now().Add(-b.openDuration)
+}
+
+func TestCircuitBreakerOpensAtThreshold(t *testing.T) {
+	b := newTestCircuitBreaker(3)
+
+	for i := 0; i < 2; i++ {
+		b.Record("p", time.Millisecond, errSearchFailed)
+	}
+	if !b.Allow("p") {
+		t.Fatal("未达到阈值时应放行")
+	}
+	b.Record("p", time.Millisecond, errSearchFailed)
+	if b.Allow("p") {
+		t.Fatal("连续失败达到阈值后应熔断")
+	}
+	if state := b.circuits["p"].state; state != CircuitOpen {
+		t.Fatalf("状态为%s，应为%s", state, CircuitOpen)
+	}
+}
+
+func TestCircuitBreakerCountsOnlyConsecutiveFailures(t *testing.T) {
+	b := newTestCircuitBreaker(3)
+
+	// 成功率只有50%，但连续失败次数从未达到阈值
+	for i := 0; i < 10; i++ {
+		b.Record("p", time.Millisecond, errSearchFailed)
+		b.Record("p", time.Millisecond, nil)
+	}
+	if !b.Allow("p") {
+		t.Fatal("失败不连续时不应熔断")
+	}
+
+	// 取消的搜索不计入
+	for i := 0; i < 5; i++ {
+		b.Record("p", time.Millisecond, context.Canceled)
+	}
+	if !b.Allow("p") || b.circuits["p"].consecutiveFailures != 0 {
+		t.Fatal("取消的搜索不应计入连续失败")
+	}
+}
+
+func TestCircuitBreakerHalfOpenSingleProbe(t *testing.T) {
+	b := newTestCircuitBreaker(1)
+	b.Record("p", time.Millisecond, errSearchFailed)
+	expireOpen(b, "p")
+
+	if !b.Allow("p") {
+		t.Fatal("熔断时间过后应放行试探请求")
+	}
+	if state := b.circuits["p"].state; state != CircuitHalfOpen {
+		t.Fatalf("状态为%s，应为%s", state, CircuitHalfOpen)
+	}
+	if b.Allow("p") {
+		t.Fatal("试探进行中不应放行其他请求")
+	}
+
+	b.Record("p", time.Millisecond, nil)
+	if state := b.circuits["p"].state; state != CircuitClosed {
+		t.Fatalf("试探成功后状态为%s，应为%s", state, CircuitClosed)
+	}
+	if !b.Allow("p") || !b.Allow("p") {
+		t.Fatal("恢复后应放行所有请求")
+	}
+}
+
+func TestCircuitBreakerProbeTimeout(t *testing.T) {
+	b := newTestCircuitBreaker(1)
+	b.Record("p", time.Millisecond, errSearchFailed)
+	expireOpen(b, "p")
+
+	if !b.Allow("p") {
+		t.Fatal("熔断时间过后应放行试探请求")
+	}
+	// 试探请求没有上报结果（如命中插件缓存），超过probeTimeout后允许再次试探
+	b.circuits["p"].probeStartedAt = time.Now().Add(-b.probeTimeout)
+	if !b.Allow("p") {
+		t.Fatal("试探超时后应允许再次试探")
+	}
+	if b.Allow("p") {
+		t.Fatal("新的试探进行中不应放行其他请求")
+	}
+}
+
+func TestCircuitBreakerFailedProbeReopens(t *testing.T) {
+	b := newTestCircuitBreaker(3)
+	for i := 0; i < 3; i++ {
+		b.Record("p", time.Millisecond, errSearchFailed)
+	}
+	expireOpen(b, "p")
+
+	if !b.Allow("p") {
+		t.Fatal("熔断时间过后应放行试探请求")
+	}
+	// 试探失败一次即重新熔断，不需要再次达到阈值
+	b.Record("p", time.Millisecond, errSearchFailed)
+	if state := b.circuits["p"].state; state != CircuitOpen {
+		t.Fatalf("试探失败后状态为%s，应为%s", state, CircuitOpen)
+	}
+	if b.Allow("p") {
+		t.Fatal("重新熔断后不应放行")
+	}
+
+	health := b.Health()
+	if len(health) != 1 || health[0].RetryAt == nil || health[0].ConsecutiveFailures != 4 {
+		t.Fatalf("健康状态不正确: %+v", health)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newTestCircuitBreaker(0)
+	for i := 0; i < 10; i++ {
+		b.Record("p", time.Millisecond, errSearchFailed)
+	}
+	if !b.Allow("p") {
+		t.Fatal("阈值为0时不应熔断")
+	}
+}
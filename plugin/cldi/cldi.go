@@ -60,6 +60,11 @@ func (p *CldiPlugin) SearchWithResult(keyword string, ext map[string]interface{}
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *CldiPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // searchImpl 实际的搜索实现
 func (p *CldiPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 1. 首先搜索第一页
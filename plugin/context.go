@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"pansou/model"
+)
+
+// ContextSearchPlugin 支持取消的插件
+// ctx取消（客户端断开、搜索超时）时插件应尽快返回，并中止正在进行的HTTP请求
+type ContextSearchPlugin interface {
+	AsyncSearchPlugin
+
+	// SearchWithResultContext 执行搜索并返回包含IsFinal标记的结果
+	SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error)
+}
+
+// SearchWithContext 以ctx执行插件搜索
+// 插件实现了ContextSearchPlugin时ctx取消会中止插件的HTTP请求；
+// 其他插件通过适配器调用，ctx取消时立即返回ctx.Err()，插件在后台执行完毕后结果被丢弃
+func SearchWithContext(ctx context.Context, p AsyncSearchPlugin, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PluginSearchResult{}, err
+	}
+	if cp, ok := p.(ContextSearchPlugin); ok {
+		return cp.SearchWithResultContext(ctx, keyword, ext)
+	}
+
+	type outcome struct {
+		result model.PluginSearchResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		if withResult, ok := p.(interface {
+			SearchWithResult(string, map[string]interface{}) (model.PluginSearchResult, error)
+		}); ok {
+			result, err := withResult.SearchWithResult(keyword, ext)
+			done <- outcome{result, err}
+			return
+		}
+		results, err := p.Search(keyword, ext)
+		done <- outcome{model.PluginSearchResult{Results: results, IsFinal: true, Source: p.Name()}, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return model.PluginSearchResult{}, ctx.Err()
+	}
+}
+
+// WithContext 返回绑定ctx的客户端，ctx取消时中止通过它发出的所有请求（包括读取响应体）
+// 与原客户端共用连接池，请求自身的超时和上下文照常生效
+func WithContext(ctx context.Context, client *http.Client) *http.Client {
+	if ctx.Done() == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	bound := *client
+	bound.Transport = &contextTransport{ctx: ctx, base: base}
+	return &bound
+}
+
+// boundContext 返回WithContext绑定到客户端的ctx，未绑定时返回nil
+func boundContext(client *http.Client) context.Context {
+	if client == nil {
+		return nil
+	}
+	if t, ok := client.Transport.(*contextTransport); ok {
+		return t.ctx
+	}
+	return nil
+}
+
+// isCanceledSearch 搜索是否因调用方取消（客户端断开、请求超时）而失败
+// 这类失败与插件本身的健康状况无关，不计入熔断统计
+func isCanceledSearch(client *http.Client, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return true
+	}
+	ctx := boundContext(client)
+	return ctx != nil && ctx.Err() != nil
+}
+
+// contextTransport 把外部ctx的取消传递给每个请求
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := context.WithCancel(req.Context())
+	stop := context.AfterFunc(t.ctx, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(reqCtx))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose 响应体关闭时释放请求的上下文
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close 关闭响应体
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -98,6 +98,11 @@ func (p *CygPlugin) SearchWithResult(keyword string, ext map[string]interface{})
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *CygPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // searchImpl 搜索实现逻辑
 func (p *CygPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 解析扩展参数
@@ -0,0 +1,579 @@
+package declarative
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+	jsonutil "pansou/util/json"
+)
+
+const (
+	// 详情页默认并发数，可被插件配置max_concurrency覆盖
+	defaultDetailConcurrency = 10
+	// 响应体最大读取大小
+	maxBodySize = 10 << 20
+	// 未设置User-Agent时使用的请求头
+	defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// 未设置datetime.format时依次尝试的时间格式
+var defaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// Plugin 由声明式定义驱动的异步搜索插件
+type Plugin struct {
+	*plugin.BaseAsyncPlugin
+	def     *Definition
+	mirrors *plugin.MirrorSelector
+}
+
+// NewPlugin 根据定义创建插件，def需已通过Validate
+func NewPlugin(def *Definition) *Plugin {
+	p := &Plugin{
+		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter(def.Name, def.Priority, def.SkipServiceFilter),
+		def:             def,
+	}
+	p.mirrors = p.NewMirrorSelector(def.BaseURL)
+	return p
+}
+
+// Definition 返回插件的定义
+func (p *Plugin) Definition() *Definition {
+	return p.def
+}
+
+// Search 执行搜索并返回结果（兼容性方法）
+func (p *Plugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 执行搜索并返回包含IsFinal标记的结果
+func (p *Plugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *Plugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchImpl 按定义请求搜索页，解析结果列表，再按需请求详情页
+func (p *Plugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	baseURL := p.mirrors.Current()
+	pages := p.MaxPages(p.def.Pagination.Pages)
+
+	var entries []entry
+	seen := make(map[string]bool)
+	for i := 0; i < pages; i++ {
+		page := p.def.Pagination.Start + i
+		pageEntries, err := p.searchPage(client, baseURL, keyword, page)
+		if err != nil {
+			if i > 0 {
+				break
+			}
+			// 第一页失败时切换到备用地址，下次搜索使用
+			if len(p.Mirrors(p.def.BaseURL)) > 1 {
+				p.mirrors.Failover()
+			}
+			return nil, err
+		}
+		added := 0
+		for _, e := range pageEntries {
+			if seen[e.result.UniqueID] {
+				continue
+			}
+			seen[e.result.UniqueID] = true
+			entries = append(entries, e)
+			added++
+		}
+		// 没有新结果说明已到最后一页
+		if added == 0 {
+			break
+		}
+	}
+
+	if p.def.Detail != nil {
+		p.fetchDetails(client, entries)
+	}
+
+	// 没有链接的结果没有意义
+	results := make([]model.SearchResult, 0, len(entries))
+	for _, e := range entries {
+		if len(e.result.Links) > 0 {
+			results = append(results, e.result)
+		}
+	}
+	return plugin.FilterResultsByKeyword(results, keyword), nil
+}
+
+// entry 解析中的搜索结果及其详情页地址
+type entry struct {
+	result    model.SearchResult
+	detailURL string
+}
+
+// searchPage 请求并解析一页搜索结果
+func (p *Plugin) searchPage(client *http.Client, baseURL, keyword string, page int) ([]entry, error) {
+	replacer := strings.NewReplacer(
+		"{keyword}", url.QueryEscape(keyword),
+		"{keyword_raw}", keyword,
+		"{page}", strconv.Itoa(page),
+		"{base_url}", baseURL,
+	)
+	searchURL := replacer.Replace(p.def.Search.URL)
+	var body io.Reader
+	if p.def.Search.Body != "" {
+		body = strings.NewReader(replacer.Replace(p.def.Search.Body))
+	}
+
+	req, err := http.NewRequest(p.def.Search.Method, searchURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 创建请求失败: %w", p.Name(), err)
+	}
+	p.setHeaders(req, baseURL)
+
+	data, finalURL, err := p.fetch(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 搜索请求失败: %w", p.Name(), err)
+	}
+
+	items, err := parseItems(p.def.Type, data, p.def.List.Selector, true)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 解析搜索结果失败: %w", p.Name(), err)
+	}
+
+	entries := make([]entry, 0, len(items))
+	for _, item := range items {
+		if e, ok := p.buildEntry(item, finalURL); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// setHeaders 设置定义中的请求头，未设置User-Agent时使用默认值
+func (p *Plugin) setHeaders(req *http.Request, baseURL string) {
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if req.Method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for key, value := range p.def.Search.Headers {
+		req.Header.Set(key, strings.ReplaceAll(value, "{base_url}", baseURL))
+	}
+}
+
+// fetch 发送请求并读取响应体，返回最终（重定向后）的地址用于补全相对地址
+func (p *Plugin) fetch(client *http.Client, req *http.Request) ([]byte, *url.URL, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("状态码: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, resp.Request.URL, nil
+}
+
+// buildEntry 从列表项提取搜索结果，缺少标题时返回false
+func (p *Plugin) buildEntry(item node, pageURL *url.URL) (entry, bool) {
+	fields := &p.def.List.Fields
+	title := item.value(fields.Title)
+	if title == "" {
+		return entry{}, false
+	}
+
+	result := model.SearchResult{
+		Title:    title,
+		Content:  item.value(fields.Content),
+		Datetime: parseDatetime(item.value(fields.Datetime), fields.Datetime.Format),
+		Tags:     item.values(fields.Tags),
+		Images:   resolveURLs(pageURL, item.values(fields.Images)),
+	}
+
+	detailURL := resolveURL(pageURL, item.value(fields.DetailURL))
+	id := item.value(fields.ID)
+	if id == "" {
+		id = detailURL
+	}
+	if id == "" {
+		id = title
+	}
+	result.UniqueID = fmt.Sprintf("%s-%s", p.Name(), id)
+	result.MessageID = result.UniqueID
+	result.Links = extractLinks(item, fields, pageURL)
+	return entry{result: result, detailURL: detailURL}, true
+}
+
+// fetchDetails 并发请求详情页，用详情页的字段补全结果
+func (p *Plugin) fetchDetails(client *http.Client, entries []entry) {
+	semaphore := make(chan struct{}, p.MaxConcurrency(defaultDetailConcurrency))
+	var wg sync.WaitGroup
+
+	for i := range entries {
+		if entries[i].detailURL == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := p.fillDetail(client, &e.result, e.detailURL); err != nil {
+				fmt.Printf("[%s] 获取详情页失败 %s: %v\n", p.Name(), e.detailURL, err)
+			}
+		}(&entries[i])
+	}
+	wg.Wait()
+}
+
+// fillDetail 请求详情页并覆盖结果中的字段
+func (p *Plugin) fillDetail(client *http.Client, result *model.SearchResult, detailURL string) error {
+	req, err := http.NewRequest("GET", detailURL, nil)
+	if err != nil {
+		return err
+	}
+	p.setHeaders(req, p.mirrors.Current())
+	req.Header.Del("Content-Type")
+
+	data, pageURL, err := p.fetch(client, req)
+	if err != nil {
+		return err
+	}
+	items, err := parseItems(p.def.Detail.Type, data, "", false)
+	if err != nil || len(items) == 0 {
+		return fmt.Errorf("解析详情页失败: %v", err)
+	}
+	doc := items[0]
+	fields := &p.def.Detail.Fields
+
+	if title := doc.value(fields.Title); title != "" {
+		result.Title = title
+	}
+	if !fields.Content.IsZero() {
+		if content := doc.value(fields.Content); content != "" {
+			result.Content = content
+		}
+	}
+	if !fields.Datetime.IsZero() {
+		if t := parseDatetime(doc.value(fields.Datetime), fields.Datetime.Format); !t.IsZero() {
+			result.Datetime = t
+		}
+	}
+	if !fields.Tags.IsZero() {
+		result.Tags = doc.values(fields.Tags)
+	}
+	if !fields.Images.IsZero() {
+		result.Images = resolveURLs(pageURL, doc.values(fields.Images))
+	}
+	if links := extractLinks(doc, fields, pageURL); len(links) > 0 {
+		result.Links = mergeLinks(result.Links, links)
+	}
+	return nil
+}
+
+// extractLinks 提取链接字段；未设置links时从文本中识别网盘链接
+func extractLinks(item node, fields *Fields, pageURL *url.URL) []model.Link {
+	text := item.text()
+	password := ""
+	if !fields.Password.IsZero() {
+		password = item.value(fields.Password)
+	}
+
+	var candidates []string
+	if fields.Links.IsZero() {
+		candidates = util.ExtractNetDiskLinks(text)
+	} else {
+		candidates = resolveURLs(pageURL, item.values(fields.Links))
+	}
+
+	var links []model.Link
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		linkType := util.GetLinkType(candidate)
+		if candidate == "" || linkType == "others" || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		linkPassword := password
+		if linkPassword == "" {
+			linkPassword = util.ExtractPassword(text, candidate)
+		}
+		links = append(links, model.Link{Type: linkType, URL: candidate, Password: linkPassword})
+	}
+	return links
+}
+
+// mergeLinks 合并链接，按URL去重
+func mergeLinks(links, more []model.Link) []model.Link {
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		seen[link.URL] = true
+	}
+	for _, link := range more {
+		if !seen[link.URL] {
+			seen[link.URL] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// parseDatetime 按format解析时间，format为unix或unix_ms时按时间戳解析，为空时尝试常见格式
+func parseDatetime(value, format string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	switch format {
+	case "unix", "unix_ms":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		if format == "unix_ms" {
+			return time.UnixMilli(n)
+		}
+		return time.Unix(n, 0)
+	case "":
+		for _, layout := range defaultTimeLayouts {
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return t
+			}
+		}
+		return time.Time{}
+	default:
+		t, _ := time.ParseInLocation(format, value, time.Local)
+		return t
+	}
+}
+
+// resolveURL 按页面地址补全相对地址
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme == "magnet" || u.Scheme == "ed2k" {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resolveURLs 补全多个相对地址
+func resolveURLs(base *url.URL, refs []string) []string {
+	for i, ref := range refs {
+		refs[i] = resolveURL(base, ref)
+	}
+	return refs
+}
+
+// node 列表项或详情页，HTML为goquery选择集，JSON为解析后的值
+type node struct {
+	sel  *goquery.Selection
+	data interface{}
+}
+
+// parseItems 解析响应，list为true时返回selector匹配的所有结果项，否则返回整个文档
+func parseItems(typ string, data []byte, selector string, list bool) ([]node, error) {
+	if typ == TypeJSON {
+		var root interface{}
+		if err := jsonutil.Unmarshal(data, &root); err != nil {
+			return nil, err
+		}
+		value := lookupPath(root, selector)
+		if array, ok := value.([]interface{}); ok && list {
+			items := make([]node, 0, len(array))
+			for _, item := range array {
+				items = append(items, node{data: item})
+			}
+			return items, nil
+		}
+		if value == nil {
+			return nil, nil
+		}
+		return []node{{data: value}}, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if !list {
+		return []node{{sel: doc.Selection}}, nil
+	}
+	var items []node
+	doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+		items = append(items, node{sel: s})
+	})
+	return items, nil
+}
+
+// value 提取字段的第一个值
+func (n node) value(spec FieldSpec) string {
+	if spec.IsZero() {
+		return ""
+	}
+	values := n.raw(spec, true)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// values 提取字段的所有值
+func (n node) values(spec FieldSpec) []string {
+	if spec.IsZero() {
+		return nil
+	}
+	return n.raw(spec, false)
+}
+
+// raw 按选择器或路径取值，再应用正则
+func (n node) raw(spec FieldSpec, first bool) []string {
+	var values []string
+	if n.sel != nil {
+		sel := n.sel
+		if spec.Selector != "" {
+			sel = sel.Find(spec.Selector)
+		}
+		if first {
+			sel = sel.First()
+		}
+		sel.Each(func(_ int, s *goquery.Selection) {
+			if spec.Attr != "" {
+				if attr, ok := s.Attr(spec.Attr); ok {
+					values = append(values, attr)
+				}
+				return
+			}
+			values = append(values, s.Text())
+		})
+	} else {
+		path := spec.Path
+		if path == "" {
+			path = spec.Selector
+		}
+		values = jsonStrings(lookupPath(n.data, path))
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+		if spec.regex != nil {
+			matches := spec.regex.FindStringSubmatch(value)
+			if matches == nil {
+				continue
+			}
+			value = matches[0]
+			if len(matches) > 1 {
+				value = matches[1]
+			}
+		}
+		if value != "" {
+			result = append(result, value)
+			if first {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// text 结果项的全部文本，用于识别网盘链接和提取码
+// HTML同时包含链接地址，因为很多站点的链接文字不是地址本身
+func (n node) text() string {
+	if n.sel == nil {
+		return strings.Join(jsonStrings(n.data), "\n")
+	}
+	var b strings.Builder
+	b.WriteString(n.sel.Text())
+	n.sel.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		b.WriteString("\n")
+		b.WriteString(href)
+	})
+	return b.String()
+}
+
+// lookupPath 按点分隔的路径取JSON值，数组用数字下标，路径为空时返回自身
+func lookupPath(value interface{}, path string) interface{} {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			value = v[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// jsonStrings 将JSON值转换为字符串，数组展开为多个值，对象的各个值依次展开
+func jsonStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, jsonStrings(item)...)
+		}
+		return values
+	case map[string]interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, jsonStrings(item)...)
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,40 @@
+package declarative
+
+import (
+	"path/filepath"
+	"testing"
+
+	"pansou/plugin/plugintest"
+)
+
+func TestSearchGolden(t *testing.T) {
+	for _, file := range []string{"html_site.yaml", "json_api.json"} {
+		def, err := LoadDefinition(filepath.Join("testdata", "definitions", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plugintest.Run(t, NewPlugin(def),
+			plugintest.Case{Name: def.Name, Keyword: "测试电影"},
+		)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{"横线插件名", "name: a-b\nsearch: {url: 'http://x/{keyword}'}\nlist: {selector: a, fields: {title: a}}"},
+		{"缺少关键词占位符", "name: a\nsearch: {url: 'http://x/'}\nlist: {selector: a, fields: {title: a}}"},
+		{"缺少列表选择器", "name: a\nsearch: {url: 'http://x/{keyword}'}\nlist: {fields: {title: a}}"},
+		{"缺少标题", "name: a\nsearch: {url: 'http://x/{keyword}'}\nlist: {selector: a}"},
+		{"详情页缺少地址", "name: a\nsearch: {url: 'http://x/{keyword}'}\nlist: {selector: a, fields: {title: a}}\ndetail: {fields: {links: a}}"},
+		{"分页缺少页码占位符", "name: a\nsearch: {url: 'http://x/{keyword}'}\nlist: {selector: a, fields: {title: a}}\npagination: {pages: 2}"},
+		{"无效正则", "name: a\nsearch: {url: 'http://x/{keyword}'}\nlist: {selector: a, fields: {title: {selector: a, regex: '('}}}"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseDefinition([]byte(tt.yaml)); err == nil {
+			t.Errorf("%s: 期望校验失败", tt.name)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package declarative
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"pansou/plugin"
+)
+
+// 站点类型
+const (
+	TypeHTML = "html" // 解析HTML页面，字段使用CSS选择器
+	TypeJSON = "json" // 解析JSON接口，字段使用点分隔的路径（如data.list）
+)
+
+// 插件名只能包含小写字母、数字和下划线
+// 结果的UniqueID为"插件名-ID"，来源按第一个横线拆分，插件名中不能有横线
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Definition 声明式插件定义，从插件目录中的YAML或JSON文件读取
+type Definition struct {
+	Name              string       `yaml:"name"`                // 插件名，需与已有插件不同
+	Priority          int          `yaml:"priority"`            // 插件优先级，默认3
+	SkipServiceFilter bool         `yaml:"skip_service_filter"` // 是否跳过Service层的关键词过滤
+	Type              string       `yaml:"type"`                // html（默认）或json
+	BaseURL           string       `yaml:"base_url"`            // 站点地址，可被插件配置mirrors覆盖，在URL模板中用{base_url}引用
+	Search            RequestSpec  `yaml:"search"`              // 搜索请求
+	List              ListSpec     `yaml:"list"`                // 搜索结果列表
+	Detail            *DetailSpec  `yaml:"detail"`              // 详情页，为空时不请求详情页
+	Pagination        PaginateSpec `yaml:"pagination"`          // 分页
+}
+
+// RequestSpec 请求配置
+// URL和Body支持占位符：{keyword}（URL编码后的关键词）、{keyword_raw}、{page}、{base_url}
+type RequestSpec struct {
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"` // GET（默认）或POST
+	Body    string            `yaml:"body"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// ListSpec 搜索结果列表
+type ListSpec struct {
+	Selector string `yaml:"selector"` // html为每个结果项的CSS选择器，json为结果数组的路径，为空表示根节点
+	Fields   Fields `yaml:"fields"`
+}
+
+// DetailSpec 详情页，URL来自列表的detail_url字段
+// 详情页提取的字段覆盖列表中的同名字段，links为空时从页面文本中识别网盘链接
+type DetailSpec struct {
+	Type   string `yaml:"type"` // html（默认）或json
+	Fields Fields `yaml:"fields"`
+}
+
+// PaginateSpec 分页配置
+type PaginateSpec struct {
+	Start int `yaml:"start"` // 第一页的页码，默认1
+	Pages int `yaml:"pages"` // 最多请求的页数，默认1，可被插件配置max_pages覆盖
+}
+
+// Fields 结果字段映射
+type Fields struct {
+	ID        FieldSpec `yaml:"id"`         // 结果ID，为空时使用detail_url或标题
+	Title     FieldSpec `yaml:"title"`      // 标题
+	Content   FieldSpec `yaml:"content"`    // 描述
+	Datetime  FieldSpec `yaml:"datetime"`   // 发布时间，format为Go时间格式或unix、unix_ms
+	DetailURL FieldSpec `yaml:"detail_url"` // 详情页地址，相对地址按请求地址补全
+	Links     FieldSpec `yaml:"links"`      // 链接，取所有匹配项，为空时从文本中识别网盘链接
+	Password  FieldSpec `yaml:"password"`   // 提取码，为空时从文本和链接中识别
+	Tags      FieldSpec `yaml:"tags"`       // 标签，取所有匹配项
+	Images    FieldSpec `yaml:"images"`     // 图片，取所有匹配项
+}
+
+// FieldSpec 字段提取规则，也可以直接写为字符串，等同于只设置selector
+type FieldSpec struct {
+	Selector string `yaml:"selector"` // html为相对于结果项的CSS选择器，为空表示结果项本身
+	Path     string `yaml:"path"`     // json为相对于结果项的路径，为空时使用selector
+	Attr     string `yaml:"attr"`     // html取该属性的值，为空时取文本
+	Regex    string `yaml:"regex"`    // 只保留正则的第一个捕获组（无捕获组时为整个匹配）
+	Format   string `yaml:"format"`   // 时间格式，仅用于datetime
+
+	regex *regexp.Regexp
+}
+
+// UnmarshalYAML 支持将字段直接写为选择器字符串
+func (f *FieldSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Selector = node.Value
+		return nil
+	}
+	type plain FieldSpec
+	return node.Decode((*plain)(f))
+}
+
+// IsZero 是否未设置该字段
+func (f FieldSpec) IsZero() bool {
+	return f.Selector == "" && f.Path == "" && f.Attr == "" && f.Regex == ""
+}
+
+// ParseDefinition 解析插件定义，JSON是YAML的子集，两种格式使用同一个解析器
+func ParseDefinition(data []byte) (*Definition, error) {
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, err
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// LoadDefinition 读取并解析插件定义文件
+func LoadDefinition(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取插件定义失败: %w", err)
+	}
+	def, err := ParseDefinition(data)
+	if err != nil {
+		return nil, fmt.Errorf("插件定义%s无效: %w", filepath.Base(path), err)
+	}
+	return def, nil
+}
+
+// Validate 检查定义并补全默认值，编译字段中的正则
+func (d *Definition) Validate() error {
+	d.Name = strings.TrimSpace(d.Name)
+	if !namePattern.MatchString(d.Name) {
+		return fmt.Errorf("name只能包含小写字母、数字和下划线: %q", d.Name)
+	}
+	if d.Priority == 0 {
+		d.Priority = 3
+	}
+
+	var err error
+	if d.Type, err = normalizeType(d.Type); err != nil {
+		return err
+	}
+	d.BaseURL = strings.TrimRight(strings.TrimSpace(d.BaseURL), "/")
+
+	if strings.TrimSpace(d.Search.URL) == "" {
+		return fmt.Errorf("缺少search.url")
+	}
+	if !strings.Contains(d.Search.URL+d.Search.Body, "{keyword") {
+		return fmt.Errorf("search.url或search.body中缺少{keyword}占位符")
+	}
+	if strings.Contains(d.Search.URL, "{base_url}") && d.BaseURL == "" {
+		return fmt.Errorf("search.url使用了{base_url}，但未设置base_url")
+	}
+	d.Search.Method = strings.ToUpper(strings.TrimSpace(d.Search.Method))
+	if d.Search.Method == "" {
+		d.Search.Method = "GET"
+	}
+	if d.Search.Method != "GET" && d.Search.Method != "POST" {
+		return fmt.Errorf("search.method只支持GET和POST: %s", d.Search.Method)
+	}
+
+	if d.Type == TypeHTML && d.List.Selector == "" {
+		return fmt.Errorf("html类型的插件缺少list.selector")
+	}
+	if d.List.Fields.Title.IsZero() {
+		return fmt.Errorf("缺少list.fields.title")
+	}
+	if err := d.List.Fields.compile("list.fields"); err != nil {
+		return err
+	}
+
+	if d.Detail != nil {
+		if d.List.Fields.DetailURL.IsZero() {
+			return fmt.Errorf("设置了detail，但缺少list.fields.detail_url")
+		}
+		if d.Detail.Type, err = normalizeType(d.Detail.Type); err != nil {
+			return fmt.Errorf("detail: %w", err)
+		}
+		if err := d.Detail.Fields.compile("detail.fields"); err != nil {
+			return err
+		}
+	}
+
+	if d.Pagination.Start <= 0 {
+		d.Pagination.Start = 1
+	}
+	if d.Pagination.Pages <= 0 {
+		d.Pagination.Pages = 1
+	}
+	if d.Pagination.Pages > 1 && !strings.Contains(d.Search.URL+d.Search.Body, "{page}") {
+		return fmt.Errorf("pagination.pages大于1，但search.url或search.body中缺少{page}占位符")
+	}
+	return nil
+}
+
+// normalizeType 校验站点类型，为空时为html
+func normalizeType(t string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(t)) {
+	case "", TypeHTML:
+		return TypeHTML, nil
+	case TypeJSON:
+		return TypeJSON, nil
+	default:
+		return "", fmt.Errorf("不支持的type: %s", t)
+	}
+}
+
+// compile 编译各字段的正则
+func (f *Fields) compile(prefix string) error {
+	specs := map[string]*FieldSpec{
+		"id": &f.ID, "title": &f.Title, "content": &f.Content, "datetime": &f.Datetime,
+		"detail_url": &f.DetailURL, "links": &f.Links, "password": &f.Password,
+		"tags": &f.Tags, "images": &f.Images,
+	}
+	for name, spec := range specs {
+		if spec.Regex == "" {
+			continue
+		}
+		re, err := regexp.Compile(spec.Regex)
+		if err != nil {
+			return fmt.Errorf("%s.%s.regex无效: %w", prefix, name, err)
+		}
+		spec.regex = re
+	}
+	return nil
+}
+
+// LoadDir 读取目录中的插件定义（.yaml、.yml、.json）并注册到全局插件注册表
+// 目录不存在时不做任何事；单个定义无效或与已注册的插件重名时跳过并返回错误，其余定义照常注册
+func LoadDir(dir string) ([]string, []error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("读取插件目录失败: %w", err)}
+	}
+
+	existing := make(map[string]bool)
+	for _, p := range plugin.GetRegisteredPlugins() {
+		existing[p.Name()] = true
+	}
+
+	var loaded []string
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !isDefinitionFile(entry.Name()) {
+			continue
+		}
+		def, err := LoadDefinition(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if existing[def.Name] {
+			errs = append(errs, fmt.Errorf("插件定义%s: 插件名%s已被使用", entry.Name(), def.Name))
+			continue
+		}
+		existing[def.Name] = true
+		plugin.RegisterGlobalPlugin(NewPlugin(def))
+		loaded = append(loaded, def.Name)
+	}
+	sort.Strings(loaded)
+	return loaded, errs
+}
+
+// isDefinitionFile 是否为插件定义文件
+func isDefinitionFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
@@ -136,6 +136,11 @@ func (p *DuoduoAsyncPlugin) SearchWithResult(keyword string, ext map[string]inte
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *DuoduoAsyncPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // searchImpl 实现具体的搜索逻辑
 func (p *DuoduoAsyncPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 性能统计
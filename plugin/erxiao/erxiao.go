@@ -100,6 +100,11 @@ func (p *ErxiaoAsyncPlugin) SearchWithResult(keyword string, ext map[string]inte
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *ErxiaoAsyncPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // searchImpl 搜索实现
 func (p *ErxiaoAsyncPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 性能统计
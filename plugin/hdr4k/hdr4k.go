@@ -1,6 +1,7 @@
 package hdr4k
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net"
@@ -120,6 +121,11 @@ func (p *Hdr4kAsyncPlugin) SearchWithResult(keyword string, ext map[string]inter
 	return p.AsyncSearchWithResult(keyword, p.doSearch, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *Hdr4kAsyncPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.doSearch, p.MainCacheKey, ext)
+}
+
 // doSearch 实际的搜索实现
 func (p *Hdr4kAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 处理ext参数
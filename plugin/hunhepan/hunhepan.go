@@ -1,6 +1,7 @@
 package hunhepan
 
 import (
+	"context"
 	"bytes"
 	"fmt"
 	"io"
@@ -56,6 +57,11 @@ func (p *HunhepanAsyncPlugin) SearchWithResult(keyword string, ext map[string]in
 	return p.AsyncSearchWithResult(keyword, p.doSearch, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *HunhepanAsyncPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.doSearch, p.MainCacheKey, ext)
+}
+
 // doSearch 实际的搜索实现
 func (p *HunhepanAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 创建结果通道和错误通道
@@ -31,7 +31,7 @@ const (
 	MaxConcurrency      = 10
 	
 	// 429限流重试配置
-	MaxRetryOnRateLimit = 0    // 遇到429时的最大重试次数，设为0则不重试
+	MaxRetryOnRateLimit = 0    // 遇到429时的最大重试次数，设为0则不重试，可通过插件配置max_retry_on_rate_limit修改
 	MinRetryDelay       = 4    // 最小延迟秒数
 	MaxRetryDelay       = 8    // 最大延迟秒数
 )
@@ -101,6 +101,11 @@ func (p *JavdbPlugin) SearchWithResult(keyword string, ext map[string]interface{
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *JavdbPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // searchImpl 搜索实现
 func (p *JavdbPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	if p.debugMode {
@@ -152,13 +157,13 @@ func (p *JavdbPlugin) executeSearchWithRateLimit(client *http.Client, keyword st
 	atomic.StoreInt32(&p.rateLimited, 0)
 	
 	// 构建搜索URL
-	searchURL := fmt.Sprintf("%s%s", BaseURL, fmt.Sprintf(SearchPath, url.QueryEscape(keyword)))
+	searchURL := fmt.Sprintf("%s%s", p.BaseURL(BaseURL), fmt.Sprintf(SearchPath, url.QueryEscape(keyword)))
 	
 	if p.debugMode {
 		log.Printf("[JAVDB] 搜索URL: %s", searchURL)
 		// 显示重试配置信息
-		if MaxRetryOnRateLimit > 0 {
-			log.Printf("[JAVDB] 429重试配置: 最大%d次，延迟%d-%d秒", MaxRetryOnRateLimit, MinRetryDelay, MaxRetryDelay)
+		if maxRetries := p.maxRetryOnRateLimit(); maxRetries > 0 {
+			log.Printf("[JAVDB] 429重试配置: 最大%d次，延迟%d-%d秒", maxRetries, MinRetryDelay, MaxRetryDelay)
 		} else {
 			log.Printf("[JAVDB] 429重试配置: 禁用重试")
 		}
@@ -184,7 +189,7 @@ func (p *JavdbPlugin) executeSearchWithRateLimit(client *http.Client, keyword st
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Upgrade-Insecure-Requests", "1")
 	req.Header.Set("Cache-Control", "max-age=0")
-	req.Header.Set("Referer", BaseURL+"/")
+	req.Header.Set("Referer", p.BaseURL(BaseURL)+"/")
 
 	if p.debugMode {
 		log.Printf("[JAVDB] 发送搜索请求...")
@@ -271,16 +276,22 @@ func (p *JavdbPlugin) doRequestWithRetry(req *http.Request, client *http.Client)
 	return nil, fmt.Errorf("[%s] 重试 %d 次后仍然失败: %w", p.Name(), maxRetries, lastErr)
 }
 
+// maxRetryOnRateLimit 遇到429时的最大重试次数
+func (p *JavdbPlugin) maxRetryOnRateLimit() int {
+	return p.SettingInt("max_retry_on_rate_limit", MaxRetryOnRateLimit)
+}
+
 // doRequestWithRateLimitRetry 带429重试机制的HTTP请求
 func (p *JavdbPlugin) doRequestWithRateLimitRetry(req *http.Request, client *http.Client) (*http.Response, error) {
 	var lastErr error
+	maxRetryOnRateLimit := p.maxRetryOnRateLimit()
 	
-	for attempt := 0; attempt <= MaxRetryOnRateLimit; attempt++ {
+	for attempt := 0; attempt <= maxRetryOnRateLimit; attempt++ {
 		if attempt > 0 {
 			// 随机延迟，避免同时重试造成更大压力
 			delaySeconds := rand.Intn(MaxRetryDelay-MinRetryDelay+1) + MinRetryDelay
 			if p.debugMode {
-				log.Printf("[JAVDB] 429重试 %d/%d，随机延迟 %d 秒", attempt, MaxRetryOnRateLimit, delaySeconds)
+				log.Printf("[JAVDB] 429重试 %d/%d，随机延迟 %d 秒", attempt, maxRetryOnRateLimit, delaySeconds)
 			}
 			time.Sleep(time.Duration(delaySeconds) * time.Second)
 		}
@@ -305,19 +316,19 @@ func (p *JavdbPlugin) doRequestWithRateLimitRetry(req *http.Request, client *htt
 		// 遇到429
 		atomic.AddInt32(&p.rateLimitCount, 1)
 		if p.debugMode {
-			log.Printf("[JAVDB] 遇到429限流，尝试 %d/%d", attempt+1, MaxRetryOnRateLimit+1)
+			log.Printf("[JAVDB] 遇到429限流，尝试 %d/%d", attempt+1, maxRetryOnRateLimit+1)
 		}
 		
 		// 如果不允许重试或已达到最大重试次数
-		if MaxRetryOnRateLimit == 0 || attempt >= MaxRetryOnRateLimit {
+		if maxRetryOnRateLimit == 0 || attempt >= maxRetryOnRateLimit {
 			atomic.StoreInt32(&p.rateLimited, 1)
 			resp.Body.Close()
 			return nil, fmt.Errorf("[%s] 429限流，%s", p.Name(), 
 				func() string {
-					if MaxRetryOnRateLimit == 0 {
+					if maxRetryOnRateLimit == 0 {
 						return "不重试"
 					}
-					return fmt.Sprintf("重试%d次后仍然限流", MaxRetryOnRateLimit)
+					return fmt.Sprintf("重试%d次后仍然限流", maxRetryOnRateLimit)
 				}())
 		}
 		
@@ -450,7 +461,7 @@ func (p *JavdbPlugin) parseResultItem(s *goquery.Selection, index int) *model.Se
 
 	// 处理相对路径
 	if strings.HasPrefix(detailURL, "/") {
-		detailURL = BaseURL + detailURL
+		detailURL = p.BaseURL(BaseURL) + detailURL
 	}
 
 	// 提取番号和标题
@@ -606,7 +617,7 @@ func (p *JavdbPlugin) fetchDetailMagnetLinks(client *http.Client, searchResults
 	}
 
 	// 使用通道控制并发数
-	semaphore := make(chan struct{}, MaxConcurrency)
+	semaphore := make(chan struct{}, p.MaxConcurrency(MaxConcurrency))
 	var wg sync.WaitGroup
 	resultsChan := make(chan []model.SearchResult, len(searchResults))
 	
@@ -812,7 +823,7 @@ func (p *JavdbPlugin) fetchDetailPageMagnetLinks(client *http.Client, detailURL
 	req.Header.Set("User-Agent", UserAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
-	req.Header.Set("Referer", BaseURL+"/")
+	req.Header.Set("Referer", p.BaseURL(BaseURL)+"/")
 
 	if p.debugMode {
 		log.Printf("[JAVDB] 发送详情页请求...")
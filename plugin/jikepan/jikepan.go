@@ -1,6 +1,7 @@
 package jikepan
 
 import (
+	"context"
 	"bytes"
 	"fmt"
 	"io"
@@ -49,6 +50,11 @@ func (p *JikepanAsyncV2Plugin) SearchWithResult(keyword string, ext map[string]i
 	return p.AsyncSearchWithResult(keyword, p.doSearch, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *JikepanAsyncV2Plugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.doSearch, p.MainCacheKey, ext)
+}
+
 // doSearch 实际的搜索实现
 func (p *JikepanAsyncV2Plugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 构建请求
@@ -0,0 +1,13 @@
+package jikepan
+
+import (
+	"testing"
+
+	"pansou/plugin/plugintest"
+)
+
+func TestSearchGolden(t *testing.T) {
+	plugintest.Run(t, NewJikepanAsyncV2Plugin(),
+		plugintest.Case{Name: "search", Keyword: "流浪地球"},
+	)
+}
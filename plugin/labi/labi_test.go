@@ -0,0 +1,13 @@
+package labi
+
+import (
+	"testing"
+
+	"pansou/plugin/plugintest"
+)
+
+func TestSearchGolden(t *testing.T) {
+	plugintest.Run(t, NewLabiPlugin(),
+		plugintest.Case{Name: "search", Keyword: "凡人修仙传"},
+	)
+}
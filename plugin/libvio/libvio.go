@@ -1,6 +1,7 @@
 package libvio
 
 import (
+	"context"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -78,6 +79,11 @@ func (p *LibvioPlugin) SearchWithResult(keyword string, ext map[string]interface
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *LibvioPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // setRequestHeaders 设置请求头
 func (p *LibvioPlugin) setRequestHeaders(req *http.Request, referer string) {
 	req.Header.Set("User-Agent", UserAgent)
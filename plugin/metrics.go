@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"pansou/model"
+	"pansou/util/metrics"
+)
+
+// 插件指标
+var (
+	pluginSearchDuration = metrics.NewHistogramVec("pansou_plugin_search_duration_seconds",
+		"插件实际搜索（请求上游）的耗时", metrics.DefBuckets, "plugin")
+	pluginSearches = metrics.NewCounterVec("pansou_plugin_searches_total",
+		"插件实际搜索次数，status为success、error或canceled（调用方取消）", "plugin", "status")
+	pluginResults = metrics.NewCounterVec("pansou_plugin_results_total",
+		"插件实际搜索返回的结果数", "plugin")
+	pluginTimeouts = metrics.NewCounterVec("pansou_plugin_timeouts_total",
+		"插件未在快速响应超时内返回、转入后台继续搜索的次数", "plugin")
+	pluginSharedSearches = metrics.NewCounterVec("pansou_plugin_shared_searches_total",
+		"与其他相同的并发请求共享同一次插件搜索的请求数（包括发起搜索的请求）", "plugin")
+)
+
+func init() {
+	metrics.NewCounterVecFunc("pansou_plugin_cache_lookups_total", "插件内存缓存的查询次数", []string{"result"},
+		func(emit func(float64, ...string)) {
+			emit(float64(atomic.LoadInt64(&cacheHits)), "hit")
+			emit(float64(atomic.LoadInt64(&cacheMisses)), "miss")
+		})
+	metrics.NewCounterFunc("pansou_plugin_async_completions_total", "超时后在后台完成的插件搜索次数", func() float64 {
+		return float64(atomic.LoadInt64(&asyncCompletions))
+	})
+	metrics.NewGaugeFunc("pansou_plugin_background_workers_busy", "正在使用的后台工作槽数量", func() float64 {
+		return float64(len(backgroundWorkerPool))
+	})
+	metrics.NewGaugeFunc("pansou_plugin_background_workers_max", "后台工作槽总数", func() float64 {
+		return float64(cap(backgroundWorkerPool))
+	})
+	metrics.NewGaugeFunc("pansou_plugin_background_tasks", "正在执行的后台任务数量", func() float64 {
+		return float64(atomic.LoadInt32(&backgroundTasksCount))
+	})
+}
+
+// instrumentSearch 包装搜索函数，记录耗时、结果数和错误，并上报给searchObserver
+// 调用方取消的搜索单独计为canceled，不上报给searchObserver
+func (p *BaseAsyncPlugin) instrumentSearch(searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error)) func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error) {
+	return func(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+		started := time.Now()
+		results, err := searchFunc(client, keyword, ext)
+		elapsed := time.Since(started)
+		if isCanceledSearch(client, err) {
+			pluginSearches.Inc(p.name, "canceled")
+			return results, err
+		}
+
+		pluginSearchDuration.Observe(elapsed.Seconds(), p.name)
+		if p.searchObserver != nil {
+			p.searchObserver(elapsed, err)
+		}
+		if err != nil {
+			pluginSearches.Inc(p.name, "error")
+		} else {
+			pluginSearches.Inc(p.name, "success")
+			pluginResults.Add(float64(len(results)), p.name)
+		}
+		return results, err
+	}
+}
+
+// recordPluginTimeout 记录插件响应超时
+func recordPluginTimeout(name string) {
+	pluginTimeouts.Inc(name)
+}
@@ -1,6 +1,7 @@
 package pan666
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math/rand"
@@ -69,6 +70,11 @@ func (p *Pan666AsyncPlugin) SearchWithResult(keyword string, ext map[string]inte
 	return p.AsyncSearchWithResult(keyword, p.doSearch, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *Pan666AsyncPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.doSearch, p.MainCacheKey, ext)
+}
+
 // doSearch 实际的搜索实现
 func (p *Pan666AsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 初始化随机数种子
@@ -1,6 +1,7 @@
 package panwiki
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -31,7 +32,7 @@ type PanwikiPlugin struct {
 	detailCache sync.Map // 详情页缓存
 	cacheTTL    time.Duration
 	debugMode   bool     // debug模式开关
-	currentBaseURL string // 当前使用的域名
+	mirrors     *plugin.MirrorSelector // 当前使用的域名
 }
 
 // NewPanwikiPlugin 创建Panwiki插件实例
@@ -44,8 +45,9 @@ func NewPanwikiPlugin() *PanwikiPlugin {
 		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter("panwiki", 3, true),
 		cacheTTL:       30 * time.Minute,
 		debugMode:      debugMode,
-		currentBaseURL: PrimaryBaseURL, // 默认使用主域名
 	}
+	// 默认使用主域名，可通过插件配置mirrors修改
+	p.mirrors = p.NewMirrorSelector(PrimaryBaseURL, BackupBaseURL)
 	
 	if p.debugMode {
 		log.Printf("[Panwiki] Debug模式已启用")
@@ -58,19 +60,19 @@ func NewPanwikiPlugin() *PanwikiPlugin {
 func (p *PanwikiPlugin) getSearchURL(keyword string, page int) string {
 	var searchURL string
 	if page <= 1 {
-		searchURL = fmt.Sprintf(p.currentBaseURL+SearchPath, url.QueryEscape(keyword))
+		searchURL = fmt.Sprintf(p.mirrors.Current()+SearchPath, url.QueryEscape(keyword))
 	} else {
-		searchURL = fmt.Sprintf(p.currentBaseURL+SearchPath+"&page=%d", url.QueryEscape(keyword), page)
+		searchURL = fmt.Sprintf(p.mirrors.Current()+SearchPath+"&page=%d", url.QueryEscape(keyword), page)
 	}
 	return searchURL
 }
 
 // switchToBackupDomain 切换到备用域名
 func (p *PanwikiPlugin) switchToBackupDomain() {
-	if p.currentBaseURL == PrimaryBaseURL {
-		p.currentBaseURL = BackupBaseURL
+	if p.mirrors.IsPrimary() {
+		backupURL := p.mirrors.Failover()
 		if p.debugMode {
-			log.Printf("[Panwiki] 切换到备用域名: %s", p.currentBaseURL)
+			log.Printf("[Panwiki] 切换到备用域名: %s", backupURL)
 		}
 	}
 }
@@ -87,13 +89,14 @@ func (p *PanwikiPlugin) searchImpl(client *http.Client, keyword string, ext map[
 	allResults = append(allResults, firstPageResults...)
 
 	// 多页并发搜索
-	if MaxPages > 1 {
+	maxPages := p.MaxPages(MaxPages)
+	if maxPages > 1 {
 		var wg sync.WaitGroup
 		var mu sync.Mutex
-		semaphore := make(chan struct{}, MaxConcurrency)
+		semaphore := make(chan struct{}, p.MaxConcurrency(MaxConcurrency))
 		pageResults := make(map[int][]model.SearchResult)
 
-		for page := 2; page <= MaxPages; page++ {
+		for page := 2; page <= maxPages; page++ {
 			wg.Add(1)
 			go func(pageNum int) {
 				defer wg.Done()
@@ -114,7 +117,7 @@ func (p *PanwikiPlugin) searchImpl(client *http.Client, keyword string, ext map[
 		wg.Wait()
 
 		// 按页码顺序添加结果
-		for page := 2; page <= MaxPages; page++ {
+		for page := 2; page <= maxPages; page++ {
 			if results, exists := pageResults[page]; exists {
 				allResults = append(allResults, results...)
 			}
@@ -174,7 +177,7 @@ func (p *PanwikiPlugin) searchPage(client *http.Client, keyword string, page int
 	resp, err := client.Do(req)
 	if err != nil {
 		// 如果主域名失败，尝试切换到备用域名
-		if p.currentBaseURL == PrimaryBaseURL {
+		if p.mirrors.IsPrimary() {
 			if p.debugMode {
 				log.Printf("[Panwiki] 主域名请求失败，尝试备用域名: %v", err)
 			}
@@ -212,7 +215,7 @@ func (p *PanwikiPlugin) searchPage(client *http.Client, keyword string, page int
 	if strings.HasPrefix(location, "http") {
 		searchURL = location
 	} else {
-		searchURL = p.currentBaseURL + "/" + strings.TrimPrefix(location, "/")
+		searchURL = p.mirrors.Current() + "/" + strings.TrimPrefix(location, "/")
 	}
 	
 	// 如果不是第一页，修改URL中的page参数
@@ -223,7 +226,7 @@ func (p *PanwikiPlugin) searchPage(client *http.Client, keyword string, page int
 			matches := re.FindStringSubmatch(searchURL)
 			if len(matches) > 1 {
 				searchid := matches[1]
-				searchURL = fmt.Sprintf("%s/search.php?mod=forum&searchid=%s&orderby=lastpost&ascdesc=desc&searchsubmit=yes&page=%d", p.currentBaseURL, searchid, page)
+				searchURL = fmt.Sprintf("%s/search.php?mod=forum&searchid=%s&orderby=lastpost&ascdesc=desc&searchsubmit=yes&page=%d", p.mirrors.Current(), searchid, page)
 			}
 		}
 	}
@@ -258,7 +261,7 @@ func (p *PanwikiPlugin) searchPage(client *http.Client, keyword string, page int
 // setRequestHeaders 设置请求头
 func (p *PanwikiPlugin) setRequestHeaders(req *http.Request) {
 	req.Header.Set("User-Agent", UserAgent)
-	req.Header.Set("Referer", p.currentBaseURL+"/")
+	req.Header.Set("Referer", p.mirrors.Current()+"/")
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 	req.Header.Set("Cache-Control", "no-cache")
@@ -302,7 +305,7 @@ func (p *PanwikiPlugin) parseSearchResult(s *goquery.Selection) model.SearchResu
 		if strings.HasPrefix(detailPath, "http") {
 			detailURL = detailPath
 		} else {
-			detailURL = p.currentBaseURL + "/" + strings.TrimPrefix(detailPath, "/")
+			detailURL = p.mirrors.Current() + "/" + strings.TrimPrefix(detailPath, "/")
 		}
 	}
 	
@@ -399,7 +402,7 @@ func (p *PanwikiPlugin) enrichWithDetailLinks(client *http.Client, results []mod
 	}
 	
 	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, MaxConcurrency)
+	semaphore := make(chan struct{}, p.MaxConcurrency(MaxConcurrency))
 	
 	for i := range results {
 		wg.Add(1)
@@ -1453,6 +1456,11 @@ func (p *PanwikiPlugin) SearchWithResult(keyword string, ext map[string]interfac
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *PanwikiPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // extractPasswordFromContent 从内容文本中提取指定链接的密码
 func (p *PanwikiPlugin) extractPasswordFromContent(content, linkURL string) string {
 	// 查找链接在内容中的位置
@@ -1,6 +1,7 @@
 package panyq
 
 import (
+	"context"
 	"crypto/tls"
 	"pansou/util/json"
 	"fmt"
@@ -195,6 +196,11 @@ func (p *PanyqPlugin) SearchWithResult(keyword string, ext map[string]interface{
 	return p.AsyncSearchWithResult(keyword, p.doSearch, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *PanyqPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.doSearch, p.MainCacheKey, ext)
+}
+
 // doSearch 实际的搜索实现
 func (p *PanyqPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	if DebugLog {
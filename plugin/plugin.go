@@ -2,10 +2,12 @@ package plugin
 
 import (
 	"net/http"
-	"strings"
 	"sync"
+	"time"
 
+	"pansou/config"
 	"pansou/model"
+	"pansou/util/textmatch"
 )
 
 // 全局异步插件注册表
@@ -79,20 +81,101 @@ func GetPluginByName(name string) (AsyncSearchPlugin, bool) {
 }
 
 // PluginManager 异步插件管理器
+// 插件列表可在运行时通过SetEnabledPlugins整体替换
 type PluginManager struct {
+	mu      sync.RWMutex
 	plugins []AsyncSearchPlugin
+	breaker *CircuitBreaker
 }
 
 // NewPluginManager 创建新的异步插件管理器
 func NewPluginManager() *PluginManager {
 	return &PluginManager{
 		plugins: make([]AsyncSearchPlugin, 0),
+		breaker: NewCircuitBreaker(),
 	}
 }
 
 // RegisterPlugin 注册异步插件
 func (pm *PluginManager) RegisterPlugin(plugin AsyncSearchPlugin) {
-	pm.plugins = append(pm.plugins, plugin)
+	pm.attach(plugin)
+	
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	// 复制后追加，避免修改GetPlugins已返回的切片
+	plugins := make([]AsyncSearchPlugin, len(pm.plugins), len(pm.plugins)+1)
+	copy(plugins, pm.plugins)
+	pm.plugins = append(plugins, plugin)
+}
+
+// SetEnabledPlugins 按名称从全局注册表中选出插件，整体替换当前插件列表
+// 存在未注册的名称时不做任何修改，并返回这些名称
+func (pm *PluginManager) SetEnabledPlugins(names []string) []string {
+	plugins := make([]AsyncSearchPlugin, 0, len(names))
+	unknown := []string{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		
+		plugin, exists := GetPluginByName(name)
+		if !exists {
+			unknown = append(unknown, name)
+			continue
+		}
+		plugins = append(plugins, plugin)
+	}
+	if len(unknown) > 0 {
+		return unknown
+	}
+	
+	for _, plugin := range plugins {
+		pm.attach(plugin)
+	}
+	
+	pm.mu.Lock()
+	pm.plugins = plugins
+	pm.mu.Unlock()
+	return nil
+}
+
+// attach 将插件接入熔断器
+func (pm *PluginManager) attach(plugin AsyncSearchPlugin) {
+	pm.breaker.Track(plugin.Name())
+	
+	// 支持上报搜索结果的插件（BaseAsyncPlugin）将结果记录到熔断器
+	if observable, ok := plugin.(interface {
+		SetSearchObserver(func(time.Duration, error))
+	}); ok {
+		name := plugin.Name()
+		observable.SetSearchObserver(func(latency time.Duration, err error) {
+			pm.breaker.Record(name, latency, err)
+		})
+	}
+}
+
+// AllowPlugin 判断插件是否可以搜索，熔断中的插件返回false
+func (pm *PluginManager) AllowPlugin(name string) bool {
+	return pm.breaker.Allow(name)
+}
+
+// PluginHealth 返回当前启用插件的健康和熔断状态
+// 运行时停用的插件保留熔断记录，但不出现在结果中
+func (pm *PluginManager) PluginHealth() []PluginHealth {
+	active := make(map[string]bool)
+	for _, name := range pm.PluginNames() {
+		active[name] = true
+	}
+	
+	health := []PluginHealth{}
+	for _, item := range pm.breaker.Health() {
+		if active[item.Name] {
+			health = append(health, item)
+		}
+	}
+	return health
 }
 
 // RegisterAllGlobalPlugins 注册所有全局异步插件
@@ -133,44 +216,57 @@ func (pm *PluginManager) RegisterGlobalPluginsWithFilter(enabledPlugins []string
 }
 
 // GetPlugins 获取所有注册的异步插件
+// 返回的是当前列表的副本，调用方可以自由排序
 func (pm *PluginManager) GetPlugins() []AsyncSearchPlugin {
-	return pm.plugins
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	
+	plugins := make([]AsyncSearchPlugin, len(pm.plugins))
+	copy(plugins, pm.plugins)
+	return plugins
+}
+
+// PluginNames 获取当前启用插件的名称
+func (pm *PluginManager) PluginNames() []string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	
+	names := make([]string, 0, len(pm.plugins))
+	for _, plugin := range pm.plugins {
+		names = append(names, plugin.Name())
+	}
+	return names
 }
 
 // FilterResultsByKeyword 根据关键词过滤搜索结果的全局辅助函数
+// 关键词中的每个词需出现在标题或内容中，匹配规则见NewKeywordMatcher
 func FilterResultsByKeyword(results []model.SearchResult, keyword string) []model.SearchResult {
 	if keyword == "" {
 		return results
 	}
 	
+	matcher := NewKeywordMatcher(keyword)
+	if matcher.Empty() {
+		return results
+	}
+	
 	// 预估过滤后会保留80%的结果
 	filteredResults := make([]model.SearchResult, 0, len(results)*8/10)
-
-	// 将关键词转为小写，用于不区分大小写的比较
-	lowerKeyword := strings.ToLower(keyword)
-
-	// 将关键词按空格分割，用于支持多关键词搜索
-	keywords := strings.Fields(lowerKeyword)
-
 	for _, result := range results {
-		// 将标题和内容转为小写
-		lowerTitle := strings.ToLower(result.Title)
-		lowerContent := strings.ToLower(result.Content)
-
-		// 检查每个关键词是否在标题或内容中
-		matched := true
-		for _, kw := range keywords {
-			// 对于所有关键词，检查是否在标题或内容中
-			if !strings.Contains(lowerTitle, kw) && !strings.Contains(lowerContent, kw) {
-				matched = false
-				break
-			}
-		}
-
-		if matched {
+		if matcher.Match(result.Title, result.Content) {
 			filteredResults = append(filteredResults, result)
 		}
 	}
 
 	return filteredResults
+}
+
+// NewKeywordMatcher 创建关键词匹配器，插件层和Service层的关键词过滤共用
+// 始终忽略繁简、全半角、大小写、标点空白和季/集编号写法的差异，拼音和模糊匹配由配置开启
+func NewKeywordMatcher(keyword string) *textmatch.Matcher {
+	var options textmatch.Options
+	if config.AppConfig != nil {
+		options.Pinyin, options.MaxDistance = config.GetKeywordMatch()
+	}
+	return textmatch.NewMatcher(keyword, options)
 } 
\ No newline at end of file
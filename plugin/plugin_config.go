@@ -0,0 +1,217 @@
+package plugin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/util"
+)
+
+// PluginConfig 插件的运行时配置
+// 来自配置文件的plugins段或环境变量PLUGIN_<插件名>_<配置项>，每次使用时读取，配置重新加载后立即生效
+// 未设置的配置项为零值，表示使用插件内置的默认值
+type PluginConfig struct {
+	Enabled        bool          // enabled，为false时插件不参与搜索，默认true
+	Timeout        time.Duration // timeout（秒），插件HTTP请求超时
+	Priority       int           // priority，覆盖插件内置的优先级
+	Mirrors        []string      // mirrors，站点地址列表，第一个为主地址，其余为备用地址
+	MaxPages       int           // max_pages，最多搜索的页数
+	MaxConcurrency int           // max_concurrency，详情页等请求的最大并发数
+	Proxy          string        // proxy，插件使用的HTTP或SOCKS5代理，如socks5://127.0.0.1:1080
+}
+
+// LoadPluginConfig 读取插件的运行时配置
+func LoadPluginConfig(name string) PluginConfig {
+	cfg := PluginConfig{
+		Enabled:        true,
+		Timeout:        time.Duration(pluginSettingInt(name, "timeout", 0)) * time.Second,
+		Priority:       pluginSettingInt(name, "priority", 0),
+		MaxPages:       pluginSettingInt(name, "max_pages", 0),
+		MaxConcurrency: pluginSettingInt(name, "max_concurrency", 0),
+	}
+	if value, ok := config.PluginSetting(name, "enabled"); ok {
+		if enabled, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			cfg.Enabled = enabled
+		}
+	}
+	if value, ok := config.PluginSetting(name, "mirrors"); ok {
+		for _, mirror := range strings.Split(value, ",") {
+			if mirror = strings.TrimRight(strings.TrimSpace(mirror), "/"); mirror != "" {
+				cfg.Mirrors = append(cfg.Mirrors, mirror)
+			}
+		}
+	}
+	if value, ok := config.PluginSetting(name, "proxy"); ok {
+		cfg.Proxy = strings.TrimSpace(value)
+	}
+	return cfg
+}
+
+// PluginEnabled 插件是否未被配置为停用（enabled: false）
+func PluginEnabled(name string) bool {
+	return LoadPluginConfig(name).Enabled
+}
+
+// pluginSettingInt 读取插件的整数配置项，未设置或无效（非正数）时返回def
+func pluginSettingInt(name, key string, def int) int {
+	value, ok := config.PluginSetting(name, key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+// configuredClients 按插件配置的超时和代理创建的HTTP客户端
+type configuredClients struct {
+	mu         sync.Mutex
+	signature  string // 创建客户端时的超时和代理，配置变化后重新创建
+	client     *http.Client
+	background *http.Client
+}
+
+// PluginConfig 返回插件的运行时配置
+func (p *BaseAsyncPlugin) PluginConfig() PluginConfig {
+	return LoadPluginConfig(p.name)
+}
+
+// Setting 读取插件的自定义配置项，用于插件特有的参数
+func (p *BaseAsyncPlugin) Setting(key string) (string, bool) {
+	return config.PluginSetting(p.name, key)
+}
+
+// SettingInt 读取插件的整数配置项，未设置或无效（非正数）时返回def
+func (p *BaseAsyncPlugin) SettingInt(key string, def int) int {
+	return pluginSettingInt(p.name, key, def)
+}
+
+// Mirrors 返回站点地址列表，未配置mirrors时返回defaults
+func (p *BaseAsyncPlugin) Mirrors(defaults ...string) []string {
+	if mirrors := p.PluginConfig().Mirrors; len(mirrors) > 0 {
+		return mirrors
+	}
+	return defaults
+}
+
+// BaseURL 返回站点主地址，未配置mirrors时返回def
+func (p *BaseAsyncPlugin) BaseURL(def string) string {
+	return p.Mirrors(def)[0]
+}
+
+// MaxPages 返回最多搜索的页数，未配置时返回def
+func (p *BaseAsyncPlugin) MaxPages(def int) int {
+	return pluginSettingInt(p.name, "max_pages", def)
+}
+
+// MaxConcurrency 返回最大并发数，未配置时返回def
+func (p *BaseAsyncPlugin) MaxConcurrency(def int) int {
+	return pluginSettingInt(p.name, "max_concurrency", def)
+}
+
+// NewHTTPClient 创建应用了插件超时和代理配置的HTTP客户端，用于需要自定义客户端的插件
+// 未配置timeout时使用defaultTimeout
+func (p *BaseAsyncPlugin) NewHTTPClient(defaultTimeout time.Duration) *http.Client {
+	cfg := p.PluginConfig()
+	client := &http.Client{Timeout: defaultTimeout}
+	if cfg.Timeout > 0 {
+		client.Timeout = cfg.Timeout
+	}
+	if cfg.Proxy != "" {
+		client.Transport = util.NewTransport(cfg.Proxy)
+	}
+	return client
+}
+
+// httpClients 返回搜索使用的短超时和长超时客户端，应用插件配置的超时和代理
+func (p *BaseAsyncPlugin) httpClients() (*http.Client, *http.Client) {
+	cfg := p.PluginConfig()
+	if cfg.Timeout <= 0 && cfg.Proxy == "" {
+		return p.client, p.backgroundClient
+	}
+
+	p.configured.mu.Lock()
+	defer p.configured.mu.Unlock()
+
+	signature := cfg.Timeout.String() + "|" + cfg.Proxy
+	if p.configured.signature == signature {
+		return p.configured.client, p.configured.background
+	}
+
+	transport := p.client.Transport
+	if cfg.Proxy != "" {
+		transport = util.NewTransport(cfg.Proxy)
+	}
+	responseTimeout := p.client.Timeout
+	processingTimeout := p.backgroundClient.Timeout
+	if cfg.Timeout > 0 {
+		processingTimeout = cfg.Timeout
+		if cfg.Timeout < responseTimeout {
+			responseTimeout = cfg.Timeout
+		}
+	}
+
+	p.configured.signature = signature
+	p.configured.client = &http.Client{Timeout: responseTimeout, Transport: transport}
+	p.configured.background = &http.Client{Timeout: processingTimeout, Transport: transport}
+	return p.configured.client, p.configured.background
+}
+
+// MirrorSelector 在插件的多个站点地址间切换
+// 地址列表每次读取插件配置，配置的mirrors变化后自动回到新的主地址
+type MirrorSelector struct {
+	plugin   *BaseAsyncPlugin
+	defaults []string
+
+	mu      sync.Mutex
+	current string
+}
+
+// NewMirrorSelector 创建站点地址选择器，defaults为插件内置的地址（第一个为主地址）
+func (p *BaseAsyncPlugin) NewMirrorSelector(defaults ...string) *MirrorSelector {
+	return &MirrorSelector{plugin: p, defaults: defaults}
+}
+
+// Current 返回当前使用的地址
+func (m *MirrorSelector) Current() string {
+	mirrors := m.plugin.Mirrors(m.defaults...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if indexOf(mirrors, m.current) < 0 {
+		m.current = mirrors[0]
+	}
+	return m.current
+}
+
+// IsPrimary 当前是否在使用主地址
+func (m *MirrorSelector) IsPrimary() bool {
+	return m.Current() == m.plugin.Mirrors(m.defaults...)[0]
+}
+
+// Failover 切换到下一个地址，已是最后一个时回到主地址，返回切换后的地址
+func (m *MirrorSelector) Failover() string {
+	mirrors := m.plugin.Mirrors(m.defaults...)
+	current := m.Current()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = mirrors[(indexOf(mirrors, current)+1)%len(mirrors)]
+	return m.current
+}
+
+// indexOf 返回s在list中的位置，不存在时返回-1
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
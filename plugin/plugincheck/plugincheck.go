@@ -0,0 +1,413 @@
+// Package plugincheck 用测试关键词检查插件是否可用
+// 对每个插件执行真实搜索，按结果归类（正常、无结果、解析失败、HTTP错误、超时、被限流），
+// 统计耗时和链接类型分布，用于定时巡检失效的插件。
+package plugincheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	jsonutil "pansou/util/json"
+)
+
+// Outcome 检查结果分类
+type Outcome string
+
+const (
+	OutcomeOK          Outcome = "ok"           // 有带链接的结果
+	OutcomeEmpty       Outcome = "empty"        // 请求成功但没有结果
+	OutcomeParseError  Outcome = "parse_error"  // 响应解析失败，通常是站点改版
+	OutcomeHTTP4xx     Outcome = "http_4xx"     // 站点返回4xx
+	OutcomeHTTP5xx     Outcome = "http_5xx"     // 站点返回5xx
+	OutcomeTimeout     Outcome = "timeout"      // 超时
+	OutcomeRateLimited Outcome = "rate_limited" // 被限流（429等）
+	OutcomeError       Outcome = "error"        // 其他错误，如DNS解析失败、连接被拒绝
+)
+
+// 默认的测试关键词，可被插件配置check_keywords覆盖
+var DefaultKeywords = []string{"凡人修仙传", "流浪地球"}
+
+var (
+	// 从错误信息中提取HTTP状态码
+	statusCodePattern = regexp.MustCompile(`(?i)(?:状态码|status(?:\s*code)?|http)\D{0,3}([1-5]\d\d)\b`)
+	// 表示被限流的错误信息
+	rateLimitPattern = regexp.MustCompile(`(?i)rate.?limit|too many requests|限流|频繁`)
+	// 表示超时的错误信息
+	timeoutPattern = regexp.MustCompile(`(?i)timeout|timed out|deadline exceeded|超时`)
+	// 表示响应解析失败的错误信息
+	parseErrorPattern = regexp.MustCompile(`(?i)解析|parse|decode|unmarshal|syntax|invalid character|unexpected end`)
+)
+
+// Options 检查参数
+type Options struct {
+	Keywords    []string      // 测试关键词，为空时使用DefaultKeywords
+	Timeout     time.Duration // 每次搜索的超时
+	Concurrency int           // 同时检查的插件数
+}
+
+// KeywordResult 一个关键词的检查结果
+type KeywordResult struct {
+	Keyword    string         `json:"keyword"`
+	Outcome    Outcome        `json:"outcome"`
+	LatencyMs  int64          `json:"latency_ms"`
+	Results    int            `json:"results"`
+	LinkTypes  map[string]int `json:"link_types,omitempty"`
+	StatusCode int            `json:"status_code,omitempty"` // 观察到的最后一个非2xx状态码
+	Error      string         `json:"error,omitempty"`
+}
+
+// PluginReport 一个插件的检查结果
+// 任一关键词正常即为正常，否则取第一个非empty的失败分类
+type PluginReport struct {
+	Name         string          `json:"name"`
+	Priority     int             `json:"priority"`
+	Outcome      Outcome         `json:"outcome"`
+	AvgLatencyMs int64           `json:"avg_latency_ms"`
+	Results      int             `json:"results"`
+	LinkTypes    map[string]int  `json:"link_types,omitempty"`
+	Keywords     []KeywordResult `json:"keywords"`
+}
+
+// Report 检查报告
+type Report struct {
+	CheckedAt time.Time       `json:"checked_at"`
+	Keywords  []string        `json:"keywords"`
+	Summary   map[Outcome]int `json:"summary"`
+	Plugins   []PluginReport  `json:"plugins"`
+}
+
+// Healthy 是否所有插件都正常
+func (r Report) Healthy() bool {
+	return r.Summary[OutcomeOK] == len(r.Plugins)
+}
+
+// Run 并发检查插件，报告按插件名排序
+// 检查期间异步插件的响应超时设置为opts.Timeout，以便拿到完整结果而不是"后台处理中"的空结果
+func Run(plugins []plugin.AsyncSearchPlugin, opts Options) Report {
+	if len(opts.Keywords) == 0 {
+		opts.Keywords = DefaultKeywords
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	if config.AppConfig != nil {
+		config.SetAsyncResponseTimeout(opts.Timeout)
+	}
+
+	report := Report{
+		CheckedAt: time.Now(),
+		Keywords:  opts.Keywords,
+		Summary:   make(map[Outcome]int),
+		Plugins:   make([]PluginReport, len(plugins)),
+	}
+
+	semaphore := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, p := range plugins {
+		wg.Add(1)
+		go func(i int, p plugin.AsyncSearchPlugin) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			report.Plugins[i] = CheckPlugin(p, opts)
+		}(i, p)
+	}
+	wg.Wait()
+
+	sort.Slice(report.Plugins, func(i, j int) bool { return report.Plugins[i].Name < report.Plugins[j].Name })
+	for _, p := range report.Plugins {
+		report.Summary[p.Outcome]++
+	}
+	return report
+}
+
+// CheckPlugin 依次用各关键词检查一个插件
+func CheckPlugin(p plugin.AsyncSearchPlugin, opts Options) PluginReport {
+	keywords := opts.Keywords
+	if value, ok := config.PluginSetting(p.Name(), "check_keywords"); ok && strings.TrimSpace(value) != "" {
+		keywords = splitKeywords(value)
+	}
+
+	// 观察插件发出的请求，比错误信息更准确地识别HTTP状态码和超时
+	recorder := &statusRecorder{next: http.DefaultTransport}
+	if settable, ok := p.(interface{ SetTransport(http.RoundTripper) }); ok {
+		settable.SetTransport(recorder)
+	}
+
+	report := PluginReport{
+		Name:      p.Name(),
+		Priority:  p.Priority(),
+		LinkTypes: make(map[string]int),
+	}
+	var totalLatency time.Duration
+	for _, keyword := range keywords {
+		recorder.reset()
+		start := time.Now()
+		results, final, err := search(p, keyword, opts.Timeout)
+		latency := time.Since(start)
+		totalLatency += latency
+
+		result := classify(keyword, results, final, err, recorder)
+		result.LatencyMs = latency.Milliseconds()
+		report.Keywords = append(report.Keywords, result)
+		report.Results += result.Results
+		for linkType, count := range result.LinkTypes {
+			report.LinkTypes[linkType] += count
+		}
+	}
+	if len(keywords) > 0 {
+		report.AvgLatencyMs = (totalLatency / time.Duration(len(keywords))).Milliseconds()
+	}
+	report.Outcome = overallOutcome(report.Keywords)
+	return report
+}
+
+// search 执行一次搜索，超过timeout（留出余量）仍未返回时视为超时
+// 插件实现了SearchWithResult时，未完成的结果（IsFinal为false）也视为超时
+func search(p plugin.AsyncSearchPlugin, keyword string, timeout time.Duration) ([]model.SearchResult, bool, error) {
+	type outcome struct {
+		results []model.SearchResult
+		final   bool
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		if withResult, ok := p.(interface {
+			SearchWithResult(string, map[string]interface{}) (model.PluginSearchResult, error)
+		}); ok {
+			result, err := withResult.SearchWithResult(keyword, nil)
+			done <- outcome{result.Results, result.IsFinal || err != nil, err}
+			return
+		}
+		results, err := p.Search(keyword, nil)
+		done <- outcome{results, true, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.results, o.final, o.err
+	case <-time.After(timeout + 5*time.Second):
+		return nil, false, context.DeadlineExceeded
+	}
+}
+
+// classify 归类一次搜索的结果
+func classify(keyword string, results []model.SearchResult, final bool, err error, recorder *statusRecorder) KeywordResult {
+	result := KeywordResult{Keyword: keyword, LinkTypes: make(map[string]int)}
+	status, transportErr := recorder.last()
+	result.StatusCode = status
+
+	for _, r := range results {
+		if len(r.Links) == 0 {
+			continue
+		}
+		result.Results++
+		for _, link := range r.Links {
+			result.LinkTypes[link.Type]++
+		}
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		result.Outcome = classifyError(err, status)
+		return result
+	}
+	if result.Results > 0 {
+		result.Outcome = OutcomeOK
+		return result
+	}
+	if !final {
+		result.Outcome = OutcomeTimeout
+		return result
+	}
+
+	// 没有结果也没有错误：插件可能吞掉了请求错误，按观察到的请求判断
+	switch {
+	case status == http.StatusTooManyRequests:
+		result.Outcome = OutcomeRateLimited
+	case status >= 500:
+		result.Outcome = OutcomeHTTP5xx
+	case status >= 400:
+		result.Outcome = OutcomeHTTP4xx
+	case transportErr != nil:
+		result.Error = transportErr.Error()
+		result.Outcome = classifyError(transportErr, 0)
+	default:
+		result.Outcome = OutcomeEmpty
+	}
+	return result
+}
+
+// classifyError 按错误和观察到的状态码归类
+func classifyError(err error, status int) Outcome {
+	msg := err.Error()
+	if status == 0 {
+		if matches := statusCodePattern.FindStringSubmatch(msg); matches != nil {
+			status, _ = strconv.Atoi(matches[1])
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case status == http.StatusTooManyRequests || rateLimitPattern.MatchString(msg):
+		return OutcomeRateLimited
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) || timeoutPattern.MatchString(msg):
+		return OutcomeTimeout
+	case status >= 500:
+		return OutcomeHTTP5xx
+	case status >= 400:
+		return OutcomeHTTP4xx
+	case parseErrorPattern.MatchString(msg):
+		return OutcomeParseError
+	default:
+		return OutcomeError
+	}
+}
+
+// overallOutcome 插件的总体结果
+func overallOutcome(results []KeywordResult) Outcome {
+	outcome := OutcomeEmpty
+	for _, r := range results {
+		if r.Outcome == OutcomeOK {
+			return OutcomeOK
+		}
+		if outcome == OutcomeEmpty && r.Outcome != OutcomeEmpty {
+			outcome = r.Outcome
+		}
+	}
+	return outcome
+}
+
+// splitKeywords 按逗号分割关键词
+func splitKeywords(value string) []string {
+	var keywords []string
+	for _, keyword := range strings.Split(value, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+// statusRecorder 记录插件请求的最后一个非2xx状态码和传输错误
+type statusRecorder struct {
+	next http.RoundTripper
+
+	mu     sync.Mutex
+	status int
+	err    error
+}
+
+// RoundTrip 实现http.RoundTripper
+func (r *statusRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.err = err
+	} else if resp.StatusCode >= 400 {
+		r.status = resp.StatusCode
+	}
+	return resp, err
+}
+
+// reset 清空记录，开始检查下一个关键词
+func (r *statusRecorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = 0
+	r.err = nil
+}
+
+// last 返回记录的状态码和传输错误
+func (r *statusRecorder) last() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status, r.err
+}
+
+// WriteJSON 以JSON格式输出报告
+func (r Report) WriteJSON(w io.Writer) error {
+	data, err := jsonutil.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// WriteTable 以表格格式输出报告
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "插件\t结果\t平均耗时\t结果数\t链接类型\t错误")
+	for _, p := range r.Plugins {
+		fmt.Fprintf(tw, "%s\t%s\t%dms\t%d\t%s\t%s\n",
+			p.Name, p.Outcome, p.AvgLatencyMs, p.Results, formatLinkTypes(p.LinkTypes), firstError(p))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	outcomes := make([]string, 0, len(r.Summary))
+	for outcome, count := range r.Summary {
+		outcomes = append(outcomes, fmt.Sprintf("%s=%d", outcome, count))
+	}
+	sort.Strings(outcomes)
+	_, err := fmt.Fprintf(w, "\n共检查 %d 个插件，关键词: %s，结果: %s\n",
+		len(r.Plugins), strings.Join(r.Keywords, ","), strings.Join(outcomes, " "))
+	return err
+}
+
+// formatLinkTypes 按数量从多到少输出链接类型，如quark:3 baidu:1
+func formatLinkTypes(linkTypes map[string]int) string {
+	if len(linkTypes) == 0 {
+		return "-"
+	}
+	types := make([]string, 0, len(linkTypes))
+	for linkType := range linkTypes {
+		types = append(types, linkType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if linkTypes[types[i]] != linkTypes[types[j]] {
+			return linkTypes[types[i]] > linkTypes[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	parts := make([]string, len(types))
+	for i, linkType := range types {
+		parts[i] = fmt.Sprintf("%s:%d", linkType, linkTypes[linkType])
+	}
+	return strings.Join(parts, " ")
+}
+
+// firstError 插件第一个错误信息，过长时截断
+func firstError(p PluginReport) string {
+	for _, k := range p.Keywords {
+		if k.Error == "" {
+			continue
+		}
+		msg := strings.Join(strings.Fields(k.Error), " ")
+		if runes := []rune(msg); len(runes) > 80 {
+			msg = string(runes[:80]) + "..."
+		}
+		return msg
+	}
+	return ""
+}
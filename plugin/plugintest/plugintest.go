@@ -0,0 +1,338 @@
+// Package plugintest 插件的离线测试工具
+//
+// 测试时插件的HTTP请求由录制的fixtures回放，解析出的结果与golden文件比较，
+// 站点改版导致解析失败时测试会报告差异。
+//
+//	go test ./plugin/...               回放fixtures并与golden文件比较
+//	go test ./plugin/labi -update      按现有fixtures重新生成golden文件
+//	go test ./plugin/labi -record      访问真实站点，重新录制fixtures并生成golden文件
+//
+// fixtures保存在插件目录的testdata/fixtures/<用例名>/下，每个请求一个文件；
+// golden文件为testdata/golden/<用例名>.json。
+package plugintest
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+)
+
+var (
+	record = flag.Bool("record", false, "访问真实站点，重新录制fixtures并生成golden文件")
+	update = flag.Bool("update", false, "按现有fixtures重新生成golden文件")
+)
+
+// 录制时保留的响应头
+var recordedHeaders = []string{"Content-Type", "Location", "Set-Cookie"}
+
+// 文件名中不允许的字符
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// localTimeOnce 测试统一使用UTC时区，保证golden文件中的时间与运行环境无关
+var localTimeOnce sync.Once
+
+// Plugin 可以替换HTTP Transport的插件，嵌入BaseAsyncPlugin的插件都满足
+type Plugin interface {
+	plugin.AsyncSearchPlugin
+	SetTransport(transport http.RoundTripper)
+}
+
+// Case 测试用例
+type Case struct {
+	Name    string                 // 用例名，用于fixtures目录和golden文件名
+	Keyword string                 // 搜索关键词
+	Ext     map[string]interface{} // 扩展参数
+}
+
+// Run 依次执行用例：回放（或录制）插件的HTTP请求，比较搜索结果与golden文件
+func Run(t *testing.T, p Plugin, cases ...Case) {
+	t.Helper()
+	localTimeOnce.Do(func() { time.Local = time.UTC })
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			transport := NewTransport(t, filepath.Join("testdata", "fixtures", c.Name))
+			p.SetTransport(transport)
+			util.SetHTTPClient(&http.Client{Transport: transport, Timeout: 30 * time.Second})
+
+			results, err := p.Search(c.Keyword, c.Ext)
+			// 缺少fixture等回放错误是搜索失败的根本原因，优先报告
+			if transportErr := transport.Err(); transportErr != nil {
+				t.Fatal(transportErr)
+			}
+			if err != nil {
+				t.Fatalf("搜索失败: %v", err)
+			}
+			CompareGolden(t, filepath.Join("testdata", "golden", c.Name+".json"), results)
+		})
+	}
+}
+
+// Transport 录制和回放HTTP请求
+// 回放时按请求方法、URL和请求体查找fixture，找不到时返回502并记录错误
+type Transport struct {
+	dir    string
+	record bool
+	next   http.RoundTripper
+
+	mu   sync.Mutex
+	errs []string
+}
+
+// NewTransport 创建使用dir中fixtures的Transport，-record时转发真实请求并覆盖fixtures
+func NewTransport(t testing.TB, dir string) *Transport {
+	tr := &Transport{dir: dir, record: *record, next: http.DefaultTransport}
+	if tr.record {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatalf("清理fixtures失败: %v", err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("创建fixtures目录失败: %v", err)
+		}
+	}
+	return tr
+}
+
+// fixture 录制的一次请求和响应
+type fixture struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Body       string            `json:"body,omitempty"`
+	Status     int               `json:"status"`
+	Header     map[string]string `json:"header,omitempty"`
+	Response   string            `json:"response"`
+	Base64Body bool              `json:"base64,omitempty"` // 响应不是UTF-8文本时为base64编码
+}
+
+// RoundTrip 实现http.RoundTripper
+func (tr *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	path := filepath.Join(tr.dir, fixtureName(req, body))
+
+	if tr.record {
+		return tr.recordRequest(req, body, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		tr.addError("缺少fixture %s（%s %s），使用-record录制", path, req.Method, req.URL)
+		return &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Status:     "502 Bad Gateway",
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析fixture %s失败: %w", path, err)
+	}
+	return f.response(req)
+}
+
+// recordRequest 发送真实请求并保存响应
+func (tr *Transport) recordRequest(req *http.Request, body []byte, path string) (*http.Response, error) {
+	resp, err := tr.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f := fixture{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Body:   string(body),
+		Status: resp.StatusCode,
+		Header: map[string]string{},
+	}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			f.Header[name] = value
+		}
+	}
+	if utf8.Valid(data) {
+		f.Response = string(data)
+	} else {
+		f.Response = base64.StdEncoding.EncodeToString(data)
+		f.Base64Body = true
+	}
+	if err := writeJSON(path, f); err != nil {
+		tr.addError("保存fixture %s失败: %v", path, err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+// response 由fixture构造响应
+func (f fixture) response(req *http.Request) (*http.Response, error) {
+	data := []byte(f.Response)
+	if f.Base64Body {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(f.Response); err != nil {
+			return nil, err
+		}
+	}
+	header := http.Header{}
+	for name, value := range f.Header {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		StatusCode:    f.Status,
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// Err 回放过程中的错误，如缺少fixture
+func (tr *Transport) Err() error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.errs) == 0 {
+		return nil
+	}
+	sort.Strings(tr.errs)
+	return fmt.Errorf("%s", strings.Join(tr.errs, "\n"))
+}
+
+// addError 记录错误，插件可能吞掉请求错误，由Run在搜索结束后报告
+// 插件重试同一请求时只记录一次
+func (tr *Transport) addError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for _, err := range tr.errs {
+		if err == msg {
+			return
+		}
+	}
+	tr.errs = append(tr.errs, msg)
+}
+
+// fixtureName 请求对应的fixture文件名：主机名加请求的哈希
+// JSON请求体先规范化（键排序），避免序列化顺序不同导致找不到fixture
+func fixtureName(req *http.Request, body []byte) string {
+	var v interface{}
+	if len(body) > 0 && json.Unmarshal(body, &v) == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			body = canonical
+		}
+	}
+	sum := sha1.Sum([]byte(req.Method + " " + req.URL.String() + "\n" + string(body)))
+	return unsafeChars.ReplaceAllString(req.URL.Host, "_") + "_" + hex.EncodeToString(sum[:6]) + ".json"
+}
+
+// goldenResult golden文件中的结果，只保留解析相关的字段
+type goldenResult struct {
+	UniqueID string       `json:"unique_id"`
+	Title    string       `json:"title"`
+	Content  string       `json:"content,omitempty"`
+	Datetime string       `json:"datetime,omitempty"`
+	Links    []model.Link `json:"links"`
+	Tags     []string     `json:"tags,omitempty"`
+}
+
+// CompareGolden 比较结果与golden文件，-record或-update时覆盖golden文件
+// 结果按UniqueID排序后比较，与插件内部的并发顺序无关
+func CompareGolden(t *testing.T, path string, results []model.SearchResult) {
+	t.Helper()
+
+	golden := make([]goldenResult, 0, len(results))
+	for _, r := range results {
+		g := goldenResult{
+			UniqueID: r.UniqueID,
+			Title:    r.Title,
+			Content:  r.Content,
+			Links:    r.Links,
+			Tags:     r.Tags,
+		}
+		if !r.Datetime.IsZero() {
+			g.Datetime = r.Datetime.UTC().Format(time.RFC3339)
+		}
+		if g.Links == nil {
+			g.Links = []model.Link{}
+		}
+		golden = append(golden, g)
+	}
+	sort.SliceStable(golden, func(i, j int) bool { return golden[i].UniqueID < golden[j].UniqueID })
+
+	got, err := marshalJSON(golden)
+	if err != nil {
+		t.Fatalf("序列化结果失败: %v", err)
+	}
+
+	if *record || *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("创建golden目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("写入golden文件失败: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取golden文件失败: %v（使用-update生成）", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("结果与golden文件%s不一致（确认为预期变化时使用-update更新）\n得到:\n%s\n期望:\n%s", path, got, want)
+	}
+}
+
+// writeJSON 写入格式化的JSON文件
+func writeJSON(path string, v interface{}) error {
+	data, err := marshalJSON(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// marshalJSON 格式化输出JSON，不转义HTML字符，便于阅读和比较
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,6 +1,7 @@
 package qupansou
 
 import (
+	"context"
 	"bytes"
 	"fmt"
 	"io"
@@ -102,6 +103,11 @@ func (p *QuPanSouAsyncPlugin) SearchWithResult(keyword string, ext map[string]in
 	return p.AsyncSearchWithResult(keyword, p.doSearch, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *QuPanSouAsyncPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.doSearch, p.MainCacheKey, ext)
+}
+
 // doSearch 执行具体的搜索逻辑
 func (p *QuPanSouAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 发送API请求
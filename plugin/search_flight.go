@@ -0,0 +1,23 @@
+package plugin
+
+import (
+	"strings"
+
+	"pansou/model"
+	"pansou/util/singleflight"
+)
+
+// 合并相同插件、相同主缓存键的并发搜索，缓存写入前同时到达的请求只请求一次上游
+var (
+	asyncSearchFlight      singleflight.Group[[]model.SearchResult]
+	searchWithResultFlight singleflight.Group[model.PluginSearchResult]
+)
+
+// flightKey 合并搜索的键：插件名加主缓存键，未设置主缓存键时使用规范化后的关键词
+// 主缓存键由关键词和插件列表生成，键相同的请求后台完成时更新同一个主缓存
+func (p *BaseAsyncPlugin) flightKey(mainCacheKey, keyword string) string {
+	if mainCacheKey != "" {
+		return p.name + ":" + mainCacheKey
+	}
+	return p.name + "::" + strings.ToLower(strings.TrimSpace(keyword))
+}
@@ -1,6 +1,7 @@
 package susu
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -117,6 +118,11 @@ func (p *SusuAsyncPlugin) SearchWithResult(keyword string, ext map[string]interf
 	return p.AsyncSearchWithResult(keyword, p.doSearch, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *SusuAsyncPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.doSearch, p.MainCacheKey, ext)
+}
+
 // doSearch 实际的搜索实现
 func (p *SusuAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 构建搜索URL
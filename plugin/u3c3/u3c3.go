@@ -98,11 +98,9 @@ func (p *U3c3Plugin) getSearch2Parameter() (string, error) {
 		log.Printf("[U3C3] 正在获取search2参数...")
 	}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	client := p.NewHTTPClient(30 * time.Second)
 
-	req, err := http.NewRequest("GET", BaseURL, nil)
+	req, err := http.NewRequest("GET", p.BaseURL(BaseURL), nil)
 	if err != nil {
 		return "", err
 	}
@@ -210,15 +208,13 @@ func (p *U3c3Plugin) extractSearch2FromHTML(html string) string {
 func (p *U3c3Plugin) doSearch(keyword, search2 string) ([]model.SearchResult, error) {
 	// 构建搜索URL
 	encodedKeyword := url.QueryEscape(keyword)
-	searchURL := fmt.Sprintf("%s/?search2=%s&search=%s", BaseURL, search2, encodedKeyword)
+	searchURL := fmt.Sprintf("%s/?search2=%s&search=%s", p.BaseURL(BaseURL), search2, encodedKeyword)
 
 	if p.debugMode {
 		log.Printf("[U3C3] 搜索URL: %s", searchURL)
 	}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	client := p.NewHTTPClient(30 * time.Second)
 
 	req, err := http.NewRequest("GET", searchURL, nil)
 	if err != nil {
@@ -226,7 +222,7 @@ func (p *U3c3Plugin) doSearch(keyword, search2 string) ([]model.SearchResult, er
 	}
 
 	req.Header.Set("User-Agent", UserAgent)
-	req.Header.Set("Referer", BaseURL+"/")
+	req.Header.Set("Referer", p.BaseURL(BaseURL)+"/")
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
 
 	var resp *http.Response
@@ -294,7 +290,7 @@ func (p *U3c3Plugin) parseSearchResults(html string) ([]model.SearchResult, erro
 		// 提取详情页链接（可选，用于后续扩展）
 		detailURL, _ := titleLink.Attr("href")
 		if detailURL != "" && !strings.HasPrefix(detailURL, "http") {
-			detailURL = BaseURL + detailURL
+			detailURL = p.BaseURL(BaseURL) + detailURL
 		}
 
 		// 提取链接信息
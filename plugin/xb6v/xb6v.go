@@ -1,6 +1,7 @@
 package xb6v
 
 import (
+	"context"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -18,11 +19,11 @@ import (
 )
 
 const (
-	BaseURL        = "https://www.66ss.org" // 主域名
+	BaseURL        = "https://www.66ss.org" // 主域名，可通过插件配置mirrors修改
 	BackupURL      = "https://www.xb6v.com" // 备用域名
 	SearchPath     = "/e/search/1index.php"  // 搜索端点
 	UserAgent      = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/138.0.0.0 Safari/537.36"
-	MaxConcurrency = 50 // 详情页最大并发数
+	MaxConcurrency = 50 // 详情页最大并发数，可通过插件配置max_concurrency修改
 	MaxResults     = 50 // 最大搜索结果数
 )
 
@@ -32,7 +33,7 @@ type Xb6vPlugin struct {
 	debugMode    bool
 	detailCache  sync.Map // 缓存详情页结果
 	cacheTTL     time.Duration
-	currentBase  string // 当前使用的域名
+	mirrors      *plugin.MirrorSelector // 当前使用的域名
 }
 
 // DetailPageInfo 详情页信息
@@ -51,8 +52,8 @@ func NewXb6vPlugin() *Xb6vPlugin {
 		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter("xb6v", 3, true),
 		debugMode:       debugMode,
 		cacheTTL:        30 * time.Minute,
-		currentBase:     BaseURL,
 	}
+	p.mirrors = p.NewMirrorSelector(BaseURL, BackupURL)
 	
 	// 设置主缓存键
 	p.BaseAsyncPlugin.SetMainCacheKey(p.Name())
@@ -89,6 +90,11 @@ func (p *Xb6vPlugin) SearchWithResult(keyword string, ext map[string]interface{}
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *Xb6vPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // setRequestHeaders 设置请求头
 func (p *Xb6vPlugin) setRequestHeaders(req *http.Request, referer string) {
 	req.Header.Set("User-Agent", UserAgent)
@@ -167,18 +173,20 @@ func (p *Xb6vPlugin) searchImpl(client *http.Client, keyword string, ext map[str
 	}
 	
 	// 第一步：POST搜索请求
-	searchURL := p.currentBase + SearchPath
+	baseURL := p.mirrors.Current()
+	searchURL := baseURL + SearchPath
 	postData := fmt.Sprintf("show=title&tempid=1&tbname=article&mid=1&dopost=search&submit=&keyboard=%s", url.QueryEscape(keyword))
 	
-	// 创建不自动重定向的客户端
-	noRedirectClient := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
+	// 创建不自动重定向的客户端（应用插件配置的代理）
+	noRedirectClient := p.NewHTTPClient(client.Timeout)
+	noRedirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
 	}
 	
-	resp, err := p.doRequest(noRedirectClient, "POST", searchURL, postData, p.currentBase)
+	resp, err := p.doRequest(noRedirectClient, "POST", searchURL, postData, baseURL)
 	if err != nil {
+		// 当前域名不可用，下次搜索使用备用域名
+		p.mirrors.Failover()
 		return nil, fmt.Errorf("搜索请求失败: %w", err)
 	}
 	defer resp.Body.Close()
@@ -272,9 +280,9 @@ func (p *Xb6vPlugin) searchImpl(client *http.Client, keyword string, ext map[str
 	// Location通常是类似 "result/?searchid=39616" 的格式，需要加上 /e/search/ 前缀
 	var resultURL string
 	if strings.HasPrefix(location, "result/") {
-		resultURL = p.currentBase + "/e/search/" + location
+		resultURL = baseURL + "/e/search/" + location
 	} else {
-		resultURL = p.currentBase + "/" + strings.TrimPrefix(location, "/")
+		resultURL = baseURL + "/" + strings.TrimPrefix(location, "/")
 	}
 	
 	if p.debugMode {
@@ -282,7 +290,7 @@ func (p *Xb6vPlugin) searchImpl(client *http.Client, keyword string, ext map[str
 	}
 	
 	// 第二步：获取搜索结果页面
-	resp2, err := p.doRequest(client, "GET", resultURL, "", p.currentBase)
+	resp2, err := p.doRequest(client, "GET", resultURL, "", baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("获取搜索结果失败: %w", err)
 	}
@@ -396,7 +404,7 @@ func (p *Xb6vPlugin) extractDetailURLs(doc *goquery.Document) []DetailPageInfo {
 		if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
 			fullURL = href
 		} else {
-			fullURL = p.currentBase + "/" + strings.TrimPrefix(href, "/")
+			fullURL = p.mirrors.Current() + "/" + strings.TrimPrefix(href, "/")
 		}
 		
 		// 去重检查
@@ -546,7 +554,7 @@ func (p *Xb6vPlugin) fetchMagnetLinksFromDetails(client *http.Client, detailPage
 	var wg sync.WaitGroup
 	
 	// 使用信号量控制并发数
-	semaphore := make(chan struct{}, MaxConcurrency)
+	semaphore := make(chan struct{}, p.MaxConcurrency(MaxConcurrency))
 	
 	for i, detailPage := range detailPages {
 		wg.Add(1)
@@ -589,7 +597,7 @@ func (p *Xb6vPlugin) fetchDetailPageMagnetLinks(client *http.Client, detailURL s
 	}
 	
 	// 请求详情页
-	resp, err := p.doRequest(client, "GET", detailURL, "", p.currentBase)
+	resp, err := p.doRequest(client, "GET", detailURL, "", p.mirrors.Current())
 	if err != nil {
 		if p.debugMode {
 			log.Printf("[Xb6v] 获取详情页失败: %v", err)
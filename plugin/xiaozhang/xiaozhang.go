@@ -1,6 +1,7 @@
 package xiaozhang
 
 import (
+	"context"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -76,6 +77,11 @@ func (p *XiaozhangPlugin) SearchWithResult(keyword string, ext map[string]interf
 	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
 }
 
+// SearchWithResultContext 执行搜索，ctx取消时中止HTTP请求
+func (p *XiaozhangPlugin) SearchWithResultContext(ctx context.Context, keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResultContext(ctx, keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
 // setRequestHeaders 设置请求头
 func (p *XiaozhangPlugin) setRequestHeaders(req *http.Request, referer string) {
 	req.Header.Set("User-Agent", UserAgent)
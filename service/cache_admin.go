@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util/cache"
+	"pansou/util/query"
+)
+
+// ErrCacheDisabled 未启用缓存时无法管理缓存
+var ErrCacheDisabled = errors.New("未启用缓存")
+
+// cacheTarget 按搜索参数确定的缓存键
+type cacheTarget struct {
+	keyword  string
+	channels []string
+	plugins  []string
+}
+
+// resolveCacheTarget 按搜索时的规则处理关键词、频道和插件，保证与搜索使用相同的缓存键
+func (s *SearchService) resolveCacheTarget(keyword string, channels []string, plugins []string) (cacheTarget, error) {
+	if !cacheInitialized || enhancedTwoLevelCache == nil {
+		return cacheTarget{}, ErrCacheDisabled
+	}
+	q := query.Parse(keyword)
+	if q.Keyword == "" {
+		return cacheTarget{}, ErrEmptyQueryKeyword
+	}
+	if len(channels) == 0 {
+		channels = config.GetDefaultChannels()
+	}
+	return cacheTarget{
+		keyword:  q.Keyword,
+		channels: channels,
+		plugins:  s.normalizePlugins("all", plugins),
+	}, nil
+}
+
+// entries 该搜索对应的TG和插件缓存状态，未启用插件时只有TG缓存
+func (t cacheTarget) entries() []model.CacheEntry {
+	entries := []model.CacheEntry{
+		inspectCacheKey("tg", cache.GenerateTGCacheKey(t.keyword, t.channels), t.keyword, t.channels, nil),
+	}
+	if config.AppConfig.AsyncPluginEnabled {
+		entries = append(entries, inspectCacheKey("plugin", cache.GeneratePluginCacheKey(t.keyword, t.plugins), t.keyword, nil, t.plugins))
+	}
+	return entries
+}
+
+// inspectCacheKey 查看缓存键在各层中的状态和缓存的结果数
+func inspectCacheKey(cacheType, key, keyword string, channels []string, plugins []string) model.CacheEntry {
+	entry := model.CacheEntry{
+		Type:     cacheType,
+		Key:      key,
+		Keyword:  keyword,
+		Channels: channels,
+		Plugins:  plugins,
+		Layers:   enhancedTwoLevelCache.Inspect(key),
+	}
+	entry.Cached = len(entry.Layers) > 0
+	if results, ok := peekCachedResults(key); ok {
+		entry.Results = len(results)
+	}
+	return entry
+}
+
+// peekCachedResults 读取缓存的搜索结果，不回填内存缓存
+func peekCachedResults(key string) ([]model.SearchResult, bool) {
+	data, ok := enhancedTwoLevelCache.Peek(key)
+	if !ok {
+		return nil, false
+	}
+	var results []model.SearchResult
+	if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err != nil {
+		return nil, false
+	}
+	return results, true
+}
+
+// InspectCache 查看关键词在指定频道和插件下的缓存状态
+// channels为空时使用默认频道，plugins为空时表示全部启用的插件，与搜索的默认值一致
+func (s *SearchService) InspectCache(keyword string, channels []string, plugins []string) ([]model.CacheEntry, error) {
+	target, err := s.resolveCacheTarget(keyword, channels, plugins)
+	if err != nil {
+		return nil, err
+	}
+	return target.entries(), nil
+}
+
+// InvalidateKeyword 删除关键词在指定频道和插件下的缓存，以及各插件内部缓存的该关键词结果
+// 缓存键是参数的哈希，其他频道或插件组合的缓存需要分别指定参数删除；
+// 包含被删除缓存中任一结果或链接的分页快照一并删除，使已发出的游标失效
+func (s *SearchService) InvalidateKeyword(keyword string, channels []string, plugins []string) (model.CacheInvalidation, error) {
+	target, err := s.resolveCacheTarget(keyword, channels, plugins)
+	if err != nil {
+		return model.CacheInvalidation{}, err
+	}
+
+	result := model.CacheInvalidation{Keys: []string{}}
+	resultIDs := make(map[string]bool)
+	linkURLs := make(map[string]bool)
+	for _, entry := range target.entries() {
+		if !entry.Cached {
+			continue
+		}
+		if cached, ok := peekCachedResults(entry.Key); ok {
+			for _, r := range cached {
+				resultIDs[r.UniqueID] = true
+				for _, link := range r.Links {
+					linkURLs[link.URL] = true
+				}
+			}
+		}
+		if err := enhancedTwoLevelCache.Delete(entry.Key); err != nil {
+			return result, fmt.Errorf("删除缓存%s失败: %w", entry.Key, err)
+		}
+		result.Keys = append(result.Keys, entry.Key)
+	}
+
+	if len(resultIDs) > 0 {
+		err := deletePageSnapshots(&result, func(snapshot model.SearchResponse) bool {
+			return snapshotContains(snapshot, func(r model.SearchResult) bool {
+				return resultIDs[r.UniqueID]
+			}, func(link model.MergedLink) bool {
+				return linkURLs[link.URL]
+			})
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(target.keyword))
+	result.PluginEntries = plugin.DeleteAsyncCache(func(_ string, keyword string) bool {
+		return strings.ToLower(strings.TrimSpace(keyword)) == normalized
+	})
+	return result, nil
+}
+
+// InvalidatePlugin 删除包含该插件结果的所有缓存（包括分页快照），以及插件内部的缓存
+// 只能遍历内存和本地磁盘中的键，只存在于远程缓存中的键会在过期后自然失效
+func (s *SearchService) InvalidatePlugin(name string) (model.CacheInvalidation, error) {
+	if !cacheInitialized || enhancedTwoLevelCache == nil {
+		return model.CacheInvalidation{}, ErrCacheDisabled
+	}
+	if _, ok := plugin.GetPluginByName(name); !ok {
+		return model.CacheInvalidation{}, fmt.Errorf("%w: %s", ErrUnknownPlugin, name)
+	}
+
+	result := model.CacheInvalidation{Keys: []string{}}
+	prefix := name + "-"
+	for _, key := range enhancedTwoLevelCache.Keys() {
+		if strings.HasPrefix(key, searchSnapshotPrefix) {
+			continue
+		}
+		results, ok := peekCachedResults(key)
+		if !ok {
+			continue
+		}
+		for _, r := range results {
+			if r.Channel == "" && strings.HasPrefix(r.UniqueID, prefix) {
+				if err := enhancedTwoLevelCache.Delete(key); err != nil {
+					return result, fmt.Errorf("删除缓存%s失败: %w", key, err)
+				}
+				result.Keys = append(result.Keys, key)
+				break
+			}
+		}
+	}
+
+	source := "plugin:" + name
+	err := deletePageSnapshots(&result, func(snapshot model.SearchResponse) bool {
+		return snapshotContains(snapshot, func(r model.SearchResult) bool {
+			return r.Channel == "" && strings.HasPrefix(r.UniqueID, prefix)
+		}, func(link model.MergedLink) bool {
+			return link.Source == source
+		})
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.PluginEntries = plugin.DeleteAsyncCache(func(pluginName string, _ string) bool {
+		return pluginName == name
+	})
+	return result, nil
+}
+
+// deletePageSnapshots 删除满足条件的分页快照，删除的键追加到result.Keys
+func deletePageSnapshots(result *model.CacheInvalidation, match func(model.SearchResponse) bool) error {
+	for _, key := range enhancedTwoLevelCache.Keys() {
+		if !strings.HasPrefix(key, searchSnapshotPrefix) {
+			continue
+		}
+		data, ok := enhancedTwoLevelCache.Peek(key)
+		if !ok {
+			continue
+		}
+		var snapshot model.SearchResponse
+		if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &snapshot); err != nil || !match(snapshot) {
+			continue
+		}
+		if err := enhancedTwoLevelCache.Delete(key); err != nil {
+			return fmt.Errorf("删除缓存%s失败: %w", key, err)
+		}
+		result.Keys = append(result.Keys, key)
+	}
+	return nil
+}
+
+// snapshotContains 分页快照中是否有满足条件的结果或合并链接
+// 按merged_by_type返回的快照不含results，需要同时检查合并链接
+func snapshotContains(snapshot model.SearchResponse, matchResult func(model.SearchResult) bool, matchLink func(model.MergedLink) bool) bool {
+	for _, r := range snapshot.Results {
+		if matchResult(r) {
+			return true
+		}
+	}
+	for _, links := range snapshot.MergedByType {
+		for _, link := range links {
+			if matchLink(link) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClearCache 清空所有层的缓存和插件内部的缓存
+func (s *SearchService) ClearCache() (model.CacheInvalidation, error) {
+	if !cacheInitialized || enhancedTwoLevelCache == nil {
+		return model.CacheInvalidation{}, ErrCacheDisabled
+	}
+	result := model.CacheInvalidation{Keys: []string{}}
+	if err := enhancedTwoLevelCache.Clear(); err != nil {
+		return result, fmt.Errorf("清空缓存失败: %w", err)
+	}
+	result.PluginEntries = plugin.DeleteAsyncCache(func(string, string) bool { return true })
+	return result, nil
+}
+
+// CacheStats 各层缓存的条目数和各分片的字节数
+func (s *SearchService) CacheStats() (model.CacheStats, error) {
+	if !cacheInitialized || enhancedTwoLevelCache == nil {
+		return model.CacheStats{}, ErrCacheDisabled
+	}
+	return model.CacheStats{
+		Layers:           enhancedTwoLevelCache.Stats(),
+		PluginAsyncItems: plugin.AsyncCacheSize(),
+	}, nil
+}
+
+// WarmCache 强制刷新并执行一次搜索，把最新结果写入缓存
+// 缓存为异步写入，插件在响应超时后仍在后台补全结果，完成后继续更新缓存
+func (s *SearchService) WarmCache(ctx context.Context, keyword string, channels []string, plugins []string) (model.CacheWarmResult, error) {
+	target, err := s.resolveCacheTarget(keyword, channels, plugins)
+	if err != nil {
+		return model.CacheWarmResult{}, err
+	}
+
+	sourceType := "all"
+	if !config.AppConfig.AsyncPluginEnabled {
+		sourceType = "tg"
+	}
+	started := time.Now()
+	response, err := s.Search(ctx, target.keyword, target.channels, 0, true, "merged_by_type", sourceType, target.plugins, nil, "", nil, false)
+	if err != nil {
+		return model.CacheWarmResult{}, err
+	}
+	return model.CacheWarmResult{
+		Keyword:   target.keyword,
+		Total:     response.Total,
+		ElapsedMs: time.Since(started).Milliseconds(),
+	}, nil
+}
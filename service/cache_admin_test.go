@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+// snapshotCursor 为响应生成分页快照并返回第一页的游标
+func snapshotCursor(t *testing.T, s *SearchService, response model.SearchResponse) string {
+	t.Helper()
+	page, err := s.PaginateResponse(response, 0, 1)
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("生成分页快照失败: %v", err)
+	}
+	return page.NextCursor
+}
+
+// 按插件删除缓存时，包含该插件结果的分页快照一并删除
+func TestInvalidatePluginDropsPageSnapshots(t *testing.T) {
+	cfg := testConfig()
+	cfg.CacheEnabled = true
+	purged := &stubPlugin{name: "purgeme"}
+	plugin.RegisterGlobalPlugin(purged)
+	s := setupTestService(t, cfg, purged)
+
+	withResults := snapshotCursor(t, s, model.SearchResponse{Total: 2, Results: stubResults("purgeme", 2)})
+	mergedOnly := snapshotCursor(t, s, model.SearchResponse{Total: 2, MergedByType: model.MergedLinks{"quark": {
+		{URL: "https://pan.quark.cn/s/1", Source: "plugin:purgeme"},
+		{URL: "https://pan.quark.cn/s/2", Source: "tg:channel"},
+	}}})
+	other := snapshotCursor(t, s, model.SearchResponse{Total: 2, Results: stubResults("other", 2)})
+
+	if _, err := s.InvalidatePlugin("purgeme"); err != nil {
+		t.Fatalf("删除插件缓存失败: %v", err)
+	}
+	for _, cursor := range []string{withResults, mergedOnly} {
+		if _, err := s.LoadResponsePage(cursor, 0); err != ErrInvalidCursor {
+			t.Fatalf("包含该插件结果的快照应被删除，实际: %v", err)
+		}
+	}
+	if _, err := s.LoadResponsePage(other, 0); err != nil {
+		t.Fatalf("其他插件的快照不应被删除: %v", err)
+	}
+}
+
+// 按关键词删除缓存时，包含被删除结果的分页快照一并删除
+func TestInvalidateKeywordDropsPageSnapshots(t *testing.T) {
+	cfg := testConfig()
+	cfg.CacheEnabled = true
+	s := setupTestService(t, cfg, &stubPlugin{name: "stub"})
+
+	target, err := s.resolveCacheTarget("三体", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := stubResults("stub", 2)
+	entries := target.entries()
+	storeSearchResults(entries[len(entries)-1].Key, cached, true)
+
+	purged := snapshotCursor(t, s, model.SearchResponse{Total: 2, Results: cached})
+	other := snapshotCursor(t, s, model.SearchResponse{Total: 2, Results: stubResults("other", 2)})
+
+	result, err := s.InvalidateKeyword("三体", nil, nil)
+	if err != nil {
+		t.Fatalf("删除关键词缓存失败: %v", err)
+	}
+	if len(result.Keys) != 2 {
+		t.Fatalf("应删除插件缓存和1个分页快照，实际: %v", result.Keys)
+	}
+	if _, err := s.LoadResponsePage(purged, 0); err != ErrInvalidCursor {
+		t.Fatalf("包含被删除结果的快照应被删除，实际: %v", err)
+	}
+	if _, err := s.LoadResponsePage(other, 0); err != nil {
+		t.Fatalf("其他快照不应被删除: %v", err)
+	}
+}
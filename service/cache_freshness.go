@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/util/cache"
+)
+
+// cacheFreshness 汇总一次搜索中TG和插件缓存的新鲜度
+// 所有方法允许nil接收者，不需要标记响应时调用方可以传nil
+type cacheFreshness struct {
+	mu    sync.Mutex
+	hit   bool
+	age   time.Duration
+	stale bool
+}
+
+// record 记录一次缓存命中，保留写入最早的缓存时长
+func (f *cacheFreshness) record(freshness cache.Freshness) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hit = true
+	if freshness.Age > f.age {
+		f.age = freshness.Age
+	}
+	f.stale = f.stale || freshness.Stale
+}
+
+// apply 在响应中标记缓存时长和是否过期，没有命中缓存时不修改
+func (f *cacheFreshness) apply(response *model.SearchResponse) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.hit {
+		return
+	}
+	response.CacheAge = int64(f.age.Seconds())
+	response.Stale = f.stale
+}
+
+// cacheStaleAfter 缓存超过该时长后视为过期，未设置CACHE_STALE_TTL时返回0，不检查新鲜度
+func cacheStaleAfter() time.Duration {
+	if config.CacheStaleTTL() <= 0 {
+		return 0
+	}
+	return config.CacheSoftTTL()
+}
+
+// refreshTGCache 返回在后台重新搜索TG频道并更新缓存的函数，用于刷新过期缓存
+func (s *SearchService) refreshTGCache(keyword string, channels []string) func() {
+	return func() {
+		cold := s.coldSearches.ticket()
+		defer cold.release()
+		_, err := s.searchTG(context.Background(), keyword, channels, true, cold, nil, nil)
+		observeRevalidation("tg", keyword, err)
+	}
+}
+
+// refreshPluginCache 返回在后台重新搜索插件并更新缓存的函数，用于刷新过期缓存
+func (s *SearchService) refreshPluginCache(keyword string, plugins []string, concurrency int, ext map[string]interface{}) func() {
+	return func() {
+		cold := s.coldSearches.ticket()
+		defer cold.release()
+		_, err := s.searchPlugins(context.Background(), keyword, plugins, true, concurrency, ext, cold, nil, nil)
+		observeRevalidation("plugin", keyword, err)
+	}
+}
+
+// observeRevalidation 记录过期缓存的后台刷新结果，冷搜索名额已满时放弃本次刷新，之后的请求会再次触发
+func observeRevalidation(cacheType, keyword string, err error) {
+	switch {
+	case err == nil:
+		revalidateCounter.Inc(cacheType, "ok")
+	case errors.Is(err, ErrTooManyColdSearches):
+		revalidateCounter.Inc(cacheType, "rejected")
+	default:
+		revalidateCounter.Inc(cacheType, "error")
+		fmt.Printf("[缓存刷新] %s搜索失败: %s | 错误: %v\n", cacheType, keyword, err)
+	}
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/util/cache"
+	"pansou/util/query"
+)
+
+const (
+	// trendingMinScore 衰减后的搜索次数低于该值的关键词不预热，即至少最近搜索过两次，避免预热只搜索过一次的关键词
+	trendingMinScore = 1.5
+	// trendingDropScore 衰减后的搜索次数低于该值时不再记录
+	trendingDropScore = 0.05
+	// trendingEntriesPerTop 每个预热名额最多记录的关键词数，超出后丢弃搜索次数最少的
+	trendingEntriesPerTop = 50
+)
+
+// trendingEntry 一组搜索参数及其按时间衰减的搜索次数
+type trendingEntry struct {
+	keyword    string
+	channels   []string
+	sourceType string
+	plugins    []string
+	score      float64   // 截至updated的搜索次数
+	updated    time.Time // score最后更新的时间
+}
+
+// scoreAt 衰减到now时的搜索次数
+func (e *trendingEntry) scoreAt(now time.Time, halfLife time.Duration) float64 {
+	elapsed := now.Sub(e.updated)
+	if elapsed <= 0 {
+		return e.score
+	}
+	return e.score * math.Exp2(-float64(elapsed)/float64(halfLife))
+}
+
+// trendingTracker 记录各组搜索参数的搜索次数，每经过一个半衰期权重减半
+type trendingTracker struct {
+	mu         sync.Mutex
+	halfLife   time.Duration
+	maxEntries int
+	entries    map[string]*trendingEntry // 键为GenerateCacheKey生成的缓存键
+}
+
+// newTrendingTracker 创建热门关键词记录，未启用预热时返回nil
+func newTrendingTracker() *trendingTracker {
+	if !config.AppConfig.CacheWarmerEnabled {
+		return nil
+	}
+	return &trendingTracker{
+		halfLife:   config.AppConfig.CacheWarmerHalfLife,
+		maxEntries: config.AppConfig.CacheWarmerTopN * trendingEntriesPerTop,
+		entries:    make(map[string]*trendingEntry),
+	}
+}
+
+// record 记录一次搜索，参数需已规范化
+func (t *trendingTracker) record(keyword string, channels []string, sourceType string, plugins []string) {
+	if t == nil {
+		return
+	}
+	key := cache.GenerateCacheKey(keyword, channels, sourceType, plugins)
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry, ok := t.entries[key]; ok {
+		entry.score = entry.scoreAt(now, t.halfLife) + 1
+		entry.updated = now
+		return
+	}
+	t.entries[key] = &trendingEntry{
+		keyword:    keyword,
+		channels:   channels,
+		sourceType: sourceType,
+		plugins:    plugins,
+		score:      1,
+		updated:    now,
+	}
+	if len(t.entries) > t.maxEntries {
+		t.pruneLocked(now)
+	}
+}
+
+// pruneLocked 丢弃衰减后搜索次数过低的关键词，仍然超出上限时保留搜索次数最多的3/4
+func (t *trendingTracker) pruneLocked(now time.Time) {
+	for key, entry := range t.entries {
+		if entry.scoreAt(now, t.halfLife) < trendingDropScore {
+			delete(t.entries, key)
+		}
+	}
+	if len(t.entries) <= t.maxEntries {
+		return
+	}
+
+	keys := make([]string, 0, len(t.entries))
+	for key := range t.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return t.entries[keys[i]].scoreAt(now, t.halfLife) > t.entries[keys[j]].scoreAt(now, t.halfLife)
+	})
+	for _, key := range keys[t.maxEntries*3/4:] {
+		delete(t.entries, key)
+	}
+}
+
+// top 衰减后搜索次数最多的前n组搜索参数，只包括达到预热门槛的
+func (t *trendingTracker) top(n int) []model.TrendingKeyword {
+	if t == nil {
+		return nil
+	}
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked(now)
+	result := make([]model.TrendingKeyword, 0, len(t.entries))
+	for _, entry := range t.entries {
+		score := entry.scoreAt(now, t.halfLife)
+		if score < trendingMinScore {
+			continue
+		}
+		result = append(result, model.TrendingKeyword{
+			Keyword:    entry.keyword,
+			Channels:   entry.channels,
+			SourceType: entry.sourceType,
+			Plugins:    entry.plugins,
+			Score:      math.Round(score*100) / 100,
+			LastSearch: entry.updated,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Keyword < result[j].Keyword
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// recordTrending 按搜索时的规则规范化参数后记录一次搜索，预热搜索不经过这里
+func (s *SearchService) recordTrending(keyword string, channels []string, sourceType string, plugins []string) {
+	if s.trending == nil {
+		return
+	}
+	q := query.Parse(keyword)
+	if q.Keyword == "" {
+		return
+	}
+	if sourceType == "" {
+		sourceType = "all"
+	}
+	if sourceType == "plugin" {
+		channels = nil
+	}
+	s.trending.record(q.Keyword, channels, sourceType, s.normalizePlugins(sourceType, plugins))
+}
+
+// TrendingKeywords 当前的热门关键词，未启用预热时返回空列表
+func (s *SearchService) TrendingKeywords() []model.TrendingKeyword {
+	trending := s.trending.top(config.AppConfig.CacheWarmerTopN)
+	if trending == nil {
+		trending = []model.TrendingKeyword{}
+	}
+	return trending
+}
+
+// StartCacheWarmer 启动热门关键词缓存预热，未启用预热或缓存时不启动
+// 每隔CACHE_WARMER_INTERVAL检查一次热门关键词，缓存不存在或将在两个间隔内过期时强制刷新搜索
+func (s *SearchService) StartCacheWarmer() {
+	if s.trending == nil || !cacheInitialized || enhancedTwoLevelCache == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.stopWarmer = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(config.AppConfig.CacheWarmerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.warmTrending(ctx)
+			}
+		}
+	}()
+	fmt.Printf("热门关键词缓存预热已启用：前%d个关键词，每%v检查一次，并发%d\n",
+		config.AppConfig.CacheWarmerTopN, config.AppConfig.CacheWarmerInterval, config.AppConfig.CacheWarmerConcurrency)
+}
+
+// StopCacheWarmer 停止缓存预热，等待进行中的预热搜索结束
+func (s *SearchService) StopCacheWarmer() {
+	if s.stopWarmer != nil {
+		s.stopWarmer()
+		s.stopWarmer = nil
+	}
+}
+
+// warmTrending 预热一轮热门关键词，所有预热搜索结束后返回
+func (s *SearchService) warmTrending(ctx context.Context) {
+	refreshBefore := 2 * config.AppConfig.CacheWarmerInterval
+	semaphore := make(chan struct{}, config.AppConfig.CacheWarmerConcurrency)
+	var wg sync.WaitGroup
+	var warmed, failed int
+	var mu sync.Mutex
+	started := time.Now()
+
+	for _, item := range s.trending.top(config.AppConfig.CacheWarmerTopN) {
+		if !needsWarming(item, refreshBefore) {
+			continue
+		}
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+
+		wg.Add(1)
+		go func(item model.TrendingKeyword) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			_, err := s.Search(ctx, item.Keyword, item.Channels, 0, true, "merged_by_type", item.SourceType, item.Plugins, nil, "", nil, false)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					failed++
+					fmt.Printf("[缓存预热] 搜索失败: %s | 错误: %v\n", item.Keyword, err)
+				}
+				warmCounter.Inc("error")
+				return
+			}
+			warmed++
+			warmCounter.Inc("ok")
+		}(item)
+	}
+	wg.Wait()
+
+	if warmed > 0 || failed > 0 {
+		fmt.Printf("[缓存预热] 完成: 成功%d个，失败%d个，耗时%v\n", warmed, failed, time.Since(started).Round(time.Millisecond))
+	}
+}
+
+// needsWarming 该搜索的TG或插件缓存不存在，或将在refreshBefore内超过有效期
+// 缓存保存到CACHE_TTL+CACHE_STALE_TTL，超过CACHE_TTL即视为过期，因此从剩余保存时长中扣除CACHE_STALE_TTL
+func needsWarming(item model.TrendingKeyword, refreshBefore time.Duration) bool {
+	refreshBefore += config.CacheHardTTL() - config.CacheSoftTTL()
+	var keys []string
+	if item.SourceType == "all" || item.SourceType == "tg" {
+		keys = append(keys, cache.GenerateTGCacheKey(item.Keyword, item.Channels))
+	}
+	if (item.SourceType == "all" || item.SourceType == "plugin") && config.AppConfig.AsyncPluginEnabled {
+		keys = append(keys, cache.GeneratePluginCacheKey(item.Keyword, item.Plugins))
+	}
+
+	for _, key := range keys {
+		// 任意一层命中即可直接返回结果，以剩余有效期最长的一层为准
+		remaining := int64(-1)
+		for _, layer := range enhancedTwoLevelCache.Inspect(key) {
+			if layer.TTLSeconds < 0 {
+				remaining = math.MaxInt64
+				break
+			}
+			if layer.TTLSeconds > remaining {
+				remaining = layer.TTLSeconds
+			}
+		}
+		if remaining < int64(refreshBefore.Seconds()) {
+			return true
+		}
+	}
+	return false
+}
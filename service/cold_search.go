@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrTooManyColdSearches 同时进行的冷搜索已达上限
+var ErrTooManyColdSearches = errors.New("当前实时搜索请求过多，请稍后重试")
+
+// coldSearchesInFlight 正在进行的冷搜索数量（不论是否设置上限）
+var coldSearchesInFlight int64
+
+// coldSearchLimiter 冷搜索（缓存未命中或强制刷新，需要实际请求TG频道和插件）并发上限
+// 命中缓存的搜索不受限制；达到上限时立即拒绝而不是排队，避免请求堆积
+type coldSearchLimiter struct {
+	slots chan struct{}
+}
+
+// newColdSearchLimiter 创建冷搜索限制器，max<=0时返回nil表示不限制
+func newColdSearchLimiter(max int) *coldSearchLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &coldSearchLimiter{slots: make(chan struct{}, max)}
+}
+
+// ticket 为一次搜索创建凭证，凭证在第一次缓存未命中时才占用名额
+// l为nil（不限制）时同样返回凭证，用于统计正在进行的冷搜索
+func (l *coldSearchLimiter) ticket() *coldSearchTicket {
+	return &coldSearchTicket{limiter: l}
+}
+
+// coldSearchTicket 单次搜索的冷搜索凭证
+// 同一次搜索中TG和插件可能分别未命中缓存，只占用一个名额
+type coldSearchTicket struct {
+	limiter  *coldSearchLimiter
+	once     sync.Once
+	acquired atomic.Bool
+	err      error
+}
+
+// acquire 占用名额，已满时返回ErrTooManyColdSearches；重复调用返回第一次的结果
+func (t *coldSearchTicket) acquire() error {
+	if t == nil {
+		return nil
+	}
+	t.once.Do(func() {
+		if t.limiter != nil {
+			select {
+			case t.limiter.slots <- struct{}{}:
+			default:
+				t.err = ErrTooManyColdSearches
+				return
+			}
+		}
+		t.acquired.Store(true)
+		atomic.AddInt64(&coldSearchesInFlight, 1)
+	})
+	return t.err
+}
+
+// release 释放名额，需在本次搜索的所有数据源完成后调用
+func (t *coldSearchTicket) release() {
+	if t == nil || !t.acquired.Load() {
+		return
+	}
+	atomic.AddInt64(&coldSearchesInFlight, -1)
+	if t.limiter != nil {
+		<-t.limiter.slots
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TG和插件同时未命中缓存的一次搜索只占用一个冷搜索名额
+func TestColdSearchUsesOneSlotPerSearch(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxColdSearches = 1
+	release := make(chan struct{})
+	p := &stubPlugin{name: "stub", results: stubResults("stub", 1), started: make(chan struct{}, 1), block: release}
+	s := setupTestService(t, cfg, p)
+
+	tgStarted := make(chan struct{}, 1)
+	stubTelegram(t, func(*http.Request) {
+		tgStarted <- struct{}{}
+		<-release
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Search(context.Background(), "冷搜索", []string{"channel"}, 0, true, "results", "all", nil, nil, "", nil, false)
+		done <- err
+	}()
+
+	// TG和插件都在搜索时，只占用一个名额
+	for _, started := range []chan struct{}{tgStarted, p.started} {
+		select {
+		case <-started:
+		case err := <-done:
+			t.Fatalf("搜索提前结束: %v", err)
+		case <-time.After(time.Second):
+			t.Fatal("等待搜索开始超时")
+		}
+	}
+	if inFlight := atomic.LoadInt64(&coldSearchesInFlight); inFlight != 1 {
+		t.Fatalf("正在进行的冷搜索数为%d，应为1", inFlight)
+	}
+
+	// 名额已满时其他冷搜索被拒绝
+	if _, err := s.Search(context.Background(), "其他关键词", nil, 0, true, "results", "plugin", nil, nil, "", nil, false); err != ErrTooManyColdSearches {
+		t.Fatalf("名额已满时应返回ErrTooManyColdSearches，实际: %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	if inFlight := atomic.LoadInt64(&coldSearchesInFlight); inFlight != 0 {
+		t.Fatalf("搜索完成后冷搜索数为%d，应为0", inFlight)
+	}
+}
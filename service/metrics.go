@@ -0,0 +1,82 @@
+package service
+
+import (
+	"sync/atomic"
+	"time"
+
+	"pansou/util/metrics"
+)
+
+// 搜索指标
+var searchDuration = metrics.NewHistogramVec("pansou_search_duration_seconds",
+	"搜索请求耗时，res=stream为流式搜索及异步任务", metrics.DefBuckets, "src", "res", "status")
+
+// 热门关键词缓存预热的搜索次数
+var warmCounter = metrics.NewCounterVec("pansou_cache_warm_searches_total", "热门关键词缓存预热的搜索次数", "status")
+
+// 与其他相同的并发请求共享一次搜索的请求数
+var sharedSearchCounter = metrics.NewCounterVec("pansou_search_shared_total",
+	"与其他相同的并发请求共享同一次搜索的请求数（包括发起搜索的请求），type为tg、plugin或channel", "type")
+
+// 过期缓存的后台刷新次数
+var revalidateCounter = metrics.NewCounterVec("pansou_cache_revalidations_total",
+	"返回过期缓存后的后台刷新次数，status为rejected表示冷搜索名额已满未刷新", "type", "status")
+
+func init() {
+	metrics.NewGaugeFunc("pansou_cold_searches_in_flight", "正在进行的冷搜索（缓存未命中或强制刷新）数量", func() float64 {
+		return float64(atomic.LoadInt64(&coldSearchesInFlight))
+	})
+	metrics.NewGaugeVecFunc("pansou_cache_size_bytes", "两级缓存各层当前占用的字节数", []string{"layer"},
+		func(emit func(float64, ...string)) {
+			if enhancedTwoLevelCache == nil {
+				return
+			}
+			emit(float64(enhancedTwoLevelCache.MemorySize()), "memory")
+			emit(float64(enhancedTwoLevelCache.DiskSize()), "disk")
+		})
+	metrics.NewGaugeFunc("pansou_cache_write_queue_size", "延迟批量写入队列中等待写入的操作数", func() float64 {
+		if globalCacheWriteManager == nil {
+			return 0
+		}
+		return float64(globalCacheWriteManager.GetWriteManagerStats().CurrentQueueSize)
+	})
+	metrics.NewGaugeFunc("pansou_cache_write_buffers", "全局缓冲区中活跃的缓冲区数量", func() float64 {
+		if globalCacheWriteManager == nil {
+			return 0
+		}
+		return float64(globalCacheWriteManager.GetGlobalBufferStats().ActiveBuffers)
+	})
+	metrics.NewCounterVecFunc("pansou_cache_writes_total", "磁盘缓存写入次数，type为batch（批量刷新）、immediate（立即写入）或failed（写入失败）", []string{"type"},
+		func(emit func(float64, ...string)) {
+			if globalCacheWriteManager == nil {
+				return
+			}
+			stats := globalCacheWriteManager.GetWriteManagerStats()
+			emit(float64(stats.BatchWrites), "batch")
+			emit(float64(stats.ImmediateWrites), "immediate")
+			emit(float64(stats.FailedWrites), "failed")
+		})
+}
+
+// observeSearch 记录搜索耗时，结果类型不在已知范围内时记为other，避免标签取值无限增长
+func observeSearch(sourceType, resultType string, started time.Time, err error) {
+	switch sourceType {
+	case "":
+		sourceType = "all"
+	case "all", "tg", "plugin":
+	default:
+		sourceType = "other"
+	}
+	switch resultType {
+	case "", "merge":
+		resultType = "merged_by_type"
+	case "all", "results", "merged_by_type", "stream":
+	default:
+		resultType = "other"
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	searchDuration.Observe(time.Since(started).Seconds(), sourceType, resultType, status)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"sync"
+
+	"pansou/model"
+)
+
+// pluginResultUpdate 插件后台完成时推送的结果
+type pluginResultUpdate struct {
+	Plugin  string
+	Results []model.SearchResult
+	IsFinal bool
+}
+
+// resultNotifier 插件后台结果通知器
+// 异步插件在响应超时后继续在后台搜索，完成时通过主缓存更新函数回写结果，
+// 通知器在这一回写点把结果转发给正在等待的订阅者
+type resultNotifier struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[chan pluginResultUpdate]struct{}
+}
+
+// 全局通知器实例
+var globalResultNotifier = &resultNotifier{
+	subscribers: make(map[string]map[chan pluginResultUpdate]struct{}),
+}
+
+// notifierKey 生成订阅键：主缓存键+插件名
+func notifierKey(cacheKey, pluginName string) string {
+	return cacheKey + "|" + pluginName
+}
+
+// subscribe 订阅指定缓存键下某个插件的后台结果，返回接收通道和取消函数
+func (n *resultNotifier) subscribe(cacheKey, pluginName string) (<-chan pluginResultUpdate, func()) {
+	key := notifierKey(cacheKey, pluginName)
+	ch := make(chan pluginResultUpdate, 4)
+
+	n.mutex.Lock()
+	if n.subscribers[key] == nil {
+		n.subscribers[key] = make(map[chan pluginResultUpdate]struct{})
+	}
+	n.subscribers[key][ch] = struct{}{}
+	n.mutex.Unlock()
+
+	cancel := func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		if subs, ok := n.subscribers[key]; ok {
+			delete(subs, ch)
+			if len(subs) == 0 {
+				delete(n.subscribers, key)
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// publish 向订阅者推送结果，订阅者处理不及时则丢弃，不阻塞缓存更新
+func (n *resultNotifier) publish(cacheKey, pluginName string, results []model.SearchResult, isFinal bool) {
+	key := notifierKey(cacheKey, pluginName)
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for ch := range n.subscribers[key] {
+		select {
+		case ch <- pluginResultUpdate{Plugin: pluginName, Results: results, IsFinal: isFinal}:
+		default:
+		}
+	}
+}
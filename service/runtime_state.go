@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"pansou/config"
+	"pansou/util/cache"
+	jsonutil "pansou/util/json"
+)
+
+// 运行时设置的参数错误
+var (
+	ErrPluginsDisabled = errors.New("未启用异步插件，无法修改插件列表")
+	ErrUnknownPlugin   = errors.New("未知的插件")
+	ErrEmptyChannels   = errors.New("频道列表不能为空")
+)
+
+// RuntimeState 通过管理接口修改的运行时设置，保存到STATE_FILE并在启动时恢复
+type RuntimeState struct {
+	Plugins  []string `json:"plugins,omitempty"`  // 启用的插件，为空表示沿用ENABLED_PLUGINS
+	Channels []string `json:"channels,omitempty"` // 默认搜索频道，为空表示沿用CHANNELS
+}
+
+// 保护运行时状态的修改和保存
+var runtimeStateLock sync.Mutex
+
+// SetEnabledPlugins 替换启用的插件列表
+// 未启用插件的搜索使用新的缓存键，原插件集合的缓存不再命中
+func (s *SearchService) SetEnabledPlugins(names []string) error {
+	if !config.AppConfig.AsyncPluginEnabled || s.pluginManager == nil {
+		return ErrPluginsDisabled
+	}
+	names = normalizeNames(names)
+
+	runtimeStateLock.Lock()
+	defer runtimeStateLock.Unlock()
+
+	if err := s.applyPlugins(names); err != nil {
+		return err
+	}
+	return s.saveRuntimeState()
+}
+
+// SetDefaultChannels 替换默认搜索频道
+// TG搜索的缓存键包含频道列表，修改后未指定频道的搜索自然使用新的缓存键
+func (s *SearchService) SetDefaultChannels(channels []string) error {
+	channels = normalizeNames(channels)
+	if len(channels) == 0 {
+		return ErrEmptyChannels
+	}
+
+	runtimeStateLock.Lock()
+	defer runtimeStateLock.Unlock()
+
+	config.SetDefaultChannels(channels)
+	config.UpdateDefaultConcurrency(s.activePluginCount())
+	return s.saveRuntimeState()
+}
+
+// LoadRuntimeState 从STATE_FILE恢复上次通过管理接口保存的设置，文件不存在时不做修改
+func (s *SearchService) LoadRuntimeState() error {
+	path := config.AppConfig.StateFile
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取状态文件失败: %w", err)
+	}
+
+	var state RuntimeState
+	if err := jsonutil.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析状态文件失败: %w", err)
+	}
+
+	runtimeStateLock.Lock()
+	defer runtimeStateLock.Unlock()
+
+	if len(state.Channels) > 0 {
+		config.SetDefaultChannels(normalizeNames(state.Channels))
+	}
+	if state.Plugins != nil && config.AppConfig.AsyncPluginEnabled && s.pluginManager != nil {
+		if err := s.applyPlugins(normalizeNames(state.Plugins)); err != nil {
+			return err
+		}
+	}
+	config.UpdateDefaultConcurrency(s.activePluginCount())
+	return nil
+}
+
+// ApplyConfigReload 配置文件重新加载后应用插件和频道的修改，用作config.OnReload的回调
+func (s *SearchService) ApplyConfigReload(changed map[string]bool) {
+	if changed["EnabledPlugins"] && config.AppConfig.AsyncPluginEnabled && s.pluginManager != nil {
+		if err := s.SetEnabledPlugins(config.GetEnabledPlugins()); err != nil {
+			fmt.Printf("应用配置文件中的插件列表失败: %v\n", err)
+		}
+	}
+	if changed["DefaultChannels"] {
+		if err := s.SetDefaultChannels(config.GetDefaultChannels()); err != nil {
+			fmt.Printf("应用配置文件中的频道列表失败: %v\n", err)
+		}
+	}
+	config.UpdateDefaultConcurrency(s.activePluginCount())
+}
+
+// activePluginCount 当前参与搜索的插件数量，未启用插件时为0
+func (s *SearchService) activePluginCount() int {
+	if !config.AppConfig.AsyncPluginEnabled || s.pluginManager == nil {
+		return 0
+	}
+	return len(s.pluginManager.GetPlugins())
+}
+
+// applyPlugins 切换插件列表并重新注入缓存，调用方需持有runtimeStateLock
+func (s *SearchService) applyPlugins(names []string) error {
+	if unknown := s.pluginManager.SetEnabledPlugins(names); len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownPlugin, strings.Join(unknown, ", "))
+	}
+
+	// 新启用的插件可能还没有注入主缓存
+	injectMainCacheToAsyncPlugins(s.pluginManager, enhancedTwoLevelCache)
+	cache.SetDefaultPluginSet(s.pluginManager.PluginNames())
+	config.UpdateDefaultConcurrency(len(names))
+	return nil
+}
+
+// saveRuntimeState 保存当前设置到STATE_FILE，未配置时不保存，调用方需持有runtimeStateLock
+func (s *SearchService) saveRuntimeState() error {
+	path := config.AppConfig.StateFile
+	if path == "" {
+		return nil
+	}
+
+	state := RuntimeState{Channels: config.GetDefaultChannels()}
+	if config.AppConfig.AsyncPluginEnabled && s.pluginManager != nil {
+		state.Plugins = s.pluginManager.PluginNames()
+	}
+	data, err := jsonutil.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建状态文件目录失败: %w", err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存状态文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("保存状态文件失败: %w", err)
+	}
+	return nil
+}
+
+// normalizeNames 去除首尾空白、空项和重复项，保持原有顺序
+func normalizeNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+// errSourceTimeout 数据源未在插件超时时间内返回
+var errSourceTimeout = errors.New("未在超时时间内完成")
+
+// sourceDiagnostics 收集一次搜索中各数据源的诊断信息，debug模式下为每个请求创建，合并的搜索另外使用一个共享的收集器
+// 所有方法允许nil接收者，未开启debug时调用方无需判断
+type sourceDiagnostics struct {
+	mu      sync.Mutex
+	entries map[string]*model.SourceDiagnostic
+	order   []string
+}
+
+// newSourceDiagnostics 创建诊断信息收集器
+func newSourceDiagnostics() *sourceDiagnostics {
+	return &sourceDiagnostics{
+		entries: make(map[string]*model.SourceDiagnostic),
+	}
+}
+
+// record 记录单个数据源的搜索情况
+func (d *sourceDiagnostics) record(source string, rawCount int, cacheStatus string, elapsed time.Duration, err error, isFinal bool) {
+	if d == nil {
+		return
+	}
+
+	entry := &model.SourceDiagnostic{
+		Source:    source,
+		RawCount:  rawCount,
+		Cache:     cacheStatus,
+		ElapsedMs: elapsed.Milliseconds(),
+		IsFinal:   isFinal,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exists := d.entries[source]; !exists {
+		d.order = append(d.order, source)
+	}
+	d.entries[source] = entry
+}
+
+// recordCached 按来源拆分主缓存中的结果并记录为缓存命中，stale为true时记录为过期缓存
+// sources为本次请求涉及的全部来源，缓存中没有结果的来源记为0条
+func (d *sourceDiagnostics) recordCached(sources []string, results []model.SearchResult, stale bool) {
+	if d == nil {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[getResultSource(result)]++
+	}
+	status := model.SourceCacheHit
+	if stale {
+		status = model.SourceCacheStale
+	}
+	for _, source := range sources {
+		d.record(source, counts[source], status, 0, nil, true)
+	}
+}
+
+// merge 合并另一个收集器记录的诊断信息，用于共享同一次搜索结果的请求
+func (d *sourceDiagnostics) merge(other *sourceDiagnostics) {
+	if d == nil || other == nil {
+		return
+	}
+
+	other.mu.Lock()
+	entries := make([]model.SourceDiagnostic, 0, len(other.order))
+	for _, source := range other.order {
+		entries = append(entries, *other.entries[source])
+	}
+	other.mu.Unlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range entries {
+		entry := entries[i]
+		if _, exists := d.entries[entry.Source]; !exists {
+			d.order = append(d.order, entry.Source)
+		}
+		d.entries[entry.Source] = &entry
+	}
+}
+
+// markUnfinished 将未能记录结果的来源标记为超时（任务被工作池超时丢弃）
+func (d *sourceDiagnostics) markUnfinished(sources []string, elapsed time.Duration) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	missing := make([]string, 0)
+	for _, source := range sources {
+		if _, exists := d.entries[source]; !exists {
+			missing = append(missing, source)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, source := range missing {
+		d.record(source, 0, model.SourceCacheMiss, elapsed, errSourceTimeout, false)
+	}
+}
+
+// build 结合最终响应统计各来源过滤后的数量，返回按记录顺序排列的诊断列表
+func (d *sourceDiagnostics) build(results []model.SearchResult, mergedLinks model.MergedLinks) []model.SourceDiagnostic {
+	if d == nil {
+		return nil
+	}
+
+	resultCounts := make(map[string]int)
+	for _, result := range results {
+		resultCounts[getResultSource(result)]++
+	}
+	mergedCounts := make(map[string]int)
+	for _, links := range mergedLinks {
+		for _, link := range links {
+			mergedCounts[link.Source]++
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	diagnostics := make([]model.SourceDiagnostic, 0, len(d.order))
+	for _, source := range d.order {
+		entry := *d.entries[source]
+		entry.ResultCount = resultCounts[source]
+		entry.MergedCount = mergedCounts[source]
+		diagnostics = append(diagnostics, entry)
+	}
+	return diagnostics
+}
+
+// pluginCacheStatus 根据插件返回结果推断缓存状态
+// 结果时间戳早于本次调用说明来自插件缓存，非最终结果表示缓存已过期、后台刷新中
+func pluginCacheStatus(result model.PluginSearchResult, started time.Time) string {
+	if result.Timestamp.IsZero() || !result.Timestamp.Before(started) {
+		return model.SourceCacheMiss
+	}
+	if result.IsFinal {
+		return model.SourceCacheHit
+	}
+	return model.SourceCacheStale
+}
+
+// searchPluginWithDiagnostics 执行单个插件搜索并记录诊断信息，返回值与searchPlugins中的任务一致
+func searchPluginWithDiagnostics(ctx context.Context, p plugin.AsyncSearchPlugin, keyword string, ext map[string]interface{}, diag *sourceDiagnostics) interface{} {
+	source := "plugin:" + p.Name()
+	started := time.Now()
+
+	result, err := plugin.SearchWithContext(ctx, p, keyword, ext)
+	cacheStatus := pluginCacheStatus(result, started)
+	if _, ok := p.(resultSearcher); !ok {
+		// 不支持IsFinal标记的插件无法区分缓存状态，按实际搜索记录
+		cacheStatus = model.SourceCacheMiss
+	}
+	diag.record(source, len(result.Results), cacheStatus, time.Since(started), err, err == nil && result.IsFinal)
+	if err != nil {
+		return nil
+	}
+	return result.Results
+}
+
+// tgSources 生成TG频道的来源标识列表
+func tgSources(channels []string) []string {
+	sources := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		sources = append(sources, "tg:"+channel)
+	}
+	return sources
+}
+
+// pluginSources 生成插件的来源标识列表
+func pluginSources(plugins []plugin.AsyncSearchPlugin) []string {
+	sources := make([]string, 0, len(plugins))
+	for _, p := range plugins {
+		sources = append(sources, "plugin:"+p.Name())
+	}
+	return sources
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"pansou/model"
+	"pansou/util/singleflight"
+)
+
+// searchFlight 合并缓存键相同的并发搜索：缓存尚未写入时同时到达的相同请求只发起一次搜索，
+// 其余请求等待并共享其结果（包括插件超时转入后台时的部分结果）
+type searchFlight struct {
+	name  string // 监控指标中的type
+	group singleflight.Group[flightResult]
+}
+
+// flightResult 一次合并搜索的结果和诊断信息
+type flightResult struct {
+	results []model.SearchResult
+	diag    *sourceDiagnostics
+}
+
+var (
+	// tgFlight 合并TG搜索，键为TG缓存键
+	tgFlight = &searchFlight{name: "tg"}
+	// pluginFlight 合并插件搜索，键为插件缓存键
+	pluginFlight = &searchFlight{name: "plugin"}
+	// channelFlight 合并单个频道的搜索，频道组合不同的搜索以及流式搜索也可以共享
+	channelFlight singleflight.Group[[]model.SearchResult]
+)
+
+// do 执行或加入键相同的搜索，fn使用独立的诊断信息收集器，完成后合并到每个请求自己的diag
+// 搜索在所有等待的请求都取消后才取消，单个请求取消时立即返回ctx.Err()
+func (f *searchFlight) do(ctx context.Context, key string, diag *sourceDiagnostics, fn func(ctx context.Context, diag *sourceDiagnostics) ([]model.SearchResult, error)) ([]model.SearchResult, error) {
+	result, err, shared := f.group.Do(ctx, key, func(ctx context.Context) (flightResult, error) {
+		d := newSourceDiagnostics()
+		results, err := fn(ctx, d)
+		return flightResult{results: results, diag: d}, err
+	})
+	if shared {
+		sharedSearchCounter.Inc(f.name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	diag.merge(result.diag)
+	return result.results, nil
+}
+
+// searchChannel 搜索单个频道，相同频道和关键词的并发请求合并为一次
+func (s *SearchService) searchChannel(ctx context.Context, keyword string, channel string) ([]model.SearchResult, error) {
+	key := channel + ":" + strings.ToLower(strings.TrimSpace(keyword))
+	results, err, shared := channelFlight.Do(ctx, key, func(ctx context.Context) ([]model.SearchResult, error) {
+		return s.fetchChannel(ctx, keyword, channel)
+	})
+	if shared {
+		sharedSearchCounter.Inc("channel")
+	}
+	return results, err
+}
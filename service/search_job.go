@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/util/query"
+)
+
+// searchJobEntry 任务内部状态，通过mu保护
+type searchJobEntry struct {
+	mu          sync.Mutex
+	job         model.SearchJob
+	sourceIndex map[string]int // 来源在job.Sources中的位置
+}
+
+// SearchJobManager 异步搜索任务管理器
+// 任务在后台通过SearchStream执行，客户端轮询获取当前合并快照及各来源的完成状态
+type SearchJobManager struct {
+	searchService *SearchService
+	ttl           time.Duration
+	maxCount      int
+
+	mu   sync.RWMutex
+	jobs map[string]*searchJobEntry
+}
+
+// NewSearchJobManager 创建异步搜索任务管理器，并启动过期任务清理
+func NewSearchJobManager(searchService *SearchService) *SearchJobManager {
+	m := &SearchJobManager{
+		searchService: searchService,
+		ttl:           config.AppConfig.SearchJobTTL,
+		maxCount:      config.AppConfig.SearchJobMaxCount,
+		jobs:          make(map[string]*searchJobEntry),
+	}
+	go m.cleanupLoop()
+	return m
+}
+
+// Create 创建并启动一个异步搜索任务，返回任务的初始快照
+// 查询中没有可搜索的关键词时返回ErrEmptyQueryKeyword，不创建任务
+func (m *SearchJobManager) Create(keyword string, channels []string, concurrency int, forceRefresh bool, sourceType string, plugins []string, cloudTypes []string, sortBy string, ext map[string]interface{}) (model.SearchJob, error) {
+	if query.Parse(keyword).Keyword == "" {
+		return model.SearchJob{}, ErrEmptyQueryKeyword
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return model.SearchJob{}, fmt.Errorf("生成任务ID失败: %v", err)
+	}
+
+	now := time.Now()
+	entry := &searchJobEntry{
+		job: model.SearchJob{
+			ID:           id,
+			Status:       model.SearchJobRunning,
+			Keyword:      keyword,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+			ExpiresAt:    now.Add(m.ttl),
+			Sources:      make([]model.SearchJobSource, 0),
+			MergedByType: make(model.MergedLinks),
+		},
+		sourceIndex: make(map[string]int),
+	}
+
+	m.mu.Lock()
+	if len(m.jobs) >= m.maxCount && !m.evictOldestCompletedLocked() {
+		m.mu.Unlock()
+		return model.SearchJob{}, fmt.Errorf("任务数已达上限(%d)，请稍后重试", m.maxCount)
+	}
+	m.jobs[id] = entry
+	m.mu.Unlock()
+
+	// 任务在创建请求返回后继续执行，不绑定请求的ctx
+	go m.searchService.SearchStream(context.Background(), keyword, channels, concurrency, forceRefresh, sourceType, plugins, cloudTypes, sortBy, ext, func(event model.SearchEvent) {
+		m.applyEvent(entry, event)
+	})
+
+	return entry.snapshot(), nil
+}
+
+// Get 获取任务当前快照，每次读取都会顺延任务的过期时间
+func (m *SearchJobManager) Get(id string) (model.SearchJob, bool) {
+	m.mu.RLock()
+	entry, exists := m.jobs[id]
+	m.mu.RUnlock()
+	if !exists {
+		return model.SearchJob{}, false
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	// 与cleanupExpired一致，运行中的任务在完成后才会过期
+	if entry.job.Status == model.SearchJobCompleted && time.Now().After(entry.job.ExpiresAt) {
+		return model.SearchJob{}, false
+	}
+	entry.job.ExpiresAt = time.Now().Add(m.ttl)
+	return entry.copyLocked(), true
+}
+
+// applyEvent 将流式搜索事件合并到任务状态
+func (m *SearchJobManager) applyEvent(entry *searchJobEntry, event model.SearchEvent) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	now := time.Now()
+	entry.job.UpdatedAt = now
+
+	switch event.Type {
+	case model.SearchEventSource:
+		source := model.SearchJobSource{
+			Source:    event.Source,
+			Count:     event.Count,
+			IsFinal:   event.IsFinal,
+			Cached:    event.Cached,
+			ElapsedMs: event.ElapsedMs,
+			Error:     event.Error,
+		}
+		if index, exists := entry.sourceIndex[event.Source]; exists {
+			entry.job.Sources[index] = source
+		} else {
+			entry.sourceIndex[event.Source] = len(entry.job.Sources)
+			entry.job.Sources = append(entry.job.Sources, source)
+		}
+
+		// source事件只携带新增链接，追加到当前快照
+		for linkType, links := range event.MergedByType {
+			entry.job.MergedByType[linkType] = append(entry.job.MergedByType[linkType], links...)
+		}
+		entry.job.Total = event.Total
+
+	case model.SearchEventDone:
+		// done事件携带排序后的完整结果，直接替换快照；搜索失败时记录错误信息
+		entry.job.Status = model.SearchJobCompleted
+		entry.job.Error = event.Error
+		entry.job.MergedByType = event.MergedByType
+		if entry.job.MergedByType == nil {
+			entry.job.MergedByType = make(model.MergedLinks)
+		}
+		entry.job.Total = event.Total
+		entry.job.ExpiresAt = now.Add(m.ttl)
+	}
+}
+
+// evictOldestCompletedLocked 淘汰最早创建的已完成任务，调用方需持有m.mu写锁
+func (m *SearchJobManager) evictOldestCompletedLocked() bool {
+	var oldestID string
+	var oldestTime time.Time
+	for id, entry := range m.jobs {
+		entry.mu.Lock()
+		completed := entry.job.Status == model.SearchJobCompleted
+		createdAt := entry.job.CreatedAt
+		entry.mu.Unlock()
+
+		if completed && (oldestID == "" || createdAt.Before(oldestTime)) {
+			oldestID = id
+			oldestTime = createdAt
+		}
+	}
+	if oldestID == "" {
+		return false
+	}
+	delete(m.jobs, oldestID)
+	return true
+}
+
+// cleanupLoop 定期清理已过期的任务
+func (m *SearchJobManager) cleanupLoop() {
+	interval := m.ttl / 2
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.cleanupExpired()
+	}
+}
+
+// cleanupExpired 删除已过期且已完成的任务，运行中的任务在完成后才会过期
+func (m *SearchJobManager) cleanupExpired() {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, entry := range m.jobs {
+		entry.mu.Lock()
+		expired := entry.job.Status == model.SearchJobCompleted && now.After(entry.job.ExpiresAt)
+		entry.mu.Unlock()
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// snapshot 获取任务快照副本
+func (e *searchJobEntry) snapshot() model.SearchJob {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.copyLocked()
+}
+
+// copyLocked 复制任务状态，避免调用方读取时与后台更新产生竞争，调用方需持有e.mu
+func (e *searchJobEntry) copyLocked() model.SearchJob {
+	job := e.job
+	job.Sources = append([]model.SearchJobSource(nil), e.job.Sources...)
+	if job.Sources == nil {
+		job.Sources = make([]model.SearchJobSource, 0)
+	}
+	job.MergedByType = make(model.MergedLinks, len(e.job.MergedByType))
+	for linkType, links := range e.job.MergedByType {
+		job.MergedByType[linkType] = append([]model.MergedLink(nil), links...)
+	}
+	return job
+}
+
+// newRandomID 生成随机ID，用于任务ID
+func newRandomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
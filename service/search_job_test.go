@@ -0,0 +1,74 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pansou/model"
+)
+
+// 只有过滤条件的查询不创建任务，与/api/search一致返回ErrEmptyQueryKeyword
+func TestSearchJobRejectsQueryWithoutKeyword(t *testing.T) {
+	cfg := testConfig()
+	cfg.SearchJobTTL = time.Minute
+	cfg.SearchJobMaxCount = 10
+	m := NewSearchJobManager(setupTestService(t, cfg))
+
+	if _, err := m.Create("-foo source:tg", nil, 0, false, "all", nil, nil, "", nil); !errors.Is(err, ErrEmptyQueryKeyword) {
+		t.Fatalf("应返回ErrEmptyQueryKeyword，实际: %v", err)
+	}
+	if len(m.jobs) != 0 {
+		t.Fatalf("不应创建任务，实际有%d个", len(m.jobs))
+	}
+}
+
+// done事件中的错误信息记录到任务中
+func TestSearchJobSurfacesDoneError(t *testing.T) {
+	cfg := testConfig()
+	cfg.SearchJobTTL = time.Minute
+	cfg.SearchJobMaxCount = 10
+	m := NewSearchJobManager(setupTestService(t, cfg))
+
+	entry := &searchJobEntry{
+		job:         model.SearchJob{Status: model.SearchJobRunning, MergedByType: make(model.MergedLinks)},
+		sourceIndex: make(map[string]int),
+	}
+	m.applyEvent(entry, model.SearchEvent{Type: model.SearchEventDone, IsFinal: true, Error: ErrEmptyQueryKeyword.Error()})
+
+	job := entry.snapshot()
+	if job.Status != model.SearchJobCompleted || job.Error != ErrEmptyQueryKeyword.Error() {
+		t.Fatalf("任务状态为%q、错误为%q，应为completed并带有错误信息", job.Status, job.Error)
+	}
+}
+
+// 正常完成的任务包含插件结果且没有错误信息
+func TestSearchJobCompletes(t *testing.T) {
+	cfg := testConfig()
+	cfg.SearchJobTTL = time.Minute
+	cfg.SearchJobMaxCount = 10
+	m := NewSearchJobManager(setupTestService(t, cfg, &stubPlugin{name: "stub", results: stubResults("stub", 2)}))
+
+	created, err := m.Create("测试", nil, 0, false, "plugin", nil, nil, "", nil)
+	if err != nil {
+		t.Fatalf("创建任务失败: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, ok := m.Get(created.ID)
+		if !ok {
+			t.Fatal("任务不存在")
+		}
+		if job.Status == model.SearchJobCompleted {
+			if job.Error != "" || len(job.MergedByType["quark"]) != 2 {
+				t.Fatalf("任务错误为%q、quark链接%d个，应无错误且有2个链接", job.Error, len(job.MergedByType["quark"]))
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("等待任务完成超时")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"pansou/config"
+	"pansou/model"
+)
+
+// ErrInvalidCursor 分页游标无法解析或对应的结果快照已过期
+var ErrInvalidCursor = errors.New("分页游标无效或已过期，请重新搜索")
+
+// searchSnapshotPrefix 分页结果快照在主缓存中的键前缀
+const searchSnapshotPrefix = "page:"
+
+// PaginateResponse 对完整搜索结果分页
+// 完整结果序列化后写入主缓存（有效期与主缓存一致），游标中记录快照的缓存键和偏移量，
+// 后续通过LoadResponsePage从缓存读取快照翻页，配置远程缓存时游标在多个实例间通用。
+// 快照只包含结果本身，不含cache_age、stale和诊断信息，以结果内容的哈希为键，
+// 相同的结果只保存一份，快照已存在时不再写入；未启用缓存时只返回当前页，不返回游标
+func (s *SearchService) PaginateResponse(response model.SearchResponse, offset int, limit int) (model.SearchResponse, error) {
+	snapshotKey := ""
+	if cacheInitialized && config.AppConfig.CacheEnabled && enhancedTwoLevelCache != nil {
+		snapshot := model.SearchResponse{
+			Total:        response.Total,
+			Results:      response.Results,
+			MergedByType: response.MergedByType,
+		}
+		data, err := enhancedTwoLevelCache.GetSerializer().Serialize(snapshot)
+		if err != nil {
+			return model.SearchResponse{}, fmt.Errorf("序列化结果快照失败: %v", err)
+		}
+		snapshotKey, err = snapshotCacheKey(snapshot)
+		if err != nil {
+			return model.SearchResponse{}, fmt.Errorf("计算结果快照哈希失败: %v", err)
+		}
+		if _, exists, _ := enhancedTwoLevelCache.Get(snapshotKey); !exists {
+			ttl := config.CacheSoftTTL()
+			if err := enhancedTwoLevelCache.SetBothLevels(snapshotKey, data, ttl); err != nil {
+				return model.SearchResponse{}, fmt.Errorf("保存结果快照失败: %v", err)
+			}
+		}
+	}
+
+	return pageResponse(snapshotKey, response, offset, limit), nil
+}
+
+// snapshotCacheKey 根据结果内容计算快照缓存键
+// 缓存序列化时map的顺序不固定，哈希使用按键排序的标准JSON编码
+func snapshotCacheKey(snapshot model.SearchResponse) (string, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum(data)
+	return searchSnapshotPrefix + hex.EncodeToString(hash[:]), nil
+}
+
+// LoadResponsePage 根据游标从缓存中的快照读取一页结果，limit<=0时沿用游标中的每页数量
+func (s *SearchService) LoadResponsePage(cursor string, limit int) (model.SearchResponse, error) {
+	snapshotKey, offset, cursorLimit, err := decodeCursor(cursor)
+	if err != nil || !strings.HasPrefix(snapshotKey, searchSnapshotPrefix) {
+		return model.SearchResponse{}, ErrInvalidCursor
+	}
+	if !cacheInitialized || enhancedTwoLevelCache == nil {
+		return model.SearchResponse{}, ErrInvalidCursor
+	}
+
+	data, hit, err := enhancedTwoLevelCache.Get(snapshotKey)
+	if err != nil || !hit {
+		return model.SearchResponse{}, ErrInvalidCursor
+	}
+	var response model.SearchResponse
+	if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &response); err != nil {
+		return model.SearchResponse{}, ErrInvalidCursor
+	}
+
+	if limit <= 0 {
+		limit = cursorLimit
+	}
+	return pageResponse(snapshotKey, response, offset, limit), nil
+}
+
+// pageResponse 截取结果的一页，results和merged_by_type中每种网盘类型使用相同的偏移量
+// snapshotKey为空时不生成下一页游标
+func pageResponse(snapshotKey string, response model.SearchResponse, offset int, limit int) model.SearchResponse {
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := model.SearchResponse{
+		Total:    response.Total,
+		Sources:  response.Sources,
+		CacheAge: response.CacheAge,
+		Stale:    response.Stale,
+	}
+	hasMore := false
+
+	if response.Results != nil {
+		page.Results, hasMore = pageSlice(response.Results, offset, limit)
+	}
+	if response.MergedByType != nil {
+		page.MergedByType = make(model.MergedLinks, len(response.MergedByType))
+		for linkType, links := range response.MergedByType {
+			pageLinks, more := pageSlice(links, offset, limit)
+			if len(pageLinks) > 0 {
+				page.MergedByType[linkType] = pageLinks
+			}
+			hasMore = hasMore || more
+		}
+	}
+
+	if hasMore && snapshotKey != "" {
+		page.NextCursor = encodeCursor(snapshotKey, offset+limit, limit)
+	}
+	return page
+}
+
+// pageSlice 截取切片的一页，返回该页以及之后是否还有数据
+func pageSlice[T any](items []T, offset int, limit int) ([]T, bool) {
+	if offset >= len(items) {
+		return []T{}, false
+	}
+	end := len(items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return items[offset:end], end < len(items)
+}
+
+// encodeCursor 生成不透明游标：快照缓存键:偏移量:每页数量
+func encodeCursor(snapshotKey string, offset int, limit int) string {
+	raw := fmt.Sprintf("%s:%d:%d", snapshotKey, offset, limit)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor 解析游标
+func decodeCursor(cursor string) (string, int, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	// 快照缓存键本身含有冒号，从右侧拆分偏移量和每页数量
+	parts := strings.Split(string(raw), ":")
+	if len(parts) < 3 {
+		return "", 0, 0, fmt.Errorf("游标格式错误")
+	}
+	parts = []string{strings.Join(parts[:len(parts)-2], ":"), parts[len(parts)-2], parts[len(parts)-1]}
+	if parts[0] == "" {
+		return "", 0, 0, fmt.Errorf("游标格式错误")
+	}
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil || offset < 0 {
+		return "", 0, 0, fmt.Errorf("游标偏移量错误")
+	}
+	limit, err := strconv.Atoi(parts[2])
+	if err != nil || limit < 0 {
+		return "", 0, 0, fmt.Errorf("游标每页数量错误")
+	}
+	return parts[0], offset, limit, nil
+}
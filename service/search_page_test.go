@@ -0,0 +1,113 @@
+package service
+
+import (
+	"testing"
+
+	"pansou/model"
+)
+
+// testPageResponse 生成n个结果以及两种网盘类型的合并链接
+func testPageResponse(n int) model.SearchResponse {
+	results := stubResults("page", n)
+	merged := model.MergedLinks{}
+	for _, result := range results {
+		for _, linkType := range []string{"quark", "baidu"} {
+			merged[linkType] = append(merged[linkType], model.MergedLink{
+				URL:      result.Links[0].URL + "/" + linkType,
+				Note:     result.Title,
+				Datetime: result.Datetime,
+				Source:   "plugin:page",
+			})
+		}
+	}
+	return model.SearchResponse{Total: n, Results: results, MergedByType: merged}
+}
+
+// 按游标翻页能取回全部结果，且不重复不遗漏
+func TestPaginateResponseCursorRoundTrip(t *testing.T) {
+	cfg := testConfig()
+	cfg.CacheEnabled = true
+	s := setupTestService(t, cfg)
+
+	response := testPageResponse(5)
+	page, err := s.PaginateResponse(response, 0, 2)
+	if err != nil {
+		t.Fatalf("分页失败: %v", err)
+	}
+
+	var ids []string
+	var quark int
+	for pages := 1; ; pages++ {
+		if page.Total != 5 {
+			t.Fatalf("第%d页total为%d，应为5", pages, page.Total)
+		}
+		for _, result := range page.Results {
+			ids = append(ids, result.UniqueID)
+		}
+		quark += len(page.MergedByType["quark"])
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Fatalf("共%d页，应为3页", pages)
+			}
+			break
+		}
+		if page, err = s.LoadResponsePage(page.NextCursor, 0); err != nil {
+			t.Fatalf("读取第%d页失败: %v", pages+1, err)
+		}
+	}
+
+	if len(ids) != 5 || quark != 5 {
+		t.Fatalf("翻页得到%d个结果、%d个quark链接，应都为5", len(ids), quark)
+	}
+	for i, result := range response.Results {
+		if ids[i] != result.UniqueID {
+			t.Fatalf("第%d个结果为%s，应为%s", i, ids[i], result.UniqueID)
+		}
+	}
+
+	if _, err := s.LoadResponsePage("not-a-cursor", 0); err != ErrInvalidCursor {
+		t.Fatalf("无效游标应返回ErrInvalidCursor，实际: %v", err)
+	}
+}
+
+// 结果相同、缓存时间和诊断信息不同的响应使用同一个快照
+func TestPaginateResponseReusesSnapshot(t *testing.T) {
+	cfg := testConfig()
+	cfg.CacheEnabled = true
+	s := setupTestService(t, cfg)
+
+	first := testPageResponse(3)
+	first.CacheAge = 1
+	second := testPageResponse(3)
+	second.CacheAge = 60
+	second.Stale = true
+	second.Sources = []model.SourceDiagnostic{{ElapsedMs: 1234}}
+
+	firstPage, err := s.PaginateResponse(first, 0, 1)
+	if err != nil {
+		t.Fatalf("分页失败: %v", err)
+	}
+	secondPage, err := s.PaginateResponse(second, 0, 1)
+	if err != nil {
+		t.Fatalf("分页失败: %v", err)
+	}
+	if firstPage.NextCursor == "" || firstPage.NextCursor != secondPage.NextCursor {
+		t.Fatalf("相同结果的游标不同: %q %q", firstPage.NextCursor, secondPage.NextCursor)
+	}
+	if secondPage.CacheAge != 60 || !secondPage.Stale {
+		t.Fatal("当前页应保留本次响应的cache_age和stale")
+	}
+}
+
+// 未启用缓存时只返回当前页，不返回游标
+func TestPaginateResponseWithoutCache(t *testing.T) {
+	s := setupTestService(t, testConfig())
+
+	page, err := s.PaginateResponse(testPageResponse(3), 0, 1)
+	if err != nil {
+		t.Fatalf("分页失败: %v", err)
+	}
+	if len(page.Results) != 1 || page.NextCursor != "" {
+		t.Fatalf("应只返回1个结果且没有游标，实际%d个结果，游标%q", len(page.Results), page.NextCursor)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util/query"
+)
+
+// ErrEmptyQueryKeyword 查询只包含过滤条件，没有可发送给上游的关键词
+var ErrEmptyQueryKeyword = errors.New("查询中缺少搜索关键词")
+
+// mergeKeyword 返回传给mergeResultsByType的关键词
+// 使用查询语法时链接标题的文本条件由filterLinksByQuery处理，因此不再做整体关键词匹配
+func mergeKeyword(q *query.Query) string {
+	if q.IsPlain() {
+		return q.Keyword
+	}
+	return ""
+}
+
+// filterResultsByQuery 按查询条件过滤搜索结果
+// 同时按网盘类型和提取码条件过滤每条结果的链接，没有剩余链接的结果被丢弃
+func filterResultsByQuery(results []model.SearchResult, q *query.Query) []model.SearchResult {
+	if q.IsPlain() {
+		return results
+	}
+
+	filtered := make([]model.SearchResult, 0, len(results))
+	for _, result := range results {
+		source := getResultSource(result)
+		if !q.MatchResult(result, source, !skipsServiceFilter(source)) {
+			continue
+		}
+
+		if q.HasLinkFilter() && len(result.Links) > 0 {
+			links := make([]model.Link, 0, len(result.Links))
+			for _, link := range result.Links {
+				if q.MatchLink(link.Type, link.Password) {
+					links = append(links, link)
+				}
+			}
+			if len(links) == 0 {
+				continue
+			}
+			result.Links = links
+		}
+
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+// filterLinksByQuery 按查询的文本条件过滤合并后链接的标题
+func filterLinksByQuery(mergedLinks model.MergedLinks, q *query.Query) model.MergedLinks {
+	if !q.HasTextFilter() {
+		return mergedLinks
+	}
+
+	filtered := make(model.MergedLinks, len(mergedLinks))
+	for linkType, links := range mergedLinks {
+		kept := make([]model.MergedLink, 0, len(links))
+		for _, link := range links {
+			if skipsServiceFilter(link.Source) || q.MatchText(link.Note) {
+				kept = append(kept, link)
+			}
+		}
+		if len(kept) > 0 {
+			filtered[linkType] = kept
+		}
+	}
+	return filtered
+}
+
+// skipsServiceFilter 来源插件是否声明跳过Service层关键词过滤
+func skipsServiceFilter(source string) bool {
+	if !strings.HasPrefix(source, "plugin:") {
+		return false
+	}
+	if pluginInstance, exists := plugin.GetPluginByName(strings.TrimPrefix(source, "plugin:")); exists {
+		return pluginInstance.SkipServiceFilter()
+	}
+	return false
+}
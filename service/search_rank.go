@@ -0,0 +1,160 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/util/rank"
+)
+
+// 搜索结果排序方式
+const (
+	SortRelevance = "relevance" // 按综合得分排序
+	SortTime      = "time"      // 按发布时间从新到旧排序，时间相同按综合得分
+	SortSource    = "source"    // 按来源优先级、时间和优先关键词的固定分段得分排序（默认，与引入sort参数前一致）
+)
+
+// recencyHalfLife 时间信号的半衰期，发布时间每早一个半衰期，时间得分减半
+const recencyHalfLife = 30 * 24 * time.Hour
+
+// Scorer 搜索结果评分器，返回值与results一一对应，得分越高排序越靠前
+type Scorer interface {
+	Score(keyword string, results []model.SearchResult) []float64
+}
+
+var (
+	scorerLock   sync.RWMutex
+	resultScorer Scorer = defaultScorer{}
+)
+
+// SetResultScorer 替换相关性排序使用的评分器，传入nil时恢复默认评分器
+func SetResultScorer(scorer Scorer) {
+	if scorer == nil {
+		scorer = defaultScorer{}
+	}
+	scorerLock.Lock()
+	resultScorer = scorer
+	scorerLock.Unlock()
+}
+
+// getResultScorer 获取当前的评分器
+func getResultScorer() Scorer {
+	scorerLock.RLock()
+	defer scorerLock.RUnlock()
+	return resultScorer
+}
+
+// normalizeSortMode 规范化排序方式，未指定或无法识别时使用来源优先级排序，相关性排序需显式指定
+func normalizeSortMode(sortBy string) string {
+	switch sortBy = strings.ToLower(strings.TrimSpace(sortBy)); sortBy {
+	case SortRelevance, SortTime:
+		return sortBy
+	default:
+		return SortSource
+	}
+}
+
+// sortResults 按指定方式排序搜索结果
+func sortResults(results []model.SearchResult, keyword string, sortBy string) {
+	sortBy = normalizeSortMode(sortBy)
+	if sortBy == SortSource {
+		sortResultsByTimeAndKeywords(results)
+		return
+	}
+
+	scores := getResultScorer().Score(keyword, results)
+	if len(scores) != len(results) {
+		// 自定义评分器返回的得分数量不对时退回固定分段排序
+		sortResultsByTimeAndKeywords(results)
+		return
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if sortBy == SortTime && !results[a].Datetime.Equal(results[b].Datetime) {
+			return results[a].Datetime.After(results[b].Datetime)
+		}
+		return scores[a] > scores[b]
+	})
+
+	sorted := make([]model.SearchResult, len(results))
+	for i, index := range order {
+		sorted[i] = results[index]
+	}
+	copy(results, sorted)
+}
+
+// defaultScorer 默认评分器
+// 综合得分为文本相关性（BM25）、发布时间、来源优先级和链接质量四个信号的加权和，
+// 各信号归一化到0~1，权重由RANK_WEIGHTS配置
+type defaultScorer struct{}
+
+// Score 计算每个结果的综合得分
+func (defaultScorer) Score(keyword string, results []model.SearchResult) []float64 {
+	weights := config.GetRankWeights()
+
+	docs := make([]rank.Document, len(results))
+	for i, result := range results {
+		docs[i] = rank.Document{Title: result.Title, Content: result.Content}
+	}
+	relevance := rank.BM25(keyword, docs)
+	maxRelevance := 0.0
+	for _, score := range relevance {
+		maxRelevance = math.Max(maxRelevance, score)
+	}
+
+	now := time.Now()
+	scores := make([]float64, len(results))
+	for i, result := range results {
+		score := weights.Recency*recencySignal(result.Datetime, now) +
+			weights.Source*sourceSignal(getResultSource(result)) +
+			weights.Links*linkSignal(result.Links)
+		if maxRelevance > 0 {
+			score += weights.Relevance * relevance[i] / maxRelevance
+		}
+		scores[i] = score
+	}
+	return scores
+}
+
+// recencySignal 时间信号，按半衰期指数衰减，没有时间信息时为0
+func recencySignal(datetime time.Time, now time.Time) float64 {
+	if datetime.IsZero() {
+		return 0
+	}
+	age := now.Sub(datetime)
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+}
+
+// sourceSignal 来源信号，等级1插件为1，等级4插件为0，TG频道与等级3插件相同
+func sourceSignal(source string) float64 {
+	return float64(getPluginLevelScore(source)+200) / 1200
+}
+
+// linkSignal 链接质量信号
+// 可识别网盘类型的链接占比和链接数量（3个及以上视为满分）各占一部分，没有链接时为0
+func linkSignal(links []model.Link) float64 {
+	if len(links) == 0 {
+		return 0
+	}
+	known := 0
+	for _, link := range links {
+		if link.URL != "" && link.Type != "" && link.Type != "others" {
+			known++
+		}
+	}
+	count := math.Min(float64(len(links)), 3) / 3
+	return 0.7*float64(known)/float64(len(links)) + 0.3*count
+}
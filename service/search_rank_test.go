@@ -0,0 +1,19 @@
+package service
+
+import "testing"
+
+// 未指定或无法识别的排序方式保持来源优先级排序，相关性排序需显式指定
+func TestNormalizeSortMode(t *testing.T) {
+	cases := map[string]string{
+		"":            SortSource,
+		"unknown":     SortSource,
+		"source":      SortSource,
+		" Relevance ": SortRelevance,
+		"TIME":        SortTime,
+	}
+	for input, want := range cases {
+		if got := normalizeSortMode(input); got != want {
+			t.Errorf("normalizeSortMode(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
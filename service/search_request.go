@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"pansou/config"
+	"pansou/model"
+)
+
+// NormalizeSearchRequest 检查并设置搜索请求的默认值
+// HTTP接口与MCP工具共用，保证两者参数处理一致
+func NormalizeSearchRequest(req *model.SearchRequest) {
+	if len(req.Channels) == 0 {
+		req.Channels = config.GetDefaultChannels()
+	}
+	
+	// 如果未指定结果类型，默认返回merge并转换为merged_by_type
+	if req.ResultType == "" {
+		req.ResultType = "merged_by_type"
+	} else if req.ResultType == "merge" {
+		// 将merge转换为merged_by_type，以兼容内部处理
+		req.ResultType = "merged_by_type"
+	} else if req.ResultType == "all_with_meta" {
+		// all_with_meta等同于res=all并开启调试模式
+		req.ResultType = "all"
+		req.Debug = true
+	}
+	
+	// 如果未指定数据来源类型，默认为全部
+	if req.SourceType == "" {
+		req.SourceType = "all"
+	}
+	
+	// 未指定或无法识别的排序方式按来源优先级排序
+	req.Sort = normalizeSortMode(req.Sort)
+	
+	// 参数互斥逻辑：当src=tg时忽略plugins参数，当src=plugin时忽略channels参数
+	if req.SourceType == "tg" {
+		req.Plugins = nil // 忽略plugins参数
+	} else if req.SourceType == "plugin" {
+		req.Channels = nil // 忽略channels参数
+	} else if req.SourceType == "all" {
+		// 对于all类型，如果plugins为空或不存在，统一设为nil
+		if req.Plugins == nil || len(req.Plugins) == 0 {
+			req.Plugins = nil
+		}
+	}
+}
+
+// SearchByRequest 按请求参数执行搜索，处理游标翻页和分页
+// 请求需先经过NormalizeSearchRequest处理，ctx取消时中止搜索
+func (s *SearchService) SearchByRequest(ctx context.Context, req model.SearchRequest) (model.SearchResponse, error) {
+	// 携带游标时直接从结果快照翻页，不再执行搜索
+	if req.Cursor != "" {
+		return s.LoadResponsePage(req.Cursor, req.Limit)
+	}
+
+	s.recordTrending(req.Keyword, req.Channels, req.SourceType, req.Plugins)
+	result, err := s.Search(ctx, req.Keyword, req.Channels, req.Concurrency, req.ForceRefresh, req.ResultType, req.SourceType, req.Plugins, req.CloudTypes, req.Sort, req.Ext, req.Debug)
+	if err != nil {
+		return model.SearchResponse{}, err
+	}
+
+	// 指定了每页数量或偏移量时分页返回
+	if req.Limit > 0 || req.Offset > 0 {
+		return s.PaginateResponse(result, req.Offset, req.Limit)
+	}
+	return result, nil
+}
@@ -17,6 +17,7 @@ import (
 	"pansou/plugin"
 	"pansou/util"
 	"pansou/util/cache"
+	"pansou/util/query"
 	"pansou/util/pool"
 )
 
@@ -200,6 +201,9 @@ func calculateCompletenessScore(result model.SearchResult) int {
 // SearchService 搜索服务
 type SearchService struct {
 	pluginManager *plugin.PluginManager
+	coldSearches  *coldSearchLimiter // 冷搜索并发上限，nil表示不限制
+	trending      *trendingTracker   // 热门关键词记录，未启用缓存预热时为nil
+	stopWarmer    func()             // 停止缓存预热，未启动时为nil
 }
 
 // NewSearchService 创建搜索服务实例并确保缓存可用
@@ -211,12 +215,19 @@ func NewSearchService(pluginManager *plugin.PluginManager) *SearchService {
 		enhancedTwoLevelCache, err = cache.NewEnhancedTwoLevelCache()
 		if err == nil {
 			cacheInitialized = true
+		} else {
+			fmt.Printf("缓存初始化失败: %v\n", err)
 		}
 	}
 	
 	// 将主缓存注入到异步插件中
 	injectMainCacheToAsyncPlugins(pluginManager, enhancedTwoLevelCache)
 	
+	// 未指定插件时的缓存键按实际启用的插件集合计算
+	if config.AppConfig.AsyncPluginEnabled && pluginManager != nil {
+		cache.SetDefaultPluginSet(pluginManager.PluginNames())
+	}
+	
 	// 确保缓存写入管理器设置了主缓存更新函数
 	if globalCacheWriteManager != nil && enhancedTwoLevelCache != nil {
 		globalCacheWriteManager.SetMainCacheUpdater(func(key string, data []byte, ttl time.Duration) error {
@@ -226,6 +237,8 @@ func NewSearchService(pluginManager *plugin.PluginManager) *SearchService {
 
 	return &SearchService{
 		pluginManager: pluginManager,
+		coldSearches:  newColdSearchLimiter(config.AppConfig.MaxColdSearches),
+		trending:      newTrendingTracker(),
 	}
 }
 
@@ -244,11 +257,19 @@ func injectMainCacheToAsyncPlugins(pluginManager *plugin.PluginManager, mainCach
 	
 	// 创建缓存更新函数（支持IsFinal参数）- 接收原始数据并与现有缓存合并
 	cacheUpdater := func(key string, newResults []model.SearchResult, ttl time.Duration, isFinal bool, keyword string, pluginName string) error {
+		// 通知等待该插件后台结果的订阅者（流式搜索等），空结果也需要通知以便订阅者结束等待
+		globalResultNotifier.publish(key, pluginName, newResults, isFinal)
+		
 		// 优化：如果新结果为空，跳过缓存更新（避免无效操作）
 		if len(newResults) == 0 {
 			return nil
 		}
 		
+		// 启用了过期缓存时至少保存CACHE_TTL+CACHE_STALE_TTL，避免插件缓存有效期较短时提前失效
+		if config.CacheStaleTTL() > 0 && ttl < config.CacheHardTTL() {
+			ttl = config.CacheHardTTL()
+		}
+		
 		// 获取现有缓存数据进行合并
 		var finalResults []model.SearchResult
 		if existingData, hit, err := mainCache.Get(key); err == nil && hit {
@@ -348,12 +369,29 @@ func injectMainCacheToAsyncPlugins(pluginManager *plugin.PluginManager, mainCach
 }
 
 // Search 执行搜索
-func (s *SearchService) Search(keyword string, channels []string, concurrency int, forceRefresh bool, resultType string, sourceType string, plugins []string, cloudTypes []string, ext map[string]interface{}) (model.SearchResponse, error) {
+// debug为true时在响应中附带各数据源的诊断信息
+// ctx取消（如客户端断开）时中止进行中的TG和插件请求，返回ctx.Err()
+func (s *SearchService) Search(ctx context.Context, keyword string, channels []string, concurrency int, forceRefresh bool, resultType string, sourceType string, plugins []string, cloudTypes []string, sortBy string, ext map[string]interface{}, debug bool) (model.SearchResponse, error) {
+	started := time.Now()
+	response, err := s.search(ctx, keyword, channels, concurrency, forceRefresh, resultType, sourceType, plugins, cloudTypes, sortBy, ext, debug)
+	observeSearch(sourceType, resultType, started, err)
+	return response, err
+}
+
+// search 执行搜索的具体逻辑
+func (s *SearchService) search(ctx context.Context, keyword string, channels []string, concurrency int, forceRefresh bool, resultType string, sourceType string, plugins []string, cloudTypes []string, sortBy string, ext map[string]interface{}, debug bool) (model.SearchResponse, error) {
 	// 确保ext不为nil
 	if ext == nil {
 		ext = make(map[string]interface{})
 	}
 	
+	// 解析查询语法，上游只使用普通关键词，完整条件在本地过滤
+	q := query.Parse(keyword)
+	if q.Keyword == "" {
+		return model.SearchResponse{}, ErrEmptyQueryKeyword
+	}
+	keyword = q.Keyword
+	
 	// 参数预处理
 	// 源类型标准化
 	if sourceType == "" {
@@ -361,71 +399,11 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	}
 
 	// 插件参数规范化处理
-	if sourceType == "tg" {
-		// 对于只搜索Telegram的请求，忽略插件参数
-		plugins = nil
-	} else if sourceType == "all" || sourceType == "plugin" {
-		// 检查是否为空列表或只包含空字符串
-		if plugins == nil || len(plugins) == 0 {
-			plugins = nil
-		} else {
-			// 检查是否有非空元素
-			hasNonEmpty := false
-			for _, p := range plugins {
-				if p != "" {
-					hasNonEmpty = true
-					break
-				}
-			}
-
-			// 如果全是空字符串，视为未指定
-			if !hasNonEmpty {
-				plugins = nil
-			} else {
-				// 检查是否包含所有插件
-				allPlugins := s.pluginManager.GetPlugins()
-				allPluginNames := make([]string, 0, len(allPlugins))
-				for _, p := range allPlugins {
-					allPluginNames = append(allPluginNames, strings.ToLower(p.Name()))
-				}
-
-				// 创建请求的插件名称集合（忽略空字符串）
-				requestedPlugins := make([]string, 0, len(plugins))
-				for _, p := range plugins {
-					if p != "" {
-						requestedPlugins = append(requestedPlugins, strings.ToLower(p))
-					}
-				}
-
-				// 如果请求的插件数量与所有插件数量相同，检查是否包含所有插件
-				if len(requestedPlugins) == len(allPluginNames) {
-					// 创建映射以便快速查找
-					pluginMap := make(map[string]bool)
-					for _, p := range requestedPlugins {
-						pluginMap[p] = true
-					}
-
-					// 检查是否包含所有插件
-					allIncluded := true
-					for _, name := range allPluginNames {
-						if !pluginMap[name] {
-							allIncluded = false
-							break
-						}
-					}
-
-					// 如果包含所有插件，统一设为nil
-					if allIncluded {
-						plugins = nil
-					}
-				}
-			}
-		}
-	}
+	plugins = s.normalizePlugins(sourceType, plugins)
 	
 	// 如果未指定并发数，使用配置中的默认值
 	if concurrency <= 0 {
-		concurrency = config.AppConfig.DefaultConcurrency
+		concurrency = config.GetDefaultConcurrency()
 	}
 
 	// 并行获取TG搜索和插件搜索结果
@@ -435,12 +413,25 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	var wg sync.WaitGroup
 	var tgErr, pluginErr error
 	
+	// debug模式下收集各数据源诊断信息
+	var diag *sourceDiagnostics
+	if debug {
+		diag = newSourceDiagnostics()
+	}
+	
+	// 记录命中缓存的新鲜度，在响应中标记
+	fresh := &cacheFreshness{}
+	
+	// 一次搜索只占用一个冷搜索名额，TG或插件第一次未命中缓存时占用，两者都完成后释放
+	cold := s.coldSearches.ticket()
+	defer cold.release()
+	
 	// 如果需要搜索TG
 	if sourceType == "all" || sourceType == "tg" {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			tgResults, tgErr = s.searchTG(keyword, channels, forceRefresh)
+			tgResults, tgErr = s.searchTG(ctx, keyword, channels, forceRefresh, cold, diag, fresh)
 		}()
 	}
 	// 如果需要搜索插件（且插件功能已启用）
@@ -450,7 +441,7 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 			defer wg.Done()
 			// 对于插件搜索，我们总是希望获取最新的缓存数据
 			// 因此，即使forceRefresh=false，我们也需要确保获取到最新的缓存
-			pluginResults, pluginErr = s.searchPlugins(keyword, plugins, forceRefresh, concurrency, ext)
+			pluginResults, pluginErr = s.searchPlugins(ctx, keyword, plugins, forceRefresh, concurrency, ext, cold, diag, fresh)
 		}()
 	}
 	
@@ -467,9 +458,12 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	
 	// 合并结果
 	allResults := mergeSearchResults(tgResults, pluginResults)
+	
+	// 应用查询语法中的过滤条件
+	allResults = filterResultsByQuery(allResults, q)
 
-	// 按照优化后的规则排序结果
-	sortResultsByTimeAndKeywords(allResults)
+	// 按请求指定的方式排序结果
+	sortResults(allResults, keyword, sortBy)
 
 	// 过滤结果，只保留有时间的结果或包含优先关键词的结果或高等级插件结果到Results中
 	filteredForResults := make([]model.SearchResult, 0, len(allResults))
@@ -484,7 +478,7 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	}
 
 	// 合并链接按网盘类型分组（使用所有过滤后的结果）
-	mergedLinks := mergeResultsByType(allResults, keyword, cloudTypes)
+	mergedLinks := filterLinksByQuery(mergeResultsByType(allResults, mergeKeyword(q), cloudTypes), q)
 
 	// 构建响应
 	var total int
@@ -506,7 +500,79 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	}
 
 	// 根据resultType过滤返回结果
-	return filterResponseByType(response, resultType), nil
+	response = filterResponseByType(response, resultType)
+	
+	// 附带各数据源诊断信息
+	response.Sources = diag.build(filteredForResults, mergedLinks)
+	fresh.apply(&response)
+	
+	return response, nil
+}
+
+// normalizePlugins 规范化插件参数：tg来源忽略插件，空列表或包含全部插件时统一视为nil
+func (s *SearchService) normalizePlugins(sourceType string, plugins []string) []string {
+	if sourceType == "tg" {
+		// 对于只搜索Telegram的请求，忽略插件参数
+		plugins = nil
+	} else if sourceType == "all" || sourceType == "plugin" {
+		// 检查是否为空列表或只包含空字符串
+		if plugins == nil || len(plugins) == 0 {
+			plugins = nil
+		} else {
+			// 检查是否有非空元素
+			hasNonEmpty := false
+			for _, p := range plugins {
+				if p != "" {
+					hasNonEmpty = true
+					break
+				}
+			}
+
+			// 如果全是空字符串，视为未指定
+			if !hasNonEmpty {
+				plugins = nil
+			} else {
+				// 检查是否包含所有插件
+				allPlugins := s.pluginManager.GetPlugins()
+				allPluginNames := make([]string, 0, len(allPlugins))
+				for _, p := range allPlugins {
+					allPluginNames = append(allPluginNames, strings.ToLower(p.Name()))
+				}
+
+				// 创建请求的插件名称集合（忽略空字符串）
+				requestedPlugins := make([]string, 0, len(plugins))
+				for _, p := range plugins {
+					if p != "" {
+						requestedPlugins = append(requestedPlugins, strings.ToLower(p))
+					}
+				}
+
+				// 如果请求的插件数量与所有插件数量相同，检查是否包含所有插件
+				if len(requestedPlugins) == len(allPluginNames) {
+					// 创建映射以便快速查找
+					pluginMap := make(map[string]bool)
+					for _, p := range requestedPlugins {
+						pluginMap[p] = true
+					}
+
+					// 检查是否包含所有插件
+					allIncluded := true
+					for _, name := range allPluginNames {
+						if !pluginMap[name] {
+							allIncluded = false
+							break
+						}
+					}
+
+					// 如果包含所有插件，统一设为nil
+					if allIncluded {
+						plugins = nil
+					}
+				}
+			}
+		}
+	}
+	return plugins
 }
 
 // filterResponseByType 根据结果类型过滤响应
@@ -587,16 +653,16 @@ func getKeywordPriority(title string) int {
 	return 0
 }
 
-// 搜索单个频道
-func (s *SearchService) searchChannel(keyword string, channel string) ([]model.SearchResult, error) {
+// fetchChannel 搜索单个频道
+func (s *SearchService) fetchChannel(ctx context.Context, keyword string, channel string) ([]model.SearchResult, error) {
 	// 构建搜索URL
 	url := util.BuildSearchURL(channel, keyword, "")
 
 	// 使用全局HTTP客户端（已配置代理）
 	client := util.GetHTTPClient()
 
-	// 创建一个带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	// 创建一个带超时的上下文，调用方取消时同样中止请求
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
 	defer cancel()
 
 	// 创建请求
@@ -942,8 +1008,8 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 	// 用于去重的映射，键为URL
 	uniqueLinks := make(map[string]model.MergedLink)
 
-	// 将关键词转为小写，用于不区分大小写的匹配
-	lowerKeyword := strings.ToLower(keyword)
+	// 关键词匹配器，忽略繁简、全半角、标点空白等差异
+	matcher := plugin.NewKeywordMatcher(keyword)
 
 	// 遍历所有搜索结果
 	for _, result := range results {
@@ -1037,7 +1103,7 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 			// 关键词过滤：现在我们有了准确的链接-标题对应关系，只需检查每个链接的具体标题
 			if !skipKeywordFilter && keyword != "" {
 				// 只检查链接的具体标题，无论是TG来源还是插件来源
-				if !strings.Contains(strings.ToLower(title), lowerKeyword) {
+				if !matcher.Match(title) {
 					continue
 				}
 			}
@@ -1144,40 +1210,42 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 }
 
 // searchTG 搜索TG频道
-func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh bool) ([]model.SearchResult, error) {
+func (s *SearchService) searchTG(ctx context.Context, keyword string, channels []string, forceRefresh bool, cold *coldSearchTicket, diag *sourceDiagnostics, fresh *cacheFreshness) ([]model.SearchResult, error) {
 	// 生成缓存键
 	cacheKey := cache.GenerateTGCacheKey(keyword, channels)
 	
 	// 如果未启用强制刷新，尝试从缓存获取结果
-	if !forceRefresh && cacheInitialized && config.AppConfig.CacheEnabled {
-		var data []byte
-		var hit bool
-		var err error
-		
-		// 使用增强版缓存
-		if enhancedTwoLevelCache != nil {
-			data, hit, err = enhancedTwoLevelCache.Get(cacheKey)
-			
-			if err == nil && hit {
-				var results []model.SearchResult
-				if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err == nil {
-					// 直接返回缓存数据，不检查新鲜度
-					return results, nil
-				}
-			}
+	// 缓存超过有效期但仍可使用时直接返回，并在后台刷新
+	if !forceRefresh {
+		if results, freshness, hit := loadCachedResults(cacheKey, s.refreshTGCache(keyword, channels)); hit {
+			diag.recordCached(tgSources(channels), results, freshness.Stale)
+			fresh.record(freshness)
+			return results, nil
 		}
 	}
 	
-	// 缓存未命中或强制刷新，执行实际搜索
+	// 缓存未命中或强制刷新，先占用本次搜索的冷搜索名额，再与相同频道和关键词的并发搜索合并为一次
+	if err := cold.acquire(); err != nil {
+		return nil, err
+	}
+	return tgFlight.do(ctx, cacheKey, diag, func(ctx context.Context, diag *sourceDiagnostics) ([]model.SearchResult, error) {
+		return s.fetchTG(ctx, keyword, channels, cacheKey, diag)
+	})
+}
+
+// fetchTG 实际搜索TG频道并异步写入缓存
+func (s *SearchService) fetchTG(ctx context.Context, keyword string, channels []string, cacheKey string, diag *sourceDiagnostics) ([]model.SearchResult, error) {
 	var results []model.SearchResult
 	
 	// 使用工作池并行搜索多个频道
-	tasks := make([]pool.Task, 0, len(channels))
+	tasks := make([]pool.ContextTask, 0, len(channels))
 	
 	for _, channel := range channels {
 		ch := channel // 创建副本，避免闭包问题
-		tasks = append(tasks, func() interface{} {
-			results, err := s.searchChannel(keyword, ch)
+		tasks = append(tasks, func(ctx context.Context) interface{} {
+			started := time.Now()
+			results, err := s.searchChannel(ctx, keyword, ch)
+			diag.record("tg:"+ch, len(results), model.SourceCacheMiss, time.Since(started), err, true)
 			if err != nil {
 				return nil
 			}
@@ -1186,7 +1254,12 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 	}
 	
 	// 执行搜索任务并获取结果
-	taskResults := pool.ExecuteBatchWithTimeout(tasks, len(channels), config.AppConfig.PluginTimeout)
+	taskResults := pool.ExecuteBatchWithContext(ctx, tasks, len(channels), config.GetPluginTimeout())
+	// 请求已取消，不完整的结果不写入缓存
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	diag.markUnfinished(tgSources(channels), config.GetPluginTimeout())
 	
 	// 合并所有频道的结果
 	for _, result := range taskResults {
@@ -1199,7 +1272,7 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 	// 异步缓存结果
 	if cacheInitialized && config.AppConfig.CacheEnabled {
 		go func(res []model.SearchResult) {
-			ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
+			ttl := config.CacheHardTTL()
 			
 			// 使用增强版缓存
 			if enhancedTwoLevelCache != nil {
@@ -1216,7 +1289,7 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 }
 
 // searchPlugins 搜索插件
-func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRefresh bool, concurrency int, ext map[string]interface{}) ([]model.SearchResult, error) {
+func (s *SearchService) searchPlugins(ctx context.Context, keyword string, plugins []string, forceRefresh bool, concurrency int, ext map[string]interface{}, cold *coldSearchTicket, diag *sourceDiagnostics, fresh *cacheFreshness) ([]model.SearchResult, error) {
 	// 确保ext不为nil
 	if ext == nil {
 		ext = make(map[string]interface{})
@@ -1227,102 +1300,61 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 	
 	
 	// 如果未启用强制刷新，尝试从缓存获取结果
-	if !forceRefresh && cacheInitialized && config.AppConfig.CacheEnabled {
-		var data []byte
-		var hit bool
-		var err error
-		
-		// 使用增强版缓存
-		if enhancedTwoLevelCache != nil {
-			
-			// 使用Get方法，它会检查磁盘缓存是否有更新
-			// 如果磁盘缓存比内存缓存更新，会自动更新内存缓存并返回最新数据
-			data, hit, err = enhancedTwoLevelCache.Get(cacheKey)
-			
-			if err == nil && hit {
-				var results []model.SearchResult
-				if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err == nil {
-					// 返回缓存数据
-					fmt.Printf("✅ [%s] 命中缓存 结果数: %d\n", keyword,  len(results))
-					return results, nil
-				} else {
-					displayKey := cacheKey[:8] + "..."
-					fmt.Printf("[主服务] 缓存反序列化失败: %s(关键词:%s) | 错误: %v\n", displayKey, keyword, err)
-				}
-			}
+	// 缓存超过有效期但仍可使用时直接返回，并在后台刷新
+	if !forceRefresh {
+		if results, freshness, hit := loadCachedResults(cacheKey, s.refreshPluginCache(keyword, plugins, concurrency, ext)); hit {
+			fmt.Printf("✅ [%s] 命中缓存 结果数: %d\n", keyword,  len(results))
+			diag.recordCached(pluginSources(s.resolvePlugins(plugins)), results, freshness.Stale)
+			fresh.record(freshness)
+			return results, nil
 		}
 	}
 	
-	// 缓存未命中或强制刷新，执行实际搜索
-	
-	// 获取所有可用插件
-	var availablePlugins []plugin.AsyncSearchPlugin
-	if s.pluginManager != nil {
-		allPlugins := s.pluginManager.GetPlugins()
-		
-		// 确保plugins不为nil并且有非空元素
-		hasPlugins := plugins != nil && len(plugins) > 0
-		hasNonEmptyPlugin := false
-		
-		if hasPlugins {
-			for _, p := range plugins {
-				if p != "" {
-					hasNonEmptyPlugin = true
-					break
-				}
-			}
-		}
-		
-		// 只有当plugins数组包含非空元素时才进行过滤
-		if hasPlugins && hasNonEmptyPlugin {
-			pluginMap := make(map[string]bool)
-			for _, p := range plugins {
-				if p != "" { // 忽略空字符串
-					pluginMap[strings.ToLower(p)] = true
-				}
-			}
-			
-			for _, p := range allPlugins {
-				if pluginMap[strings.ToLower(p.Name())] {
-					availablePlugins = append(availablePlugins, p)
-				}
-			}
-		} else {
-			// 如果plugins为nil、空数组或只包含空字符串，视为未指定，使用所有插件
-			availablePlugins = allPlugins
-		}
+	// 缓存未命中或强制刷新，先占用本次搜索的冷搜索名额，再与相同插件和关键词的并发搜索合并为一次，
+	// 使用最先发起的请求的并发数和扩展参数
+	if err := cold.acquire(); err != nil {
+		return nil, err
+	}
+	return pluginFlight.do(ctx, cacheKey, diag, func(ctx context.Context, diag *sourceDiagnostics) ([]model.SearchResult, error) {
+		return s.fetchPlugins(ctx, keyword, plugins, cacheKey, concurrency, ext, diag)
+	})
+}
+
+// fetchPlugins 实际搜索插件并写入缓存
+func (s *SearchService) fetchPlugins(ctx context.Context, keyword string, plugins []string, cacheKey string, concurrency int, ext map[string]interface{}, diag *sourceDiagnostics) ([]model.SearchResult, error) {
+	// 获取所有可用插件，跳过熔断中的插件
+	availablePlugins, skippedPlugins := s.splitByCircuit(s.resolvePlugins(plugins))
+	for _, p := range skippedPlugins {
+		diag.record("plugin:"+p.Name(), 0, model.SourceCacheMiss, 0, plugin.ErrCircuitOpen, true)
 	}
 	
 	// 控制并发数
 	if concurrency <= 0 {
 		// 使用配置中的默认值
-		concurrency = config.AppConfig.DefaultConcurrency
+		concurrency = config.GetDefaultConcurrency()
 	}
 	
 	// 使用工作池执行并行搜索
-	tasks := make([]pool.Task, 0, len(availablePlugins))
+	tasks := make([]pool.ContextTask, 0, len(availablePlugins))
 	for _, p := range availablePlugins {
-		plugin := p // 创建副本，避免闭包问题
-		tasks = append(tasks, func() interface{} {
+		searchPlugin := p // 创建副本，避免闭包问题
+		tasks = append(tasks, func(ctx context.Context) interface{} {
 			// 设置主缓存键和当前关键词
-			plugin.SetMainCacheKey(cacheKey)
-			plugin.SetCurrentKeyword(keyword)
+			searchPlugin.SetMainCacheKey(cacheKey)
+			searchPlugin.SetCurrentKeyword(keyword)
 			
-			// 调用异步插件的AsyncSearch方法
-			results, err := plugin.AsyncSearch(keyword, func(client *http.Client, kw string, extParams map[string]interface{}) ([]model.SearchResult, error) {
-				// 使用插件的Search方法作为搜索函数
-				return plugin.Search(kw, extParams)
-			}, cacheKey, ext)
-			
-			if err != nil {
-				return nil
-			}
-			return results
+			// 记录缓存状态和IsFinal标记，支持ctx的插件在取消时中止HTTP请求
+			return searchPluginWithDiagnostics(ctx, searchPlugin, keyword, ext, diag)
 		})
 	}
 	
 	// 执行搜索任务并获取结果
-	results := pool.ExecuteBatchWithTimeout(tasks, concurrency, config.AppConfig.PluginTimeout)
+	results := pool.ExecuteBatchWithContext(ctx, tasks, concurrency, config.GetPluginTimeout())
+	// 请求已取消，不完整的结果不写入缓存
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	diag.markUnfinished(pluginSources(availablePlugins), config.GetPluginTimeout())
 	
 	// 合并所有插件的结果，过滤掉无链接的结果
 	var allResults []model.SearchResult
@@ -1341,7 +1373,7 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 	// 恢复主程序缓存更新：确保最终合并结果被正确缓存
 	if cacheInitialized && config.AppConfig.CacheEnabled {
 		go func(res []model.SearchResult, kw string, key string) {
-			ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
+			ttl := config.CacheHardTTL()
 			
 			// 使用增强版缓存，确保与异步插件使用相同的序列化器
 			if enhancedTwoLevelCache != nil {
@@ -1367,6 +1399,72 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 
 
 
+// resolvePlugins 根据请求的插件列表筛选出实际参与搜索的插件
+func (s *SearchService) resolvePlugins(plugins []string) []plugin.AsyncSearchPlugin {
+	var availablePlugins []plugin.AsyncSearchPlugin
+	if s.pluginManager != nil {
+		allPlugins := s.pluginManager.GetPlugins()
+		
+		// 确保plugins不为nil并且有非空元素
+		hasPlugins := plugins != nil && len(plugins) > 0
+		hasNonEmptyPlugin := false
+		
+		if hasPlugins {
+			for _, p := range plugins {
+				if p != "" {
+					hasNonEmptyPlugin = true
+					break
+				}
+			}
+		}
+		
+		// 只有当plugins数组包含非空元素时才进行过滤
+		if hasPlugins && hasNonEmptyPlugin {
+			pluginMap := make(map[string]bool)
+			for _, p := range plugins {
+				if p != "" { // 忽略空字符串
+					pluginMap[strings.ToLower(p)] = true
+				}
+			}
+			
+			for _, p := range allPlugins {
+				if pluginMap[strings.ToLower(p.Name())] {
+					availablePlugins = append(availablePlugins, p)
+				}
+			}
+		} else {
+			// 如果plugins为nil、空数组或只包含空字符串，视为未指定，使用所有插件
+			availablePlugins = allPlugins
+		}
+	}
+	
+	// 插件配置中停用（enabled: false）的插件不参与搜索
+	enabledPlugins := make([]plugin.AsyncSearchPlugin, 0, len(availablePlugins))
+	for _, p := range availablePlugins {
+		if plugin.PluginEnabled(p.Name()) {
+			enabledPlugins = append(enabledPlugins, p)
+		}
+	}
+	return enabledPlugins
+}
+
+// splitByCircuit 按熔断状态拆分插件，返回可以搜索的插件和熔断中被跳过的插件
+func (s *SearchService) splitByCircuit(plugins []plugin.AsyncSearchPlugin) ([]plugin.AsyncSearchPlugin, []plugin.AsyncSearchPlugin) {
+	if s.pluginManager == nil {
+		return plugins, nil
+	}
+	allowed := make([]plugin.AsyncSearchPlugin, 0, len(plugins))
+	var skipped []plugin.AsyncSearchPlugin
+	for _, p := range plugins {
+		if s.pluginManager.AllowPlugin(p.Name()) {
+			allowed = append(allowed, p)
+		} else {
+			skipped = append(skipped, p)
+		}
+	}
+	return allowed, skipped
+}
+
 // GetPluginManager 获取插件管理器
 func (s *SearchService) GetPluginManager() *plugin.PluginManager {
 	return s.pluginManager
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util/cache"
+	"pansou/util/query"
+)
+
+// sourceOutcome 单个来源（TG频道或插件）的一次结果推送
+type sourceOutcome struct {
+	Source  string
+	Results []model.SearchResult
+	Elapsed time.Duration
+	Err     error
+	IsFinal bool
+	Cached  bool
+	Stale   bool
+	IsTG    bool
+}
+
+// resultSearcher 支持返回IsFinal标记的插件（BaseAsyncPlugin的大多数实现）
+type resultSearcher interface {
+	SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error)
+}
+
+// SearchStream 流式搜索
+// 与Search使用相同的参数和缓存，每个TG频道/插件产生结果时立即通过emit推送source事件，
+// 超时转入后台的插件会继续等待其后台结果，全部完成后推送done事件。
+// emit只会在调用方goroutine中被顺序调用。
+// ctx取消（如客户端断开）时中止进行中的请求，不再推送done事件，结果也不写回缓存。
+func (s *SearchService) SearchStream(ctx context.Context, keyword string, channels []string, concurrency int, forceRefresh bool, sourceType string, plugins []string, cloudTypes []string, sortBy string, ext map[string]interface{}, emit func(model.SearchEvent)) {
+	// 确保ext不为nil
+	if ext == nil {
+		ext = make(map[string]interface{})
+	}
+
+	// 解析查询语法，上游只使用普通关键词，完整条件在本地过滤
+	q := query.Parse(keyword)
+	if q.Keyword == "" {
+		emit(model.SearchEvent{Type: model.SearchEventDone, IsFinal: true, Error: ErrEmptyQueryKeyword.Error()})
+		return
+	}
+	keyword = q.Keyword
+
+	if sourceType == "" {
+		sourceType = "all"
+	}
+	plugins = s.normalizePlugins(sourceType, plugins)
+	s.trending.record(keyword, channels, sourceType, plugins)
+	if concurrency <= 0 {
+		concurrency = config.GetDefaultConcurrency()
+	}
+
+	start := time.Now()
+	outcomes := make(chan sourceOutcome)
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	tgCacheKey := cache.GenerateTGCacheKey(keyword, channels)
+	pluginCacheKey := cache.GeneratePluginCacheKey(keyword, plugins)
+
+	// 冷搜索名额在所有来源完成后释放
+	cold := s.coldSearches.ticket()
+	defer cold.release()
+
+	if sourceType == "all" || sourceType == "tg" {
+		s.streamTG(ctx, keyword, channels, tgCacheKey, forceRefresh, cold, semaphore, &wg, outcomes)
+	}
+	if (sourceType == "all" || sourceType == "plugin") && config.AppConfig.AsyncPluginEnabled {
+		s.streamPlugins(ctx, keyword, plugins, pluginCacheKey, forceRefresh, ext, cold, semaphore, &wg, outcomes)
+	}
+
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	// 汇总各来源结果，按来源保留最新一次推送（插件可能先推送部分结果再推送最终结果）
+	latestBySource := make(map[string]sourceOutcome)
+	sourceOrder := make([]string, 0)
+	seenLinks := make(map[string]bool)
+	tgFresh, pluginFresh := false, false
+
+	for outcome := range outcomes {
+		if _, exists := latestBySource[outcome.Source]; !exists {
+			sourceOrder = append(sourceOrder, outcome.Source)
+		}
+		latestBySource[outcome.Source] = outcome
+		// 因冷搜索上限被拒绝的来源没有实际搜索，不写回缓存
+		if !outcome.Cached && outcome.Err != ErrTooManyColdSearches {
+			if outcome.IsTG {
+				tgFresh = true
+			} else {
+				pluginFresh = true
+			}
+		}
+
+		event := model.SearchEvent{
+			Type:      model.SearchEventSource,
+			Source:    outcome.Source,
+			Count:     len(outcome.Results),
+			ElapsedMs: outcome.Elapsed.Milliseconds(),
+			IsFinal:   outcome.IsFinal,
+			Cached:    outcome.Cached,
+			Stale:     outcome.Stale,
+		}
+		if outcome.Err != nil {
+			event.Error = outcome.Err.Error()
+		}
+
+		// 只推送此前未出现过的链接，客户端按增量合并即可
+		delta := make(model.MergedLinks)
+		matched := filterResultsByQuery(outcome.Results, q)
+		for linkType, links := range filterLinksByQuery(mergeResultsByType(matched, mergeKeyword(q), cloudTypes), q) {
+			for _, link := range links {
+				if seenLinks[link.URL] {
+					continue
+				}
+				seenLinks[link.URL] = true
+				delta[linkType] = append(delta[linkType], link)
+			}
+		}
+		if len(delta) > 0 {
+			event.MergedByType = delta
+		}
+		event.Total = len(seenLinks)
+
+		emit(event)
+	}
+
+	if err := ctx.Err(); err != nil {
+		observeSearch(sourceType, "stream", start, err)
+		return
+	}
+
+	var tgResults, pluginResults []model.SearchResult
+	for _, source := range sourceOrder {
+		outcome := latestBySource[source]
+		if outcome.IsTG {
+			tgResults = append(tgResults, outcome.Results...)
+		} else {
+			pluginResults = append(pluginResults, outcome.Results...)
+		}
+	}
+
+	// 新鲜搜索的结果写回主缓存，普通搜索接口可直接复用
+	if tgFresh {
+		storeSearchResults(tgCacheKey, tgResults, false)
+	}
+	if pluginFresh {
+		storeSearchResults(pluginCacheKey, pluginResults, true)
+	}
+
+	allResults := filterResultsByQuery(mergeSearchResults(tgResults, pluginResults), q)
+	sortResults(allResults, keyword, sortBy)
+	mergedLinks := filterLinksByQuery(mergeResultsByType(allResults, mergeKeyword(q), cloudTypes), q)
+
+	total := 0
+	for _, links := range mergedLinks {
+		total += len(links)
+	}
+
+	emit(model.SearchEvent{
+		Type:         model.SearchEventDone,
+		Count:        len(allResults),
+		ElapsedMs:    time.Since(start).Milliseconds(),
+		IsFinal:      true,
+		Total:        total,
+		MergedByType: mergedLinks,
+	})
+	observeSearch(sourceType, "stream", start, nil)
+}
+
+// streamTG 为流式搜索启动TG频道搜索，缓存命中时按频道拆分缓存结果
+func (s *SearchService) streamTG(ctx context.Context, keyword string, channels []string, cacheKey string, forceRefresh bool, cold *coldSearchTicket, semaphore chan struct{}, wg *sync.WaitGroup, outcomes chan<- sourceOutcome) {
+	if !forceRefresh {
+		if cached, freshness, hit := loadCachedResults(cacheKey, s.refreshTGCache(keyword, channels)); hit {
+			byChannel := make(map[string][]model.SearchResult)
+			for _, result := range cached {
+				byChannel[result.Channel] = append(byChannel[result.Channel], result)
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, channel := range channels {
+					outcomes <- sourceOutcome{
+						Source:  "tg:" + channel,
+						Results: byChannel[channel],
+						IsFinal: true,
+						Cached:  true,
+						Stale:   freshness.Stale,
+						IsTG:    true,
+					}
+				}
+			}()
+			return
+		}
+	}
+
+	if err := cold.acquire(); err != nil {
+		rejectStreamSources(tgSources(channels), err, true, wg, outcomes)
+		return
+	}
+
+	for _, channel := range channels {
+		ch := channel // 创建副本，避免闭包问题
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			started := time.Now()
+			results, err := s.searchChannel(ctx, keyword, ch)
+			outcomes <- sourceOutcome{
+				Source:  "tg:" + ch,
+				Results: results,
+				Elapsed: time.Since(started),
+				Err:     err,
+				IsFinal: true,
+				IsTG:    true,
+			}
+		}()
+	}
+}
+
+// streamPlugins 为流式搜索启动插件搜索，缓存命中时按插件拆分缓存结果
+func (s *SearchService) streamPlugins(ctx context.Context, keyword string, plugins []string, cacheKey string, forceRefresh bool, ext map[string]interface{}, cold *coldSearchTicket, semaphore chan struct{}, wg *sync.WaitGroup, outcomes chan<- sourceOutcome) {
+	availablePlugins := s.resolvePlugins(plugins)
+
+	if !forceRefresh {
+		if cached, freshness, hit := loadCachedResults(cacheKey, s.refreshPluginCache(keyword, plugins, 0, ext)); hit {
+			bySource := make(map[string][]model.SearchResult)
+			for _, result := range cached {
+				source := getResultSource(result)
+				bySource[source] = append(bySource[source], result)
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, p := range availablePlugins {
+					source := "plugin:" + p.Name()
+					outcomes <- sourceOutcome{
+						Source:  source,
+						Results: bySource[source],
+						IsFinal: true,
+						Cached:  true,
+						Stale:   freshness.Stale,
+					}
+				}
+			}()
+			return
+		}
+	}
+
+	if err := cold.acquire(); err != nil {
+		rejectStreamSources(pluginSources(availablePlugins), err, false, wg, outcomes)
+		return
+	}
+
+	// 熔断中的插件直接推送错误，不再等待
+	availablePlugins, skippedPlugins := s.splitByCircuit(availablePlugins)
+	if len(skippedPlugins) > 0 {
+		rejectStreamSources(pluginSources(skippedPlugins), plugin.ErrCircuitOpen, false, wg, outcomes)
+	}
+
+	for _, p := range availablePlugins {
+		searchPlugin := p // 创建副本，避免闭包问题
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			source := "plugin:" + searchPlugin.Name()
+			started := time.Now()
+			s.searchPluginUntilFinal(ctx, searchPlugin, keyword, cacheKey, ext, func(results []model.SearchResult, isFinal bool, err error) {
+				outcomes <- sourceOutcome{
+					Source:  source,
+					Results: filterResultsWithLinks(results),
+					Elapsed: time.Since(started),
+					Err:     err,
+					IsFinal: isFinal,
+				}
+			})
+		}()
+	}
+}
+
+// rejectStreamSources 冷搜索被拒绝或插件熔断时，为每个来源推送带错误的最终结果
+func rejectStreamSources(sources []string, err error, isTG bool, wg *sync.WaitGroup, outcomes chan<- sourceOutcome) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, source := range sources {
+			outcomes <- sourceOutcome{Source: source, Err: err, IsFinal: true, IsTG: isTG}
+		}
+	}()
+}
+
+// searchPluginUntilFinal 执行单个插件搜索，插件超时转入后台或刷新过期缓存时继续等待其后台结果
+// report可能被调用两次：先报告已有的部分结果，再报告最终结果
+func (s *SearchService) searchPluginUntilFinal(ctx context.Context, p plugin.AsyncSearchPlugin, keyword string, cacheKey string, ext map[string]interface{}, report func([]model.SearchResult, bool, error)) {
+	// 先订阅后台结果，避免在插件调用期间错过推送
+	updates, cancel := globalResultNotifier.subscribe(cacheKey, p.Name())
+	defer cancel()
+
+	p.SetMainCacheKey(cacheKey)
+	p.SetCurrentKeyword(keyword)
+
+	// 不支持IsFinal标记的插件由适配器标记为最终结果
+	result, err := plugin.SearchWithContext(ctx, p, keyword, ext)
+	if err != nil || result.IsFinal {
+		report(result.Results, true, err)
+		return
+	}
+
+	// 插件没有进行中的后台搜索时（如部分结果来自插件缓存且没有触发刷新），之后不会再有后台结果，
+	// 直接以当前结果结束；后台搜索刚好已经完成时，其结果已在订阅通道中
+	background := plugin.BackgroundSearchDone(p.Name(), cacheKey)
+	if background == nil {
+		select {
+		case update := <-updates:
+			report(update.Results, true, nil)
+		default:
+			report(result.Results, true, nil)
+		}
+		return
+	}
+
+	// 已有部分结果（如过期缓存）时先行推送
+	if len(result.Results) > 0 {
+		report(result.Results, false, nil)
+	}
+
+	timer := time.NewTimer(config.GetPluginTimeout())
+	defer timer.Stop()
+
+	select {
+	case update := <-updates:
+		report(update.Results, true, nil)
+	case <-background:
+		// 后台搜索结束但没有推送结果（搜索失败或结果未变化），以当前结果结束
+		select {
+		case update := <-updates:
+			report(update.Results, true, nil)
+		default:
+			report(result.Results, true, nil)
+		}
+	case <-timer.C:
+		report(result.Results, false, fmt.Errorf("等待后台结果超时(%v)", config.GetPluginTimeout()))
+	case <-ctx.Done():
+		report(result.Results, false, ctx.Err())
+	}
+}
+
+// filterResultsWithLinks 过滤掉没有链接的结果
+func filterResultsWithLinks(results []model.SearchResult) []model.SearchResult {
+	filtered := make([]model.SearchResult, 0, len(results))
+	for _, result := range results {
+		if len(result.Links) > 0 {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// loadCachedResults 从主缓存读取搜索结果
+// 设置了CACHE_STALE_TTL时，超过CACHE_TTL的缓存仍然返回并标记为过期，同时在后台调用refresh刷新
+func loadCachedResults(cacheKey string, refresh func()) ([]model.SearchResult, cache.Freshness, bool) {
+	if !cacheInitialized || !config.AppConfig.CacheEnabled || enhancedTwoLevelCache == nil {
+		return nil, cache.Freshness{}, false
+	}
+
+	data, freshness, hit := enhancedTwoLevelCache.GetWithRevalidate(cacheKey, cacheStaleAfter(), refresh)
+	if !hit {
+		return nil, cache.Freshness{}, false
+	}
+
+	var results []model.SearchResult
+	if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err != nil {
+		fmt.Printf("[主服务] 缓存反序列化失败: %s | 错误: %v\n", cacheKey, err)
+		return nil, cache.Freshness{}, false
+	}
+	return results, freshness, true
+}
+
+// storeSearchResults 将搜索结果写入主缓存，bothLevels为true时同步写入磁盘
+func storeSearchResults(cacheKey string, results []model.SearchResult, bothLevels bool) {
+	if !cacheInitialized || !config.AppConfig.CacheEnabled || enhancedTwoLevelCache == nil {
+		return
+	}
+
+	data, err := enhancedTwoLevelCache.GetSerializer().Serialize(results)
+	if err != nil {
+		fmt.Printf("[主程序] 缓存序列化失败: %s | 错误: %v\n", cacheKey, err)
+		return
+	}
+
+	ttl := config.CacheHardTTL()
+	if bothLevels {
+		enhancedTwoLevelCache.SetBothLevels(cacheKey, data, ttl)
+	} else {
+		enhancedTwoLevelCache.Set(cacheKey, data, ttl)
+	}
+}
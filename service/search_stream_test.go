@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+// partialPlugin 返回非最终结果但没有后台搜索的插件，模拟结果来自插件缓存且没有触发刷新
+type partialPlugin struct {
+	stubPlugin
+}
+
+func (p *partialPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return model.PluginSearchResult{Results: p.results, IsFinal: false, Source: p.name}, nil
+}
+
+// slowAsyncPlugin 基于BaseAsyncPlugin的插件，搜索耗时超过响应超时后转入后台完成
+type slowAsyncPlugin struct {
+	*plugin.BaseAsyncPlugin
+	delay   time.Duration
+	results []model.SearchResult
+}
+
+func (p *slowAsyncPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	return result.Results, err
+}
+
+func (p *slowAsyncPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, func(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+		time.Sleep(p.delay)
+		return p.results, nil
+	}, p.MainCacheKey, ext)
+}
+
+// collectStream 执行流式搜索，返回全部事件和耗时
+func collectStream(s *SearchService, keyword string) ([]model.SearchEvent, time.Duration) {
+	var events []model.SearchEvent
+	started := time.Now()
+	s.SearchStream(context.Background(), keyword, nil, 0, true, "plugin", nil, nil, "", nil, func(event model.SearchEvent) {
+		events = append(events, event)
+	})
+	return events, time.Since(started)
+}
+
+// 非最终结果没有进行中的后台搜索时，不等待插件超时
+func TestSearchStreamDoesNotWaitWithoutBackgroundSearch(t *testing.T) {
+	cfg := testConfig()
+	cfg.PluginTimeout = 5 * time.Second
+	s := setupTestService(t, cfg, &partialPlugin{stubPlugin{name: "partial", results: stubResults("partial", 2)}})
+
+	events, elapsed := collectStream(s, "测试资源")
+	if elapsed >= time.Second {
+		t.Fatalf("流式搜索耗时%v，不应等待插件超时", elapsed)
+	}
+	if len(events) != 2 || events[0].Type != model.SearchEventSource || events[1].Type != model.SearchEventDone {
+		t.Fatalf("应推送一个source事件和done事件，实际: %+v", events)
+	}
+	if source := events[0]; !source.IsFinal || source.Error != "" || source.Count != 2 {
+		t.Fatalf("source事件应为无错误的最终结果且有2个结果，实际: %+v", source)
+	}
+}
+
+// 插件超时转入后台时，等待后台结果后再结束
+func TestSearchStreamWaitsForBackgroundSearch(t *testing.T) {
+	cfg := testConfig()
+	cfg.CacheEnabled = true
+	cfg.AsyncResponseTimeoutDur = 50 * time.Millisecond
+	p := &slowAsyncPlugin{
+		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter("slowasync", 2, true),
+		delay:           300 * time.Millisecond,
+		results:         stubResults("slowasync", 3),
+	}
+	s := setupTestService(t, cfg, p)
+
+	events, elapsed := collectStream(s, "测试资源")
+	if elapsed >= cfg.PluginTimeout {
+		t.Fatalf("流式搜索耗时%v，应在后台搜索完成后结束", elapsed)
+	}
+	done := events[len(events)-1]
+	if done.Type != model.SearchEventDone || done.Total != 3 {
+		t.Fatalf("done事件应包含后台搜索的3个链接，实际: %+v", done)
+	}
+	if plugin.BackgroundSearchDone("slowasync", "") != nil {
+		t.Fatal("未设置主缓存键时不应登记后台搜索")
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+	"pansou/util/cache"
+)
+
+// testConfig 测试用配置，不访问外部服务
+func testConfig() *config.Config {
+	return &config.Config{
+		DefaultConcurrency:      4,
+		PluginTimeout:           2 * time.Second,
+		AsyncResponseTimeout:    1,
+		AsyncResponseTimeoutDur: time.Second,
+		AsyncPluginEnabled:      true,
+		AsyncCacheTTLHours:      1,
+		CacheTTLMinutes:         60,
+	}
+}
+
+// setupTestService 使用给定配置和插件创建搜索服务，测试结束后恢复全局状态
+// cfg.CacheEnabled为true时主缓存使用临时目录
+func setupTestService(t *testing.T, cfg *config.Config, plugins ...plugin.AsyncSearchPlugin) *SearchService {
+	t.Helper()
+	previousConfig := config.AppConfig
+	previousCache, previousInitialized := enhancedTwoLevelCache, cacheInitialized
+	t.Cleanup(func() {
+		config.AppConfig = previousConfig
+		enhancedTwoLevelCache, cacheInitialized = previousCache, previousInitialized
+	})
+
+	config.AppConfig = cfg
+	enhancedTwoLevelCache, cacheInitialized = nil, false
+	if cfg.CacheEnabled {
+		cfg.CachePath = t.TempDir()
+		if cfg.CacheMaxSizeMB == 0 {
+			cfg.CacheMaxSizeMB = 10
+		}
+		mainCache, err := cache.NewEnhancedTwoLevelCache()
+		if err != nil {
+			t.Fatalf("创建缓存失败: %v", err)
+		}
+		enhancedTwoLevelCache, cacheInitialized = mainCache, true
+	}
+
+	manager := plugin.NewPluginManager()
+	for _, p := range plugins {
+		manager.RegisterPlugin(p)
+	}
+	return NewSearchService(manager)
+}
+
+// stubPlugin 测试用插件，返回固定结果；设置了block时在block关闭前不返回
+type stubPlugin struct {
+	name    string
+	results []model.SearchResult
+	started chan struct{} // 开始搜索时写入，可为nil
+	block   chan struct{} // 为nil时立即返回
+}
+
+func (p *stubPlugin) Name() string                     { return p.name }
+func (p *stubPlugin) Priority() int                    { return 2 }
+func (p *stubPlugin) SetMainCacheKey(key string)       {}
+func (p *stubPlugin) SetCurrentKeyword(keyword string) {}
+func (p *stubPlugin) SkipServiceFilter() bool          { return true }
+
+func (p *stubPlugin) AsyncSearch(keyword string, searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error), mainCacheKey string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	return p.Search(keyword, ext)
+}
+
+func (p *stubPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	if p.started != nil {
+		p.started <- struct{}{}
+	}
+	if p.block != nil {
+		<-p.block
+	}
+	return p.results, nil
+}
+
+// stubResults 生成n个带链接的结果
+func stubResults(source string, n int) []model.SearchResult {
+	results := make([]model.SearchResult, n)
+	for i := range results {
+		id := source + "-" + string(rune('a'+i))
+		results[i] = model.SearchResult{
+			UniqueID: id,
+			Title:    "测试资源 " + id,
+			Datetime: time.Date(2024, 5, 1, 8, i, 0, 0, time.UTC),
+			Links:    []model.Link{{Type: "quark", URL: "https://pan.quark.cn/s/" + id}},
+		}
+	}
+	return results
+}
+
+// roundTripFunc 用函数实现http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// stubTelegram 替换TG频道搜索使用的HTTP客户端，每个请求在handler返回后得到空的搜索页
+func stubTelegram(t *testing.T, handler func(*http.Request)) {
+	t.Helper()
+	previous := util.GetHTTPClient()
+	t.Cleanup(func() { util.SetHTTPClient(previous) })
+	util.SetHTTPClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if handler != nil {
+			handler(req)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/html"}},
+			Body:       io.NopCloser(strings.NewReader("<html><body></body></html>")),
+			Request:    req,
+		}, nil
+	})})
+}
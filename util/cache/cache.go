@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"time"
+)
+
+// Cache 缓存后端，作为EnhancedTwoLevelCache内存缓存之后的一级
+// ShardedDiskCache（本地磁盘）和RedisCache（Redis协议的远程缓存）都实现该接口
+type Cache interface {
+	// Set 写入缓存，ttl小于等于0时不过期
+	Set(key string, data []byte, ttl time.Duration) error
+	// Get 读取缓存，不存在或已过期时返回false
+	Get(key string) ([]byte, bool, error)
+	// GetLastModified 获取缓存项的写入时间
+	GetLastModified(key string) (time.Time, bool)
+	// Delete 删除缓存
+	Delete(key string) error
+	// Clear 清空缓存
+	Clear() error
+	// Size 当前占用的字节数，无法统计时返回0
+	Size() int64
+}
+
+// timestampedCache 可以在一次读取中同时返回写入时间的后端，远程缓存借此减少往返
+type timestampedCache interface {
+	GetWithTimestamp(key string) ([]byte, time.Time, bool, error)
+}
+
+// getWithTimestamp 读取缓存及其写入时间
+func getWithTimestamp(c Cache, key string) ([]byte, time.Time, bool, error) {
+	if tc, ok := c.(timestampedCache); ok {
+		return tc.GetWithTimestamp(key)
+	}
+	data, hit, err := c.Get(key)
+	if err != nil || !hit {
+		return nil, time.Time{}, false, err
+	}
+	lastModified, _ := c.GetLastModified(key)
+	return data, lastModified, true, nil
+}
@@ -46,6 +46,15 @@ func init() {
 	precomputedHashes.Store("all_channels", allChannelsHash)
 }
 
+// SetDefaultPluginSet 设置未指定插件时使用的插件集合
+// 插件集合变化后，未指定插件的搜索使用新的缓存键，旧插件集合的缓存不再命中
+func SetDefaultPluginSet(names []string) {
+	sorted := make([]string, len(names))
+	copy(sorted, names)
+	sort.Strings(sorted)
+	precomputedHashes.Store("all_plugins", calculateListHash(sorted))
+}
+
 // GenerateTGCacheKey 为TG搜索生成缓存键
 func GenerateTGCacheKey(keyword string, channels []string) string {
 	// 关键词标准化
@@ -3,7 +3,6 @@ package cache
 import (
 	"context"
 	"fmt"
-	"os"
 	"runtime"
 	"sort"
 	"strconv"
@@ -12,6 +11,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"pansou/config"
 	"pansou/model"
 )
 
@@ -98,34 +98,34 @@ func (c *CacheWriteConfig) Initialize() error {
 	return c.validateAndConstraint()
 }
 
-// loadFromEnvironment 从环境变量加载配置
+// loadFromEnvironment 从环境变量（或配置文件）加载配置
 func (c *CacheWriteConfig) loadFromEnvironment() {
 	// 策略配置
-	if strategy := os.Getenv("CACHE_WRITE_STRATEGY"); strategy != "" {
+	if strategy := config.Getenv("CACHE_WRITE_STRATEGY"); strategy != "" {
 		c.Strategy = CacheWriteStrategy(strategy)
 	}
 	
 	// 批量写入参数
-	if interval := os.Getenv("BATCH_MAX_INTERVAL"); interval != "" {
+	if interval := config.Getenv("BATCH_MAX_INTERVAL"); interval != "" {
 		if d, err := time.ParseDuration(interval); err == nil {
 			c.MaxBatchInterval = d
 		}
 	}
 	
-	if size := os.Getenv("BATCH_MAX_SIZE"); size != "" {
+	if size := config.Getenv("BATCH_MAX_SIZE"); size != "" {
 		if s, err := strconv.Atoi(size); err == nil {
 			c.MaxBatchSize = s
 		}
 	}
 	
-	if dataSize := os.Getenv("BATCH_MAX_DATA_SIZE"); dataSize != "" {
+	if dataSize := config.Getenv("BATCH_MAX_DATA_SIZE"); dataSize != "" {
 		if ds, err := strconv.Atoi(dataSize); err == nil {
 			c.MaxBatchDataSize = ds
 		}
 	}
 	
 	// 行为参数
-	if ratio := os.Getenv("HIGH_PRIORITY_RATIO"); ratio != "" {
+	if ratio := config.Getenv("HIGH_PRIORITY_RATIO"); ratio != "" {
 		if r, err := strconv.ParseFloat(ratio, 64); err == nil {
 			c.HighPriorityRatio = r
 		}
@@ -965,6 +965,11 @@ func (m *DelayedBatchWriteManager) GetStats() map[string]interface{} {
 	return combinedStats
 }
 
+// GetGlobalBufferStats 获取全局缓冲区统计
+func (m *DelayedBatchWriteManager) GetGlobalBufferStats() *GlobalBufferStats {
+	return m.globalBufferManager.GetStats()
+}
+
 // GetWriteManagerStats 获取写入管理器统计（兼容性方法）
 func (m *DelayedBatchWriteManager) GetWriteManagerStats() *WriteManagerStats {
 	stats := *m.stats
@@ -349,6 +349,13 @@ func (c *DiskCache) Clear() error {
 	return nil
 } 
 
+// Size 当前占用的字节数
+func (c *DiskCache) Size() int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.currSize
+}
+
 // GetLastModified 获取缓存项的最后修改时间
 func (c *DiskCache) GetLastModified(key string) (time.Time, bool) {
 	c.mutex.RLock()
@@ -3,17 +3,23 @@ package cache
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"pansou/config"
 )
 
 // EnhancedTwoLevelCache 改进的两级缓存
+// 内存缓存之后为第二级（本地磁盘缓存，或CACHE_REMOTE_MODE=l2时的远程缓存），
+// CACHE_REMOTE_MODE=l3时远程缓存作为多个实例共享的第三级
 type EnhancedTwoLevelCache struct {
 	memory     *ShardedMemoryCache
-	disk       *ShardedDiskCache
+	disk       Cache
+	remote     Cache // 第三级缓存，未启用时为nil
 	mutex      sync.RWMutex
 	serializer Serializer
+	refreshing sync.Map // 正在后台刷新的键
+	partial    sync.Map // 只写入内存的不完整结果的键，第三级缓存中可能有其他实例写入的更新的最终结果
 }
 
 // NewEnhancedTwoLevelCache 创建新的改进两级缓存
@@ -25,23 +31,45 @@ func NewEnhancedTwoLevelCache() (*EnhancedTwoLevelCache, error) {
 	memCache := NewShardedMemoryCache(memCacheMaxItems, memCacheSizeMB)
 	memCache.StartCleanupTask()
 
+	var remoteCache *RedisCache
+	if config.AppConfig.CacheRemoteURL != "" {
+		opts, err := ParseRedisURL(config.AppConfig.CacheRemoteURL)
+		if err != nil {
+			return nil, err
+		}
+		opts.Prefix = config.AppConfig.CacheRemotePrefix
+		opts.Compress = config.AppConfig.CacheRemoteCompress
+		remoteCache = NewRedisCache(opts)
+		// 启动时检查连接，不可用时记录日志但不影响服务，之后按需重连
+		_ = remoteCache.Ping()
+	}
+
+	if remoteCache != nil && config.AppConfig.CacheRemoteMode == "l2" {
+		return newEnhancedTwoLevelCache(memCache, remoteCache, nil), nil
+	}
+
 	// 创建优化的分片磁盘缓存，使用动态分片数量
 	diskCache, err := NewOptimizedShardedDiskCache(config.AppConfig.CachePath, config.AppConfig.CacheMaxSizeMB)
 	if err != nil {
 		return nil, err
 	}
+	if remoteCache != nil {
+		return newEnhancedTwoLevelCache(memCache, diskCache, remoteCache), nil
+	}
+	return newEnhancedTwoLevelCache(memCache, diskCache, nil), nil
+}
 
-	// 创建序列化器
-	serializer := NewGobSerializer()
-
+// newEnhancedTwoLevelCache 组合内存缓存、第二级缓存和可选的第三级缓存
+func newEnhancedTwoLevelCache(memCache *ShardedMemoryCache, disk Cache, remote Cache) *EnhancedTwoLevelCache {
 	// 设置内存缓存的磁盘缓存引用，用于LRU淘汰时的备份
-	memCache.SetDiskCacheReference(diskCache)
+	memCache.SetDiskCacheReference(disk)
 
 	return &EnhancedTwoLevelCache{
 		memory:     memCache,
-		disk:       diskCache,
-		serializer: serializer,
-	}, nil
+		disk:       disk,
+		remote:     remote,
+		serializer: NewGobSerializer(),
+	}
 }
 
 // Set 设置缓存
@@ -51,11 +79,15 @@ func (c *EnhancedTwoLevelCache) Set(key string, data []byte, ttl time.Duration)
 	
 	// 先设置内存缓存（这是快速操作，直接在当前goroutine中执行）
 	c.memory.SetWithTimestamp(key, data, ttl, now)
+	c.partial.Delete(key)
 	
 	// 异步设置磁盘缓存（这是IO操作，可能较慢）
 	go func(k string, d []byte, t time.Duration) {
 		// 使用独立的goroutine写入磁盘，避免阻塞调用者
 		_ = c.disk.Set(k, d, t)
+		if c.remote != nil {
+			_ = c.remote.Set(k, d, t)
+		}
 	}(key, data, ttl)
 	
 	return nil
@@ -67,6 +99,9 @@ func (c *EnhancedTwoLevelCache) SetMemoryOnly(key string, data []byte, ttl time.
 	
 	// 只更新内存缓存，不触发磁盘写入
 	c.memory.SetWithTimestamp(key, data, ttl, now)
+	if c.remote != nil {
+		c.partial.Store(key, struct{}{})
+	}
 	
 	return nil
 }
@@ -77,9 +112,18 @@ func (c *EnhancedTwoLevelCache) SetBothLevels(key string, data []byte, ttl time.
 	
 	// 同步更新内存缓存
 	c.memory.SetWithTimestamp(key, data, ttl, now)
+	c.partial.Delete(key)
 	
 	// 同步更新磁盘缓存，确保数据立即写入
-	return c.disk.Set(key, data, ttl)
+	err := c.disk.Set(key, data, ttl)
+	
+	// 最终结果同步写入共享缓存，其他实例可以直接使用
+	if c.remote != nil {
+		if remoteErr := c.remote.Set(key, data, ttl); remoteErr != nil && err == nil {
+			err = remoteErr
+		}
+	}
+	return err
 }
 
 // SetWithFinalFlag 根据结果状态选择更新策略
@@ -93,42 +137,128 @@ func (c *EnhancedTwoLevelCache) SetWithFinalFlag(key string, data []byte, ttl ti
 
 // Get 获取缓存
 func (c *EnhancedTwoLevelCache) Get(key string) ([]byte, bool, error) {
+	data, _, hit := c.get(key)
+	return data, hit, nil
+}
+
+// get 依次从内存、第二级、第三级缓存读取，返回数据和写入时间
+// 从下层命中时回填内存缓存，回填的有效期为写入时间起CACHE_TTL+CACHE_STALE_TTL的剩余部分
+func (c *EnhancedTwoLevelCache) get(key string) ([]byte, time.Time, bool) {
 	
 	// 检查内存缓存
-	data, _, memHit := c.memory.GetWithTimestamp(key)
+	data, lastModified, memHit := c.memory.GetWithTimestamp(key)
 	if memHit {
-		return data, true, nil
+		atomic.AddInt64(&memoryHits, 1)
+		// 本地只有不完整的结果时，优先使用其他实例之后写入共享缓存的最终结果
+		if _, partial := c.partial.Load(key); partial {
+			if remoteData, remoteLastModified, ok := c.getNewerRemote(key, lastModified); ok {
+				return remoteData, remoteLastModified, true
+			}
+		}
+		return data, lastModified, true
 	}
+	atomic.AddInt64(&memoryMisses, 1)
+	c.partial.Delete(key)
 
     // 尝试从磁盘读取数据
-	diskData, diskHit, diskErr := c.disk.Get(key)
+	diskData, diskLastModified, diskHit, diskErr := getWithTimestamp(c.disk, key)
 	if diskErr == nil && diskHit {
+		atomic.AddInt64(&diskHits, 1)
 		// 磁盘缓存命中，更新内存缓存
-		diskLastModified, _ := c.disk.GetLastModified(key)
-		ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
-		c.memory.SetWithTimestamp(key, diskData, ttl, diskLastModified)
-		return diskData, true, nil
+		c.promote(key, diskData, diskLastModified)
+		return diskData, diskLastModified, true
+	}
+	atomic.AddInt64(&diskMisses, 1)
+	
+	// 尝试从共享的远程缓存读取（可能由其他实例写入）
+	if c.remote != nil {
+		remoteData, remoteLastModified, remoteHit, remoteErr := getWithTimestamp(c.remote, key)
+		if remoteErr == nil && remoteHit {
+			atomic.AddInt64(&remoteHits, 1)
+			c.promote(key, remoteData, remoteLastModified)
+			return remoteData, remoteLastModified, true
+		}
+		atomic.AddInt64(&remoteMisses, 1)
 	}
 	
-	return nil, false, nil
+	return nil, time.Time{}, false
+}
+
+// getNewerRemote 远程缓存中的数据比本地写入时间更新时读取并回填内存缓存
+// 只读取写入时间判断，远程数据不比本地新时不传输数据本身
+func (c *EnhancedTwoLevelCache) getNewerRemote(key string, localModified time.Time) ([]byte, time.Time, bool) {
+	remoteModified, ok := c.remote.GetLastModified(key)
+	if !ok || !remoteModified.After(localModified) {
+		return nil, time.Time{}, false
+	}
+	data, lastModified, hit, err := getWithTimestamp(c.remote, key)
+	if err != nil || !hit {
+		return nil, time.Time{}, false
+	}
+	atomic.AddInt64(&remoteHits, 1)
+	c.partial.Delete(key)
+	c.promote(key, data, lastModified)
+	return data, lastModified, true
+}
+
+// promote 将下层缓存命中的数据回填到内存缓存，保留原来的写入时间
+func (c *EnhancedTwoLevelCache) promote(key string, data []byte, lastModified time.Time) {
+	ttl := config.CacheHardTTL()
+	if !lastModified.IsZero() {
+		ttl -= time.Since(lastModified)
+	}
+	if ttl > 0 {
+		c.memory.SetWithTimestamp(key, data, ttl, lastModified)
+	}
+}
+
+// MemorySize 内存缓存当前占用的字节数
+func (c *EnhancedTwoLevelCache) MemorySize() int64 {
+	return c.memory.Size()
+}
+
+// DiskSize 磁盘缓存当前占用的字节数
+func (c *EnhancedTwoLevelCache) DiskSize() int64 {
+	return c.disk.Size()
 }
 
 // Delete 删除缓存
 func (c *EnhancedTwoLevelCache) Delete(key string) error {
 	// 从内存缓存删除
 	c.memory.Delete(key)
+	c.partial.Delete(key)
 	
 	// 从磁盘缓存删除
-	return c.disk.Delete(key)
+	err := c.disk.Delete(key)
+	
+	// 从远程缓存删除
+	if c.remote != nil {
+		if remoteErr := c.remote.Delete(key); remoteErr != nil && err == nil {
+			err = remoteErr
+		}
+	}
+	return err
 }
 
 // Clear 清空所有缓存
 func (c *EnhancedTwoLevelCache) Clear() error {
 	// 清空内存缓存
 	c.memory.Clear()
+	c.partial.Range(func(key, _ interface{}) bool {
+		c.partial.Delete(key)
+		return true
+	})
 	
 	// 清空磁盘缓存
-	return c.disk.Clear()
+	err := c.disk.Clear()
+	
+	// 清空远程缓存中本服务的键
+	if c.remote != nil {
+		if remoteErr := c.remote.Clear(); remoteErr != nil && err == nil {
+			err = remoteErr
+		}
+	}
+	return err
 }
 
 // 设置序列化器
@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"pansou/model"
+)
+
+// inspectableCache 可以查看单个缓存项状态和整体统计的后端
+type inspectableCache interface {
+	Inspect(key string) (model.CacheLayerEntry, bool)
+	Stats() model.CacheLayerStats
+}
+
+// keyLister 可以列出所有键的后端，远程缓存不实现
+type keyLister interface {
+	Keys() []string
+}
+
+// layerEntry 根据过期时间生成缓存层状态，expiry为零值表示不过期
+func layerEntry(layer string, size int, lastModified, expiry time.Time) model.CacheLayerEntry {
+	entry := model.CacheLayerEntry{
+		Layer:        layer,
+		Size:         size,
+		LastModified: lastModified,
+		TTLSeconds:   -1,
+	}
+	if !expiry.IsZero() {
+		entry.ExpiresAt = expiry
+		entry.TTLSeconds = int64(time.Until(expiry).Seconds())
+	}
+	return entry
+}
+
+// Inspect 查看缓存项状态，不更新最后使用时间
+func (c *ShardedMemoryCache) Inspect(key string) (model.CacheLayerEntry, bool) {
+	shard := c.getShard(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	item, exists := shard.items[key]
+	if !exists || time.Now().After(item.expiry) {
+		return model.CacheLayerEntry{}, false
+	}
+	return layerEntry(model.CacheLayerMemory, item.size, item.lastModified, item.expiry), true
+}
+
+// Stats 各分片的条目数和字节数，包括尚未清理的过期项
+func (c *ShardedMemoryCache) Stats() model.CacheLayerStats {
+	stats := model.CacheLayerStats{Layer: model.CacheLayerMemory, Shards: make([]model.CacheShardStats, 0, len(c.shards))}
+	for _, shard := range c.shards {
+		shard.mutex.RLock()
+		shardStats := model.CacheShardStats{Items: len(shard.items), Bytes: atomic.LoadInt64(&shard.currSize)}
+		shard.mutex.RUnlock()
+
+		stats.Items += shardStats.Items
+		stats.Bytes += shardStats.Bytes
+		stats.Shards = append(stats.Shards, shardStats)
+	}
+	return stats
+}
+
+// Keys 所有未过期的键
+func (c *ShardedMemoryCache) Keys() []string {
+	now := time.Now()
+	var keys []string
+	for _, shard := range c.shards {
+		shard.mutex.RLock()
+		for key, item := range shard.items {
+			if !now.After(item.expiry) {
+				keys = append(keys, key)
+			}
+		}
+		shard.mutex.RUnlock()
+	}
+	return keys
+}
+
+// inspect 查看缓存项的元数据
+func (c *DiskCache) inspect(key string) (model.CacheLayerEntry, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	meta, exists := c.metadata[key]
+	if !exists || time.Now().After(meta.Expiry) {
+		return model.CacheLayerEntry{}, false
+	}
+	return layerEntry(model.CacheLayerDisk, meta.Size, meta.LastModified, meta.Expiry), true
+}
+
+// stats 条目数和字节数
+func (c *DiskCache) stats() model.CacheShardStats {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return model.CacheShardStats{Items: len(c.metadata), Bytes: c.currSize}
+}
+
+// keys 所有未过期的键
+func (c *DiskCache) keys() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(c.metadata))
+	for key, meta := range c.metadata {
+		if !now.After(meta.Expiry) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Inspect 查看缓存项状态，不读取数据文件
+func (c *ShardedDiskCache) Inspect(key string) (model.CacheLayerEntry, bool) {
+	return c.getShard(key).inspect(key)
+}
+
+// Stats 各分片的条目数和字节数
+func (c *ShardedDiskCache) Stats() model.CacheLayerStats {
+	stats := model.CacheLayerStats{Layer: model.CacheLayerDisk, Shards: make([]model.CacheShardStats, 0, len(c.shards))}
+	for _, shard := range c.shards {
+		shardStats := shard.stats()
+		stats.Items += shardStats.Items
+		stats.Bytes += shardStats.Bytes
+		stats.Shards = append(stats.Shards, shardStats)
+	}
+	return stats
+}
+
+// Keys 所有未过期的键
+func (c *ShardedDiskCache) Keys() []string {
+	var keys []string
+	for _, shard := range c.shards {
+		keys = append(keys, shard.keys()...)
+	}
+	return keys
+}
+
+// Inspect 查看缓存项状态：存储的大小、写入时间和剩余有效期
+func (c *RedisCache) Inspect(key string) (model.CacheLayerEntry, bool) {
+	lastModified, ok := c.GetLastModified(key)
+	if !ok {
+		return model.CacheLayerEntry{}, false
+	}
+	reply, err := c.do("STRLEN", c.opts.Prefix+key)
+	if err != nil {
+		return model.CacheLayerEntry{}, false
+	}
+	size, _ := reply.(int64)
+
+	var expiry time.Time
+	if reply, err := c.do("PTTL", c.opts.Prefix+key); err == nil {
+		if ms, ok := reply.(int64); ok && ms >= 0 {
+			expiry = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+	}
+	return layerEntry(model.CacheLayerRemote, int(size), lastModified, expiry), true
+}
+
+// Stats 带前缀的键的数量，需要遍历键空间；占用的字节数由服务端统计，这里为0
+func (c *RedisCache) Stats() model.CacheLayerStats {
+	stats := model.CacheLayerStats{Layer: model.CacheLayerRemote}
+	_ = c.scan(func(keys []interface{}) error {
+		stats.Items += len(keys)
+		return nil
+	})
+	return stats
+}
+
+// scan 遍历带前缀的所有键，每批键调用一次fn
+func (c *RedisCache) scan(fn func(keys []interface{}) error) error {
+	pattern := escapeRedisPattern(c.opts.Prefix) + "*"
+	cursor := "0"
+	for {
+		reply, err := c.do("SCAN", cursor, "MATCH", pattern, "COUNT", strconv.Itoa(redisScanCount))
+		if err != nil {
+			return err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return fmt.Errorf("远程缓存SCAN返回了意外的结果")
+		}
+		next, _ := parts[0].([]byte)
+		keys, _ := parts[1].([]interface{})
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// Inspect 查看键在各层中的状态，按内存、第二级、第三级的顺序返回存在该键的层
+func (c *EnhancedTwoLevelCache) Inspect(key string) []model.CacheLayerEntry {
+	entries := []model.CacheLayerEntry{}
+	if entry, ok := c.memory.Inspect(key); ok {
+		entries = append(entries, entry)
+	}
+	for _, layer := range []Cache{c.disk, c.remote} {
+		if ic, ok := layer.(inspectableCache); ok {
+			if entry, ok := ic.Inspect(key); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// Stats 各层的条目数和字节数
+func (c *EnhancedTwoLevelCache) Stats() []model.CacheLayerStats {
+	stats := []model.CacheLayerStats{c.memory.Stats()}
+	for _, layer := range []Cache{c.disk, c.remote} {
+		if ic, ok := layer.(inspectableCache); ok {
+			stats = append(stats, ic.Stats())
+		}
+	}
+	return stats
+}
+
+// Keys 内存和本地磁盘中所有未过期的键（已排序）
+// 远程缓存中的键无法区分用途，不包括只存在于远程缓存的键
+func (c *EnhancedTwoLevelCache) Keys() []string {
+	seen := make(map[string]bool)
+	for _, key := range c.memory.Keys() {
+		seen[key] = true
+	}
+	if kl, ok := c.disk.(keyLister); ok {
+		for _, key := range kl.Keys() {
+			seen[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Peek 读取缓存但不回填内存缓存，用于查看缓存内容
+func (c *EnhancedTwoLevelCache) Peek(key string) ([]byte, bool) {
+	if data, ok := c.memory.peek(key); ok {
+		return data, true
+	}
+	for _, layer := range []Cache{c.disk, c.remote} {
+		if layer == nil {
+			continue
+		}
+		if data, hit, err := layer.Get(key); err == nil && hit {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// peek 读取缓存项，不更新最后使用时间
+func (c *ShardedMemoryCache) peek(key string) ([]byte, bool) {
+	shard := c.getShard(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	item, exists := shard.items[key]
+	if !exists || time.Now().After(item.expiry) {
+		return nil, false
+	}
+	return item.data, true
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"sync/atomic"
+
+	"pansou/util/metrics"
+)
+
+// 两级缓存各层的查询统计，所有EnhancedTwoLevelCache实例共用
+// 远程缓存作为第二级（l2模式）时计入disk，作为共享的第三级时计入remote
+var (
+	memoryHits   int64
+	memoryMisses int64
+	diskHits     int64
+	diskMisses   int64
+	remoteHits   int64
+	remoteMisses int64
+)
+
+func init() {
+	metrics.NewCounterVecFunc("pansou_cache_lookups_total", "两级缓存各层的查询次数", []string{"layer", "result"},
+		func(emit func(float64, ...string)) {
+			emit(float64(atomic.LoadInt64(&memoryHits)), "memory", "hit")
+			emit(float64(atomic.LoadInt64(&memoryMisses)), "memory", "miss")
+			emit(float64(atomic.LoadInt64(&diskHits)), "disk", "hit")
+			emit(float64(atomic.LoadInt64(&diskMisses)), "disk", "miss")
+			emit(float64(atomic.LoadInt64(&remoteHits)), "remote", "hit")
+			emit(float64(atomic.LoadInt64(&remoteMisses)), "remote", "miss")
+		})
+	metrics.NewGaugeVecFunc("pansou_cache_hit_ratio", "两级缓存各层自启动以来的命中率", []string{"layer"},
+		func(emit func(float64, ...string)) {
+			emit(hitRatio(&memoryHits, &memoryMisses), "memory")
+			emit(hitRatio(&diskHits, &diskMisses), "disk")
+			emit(hitRatio(&remoteHits, &remoteMisses), "remote")
+		})
+}
+
+// hitRatio 计算命中率，没有查询时为0
+func hitRatio(hits, misses *int64) float64 {
+	h := atomic.LoadInt64(hits)
+	total := h + atomic.LoadInt64(misses)
+	if total == 0 {
+		return 0
+	}
+	return float64(h) / float64(total)
+}
@@ -0,0 +1,478 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	redisEntryHeaderSize  = 9               // 值的头部：标志位1字节 + 写入时间8字节
+	redisFlagGzip         = 1               // 值经过gzip压缩
+	redisCompressMinBytes = 1024            // 达到该大小的值才压缩
+	redisRetryInterval    = 5 * time.Second // 连接失败后暂停访问的时间，避免每次读写都等待连接超时
+	redisScanCount        = 500             // Clear时每次SCAN的数量
+)
+
+// ErrRemoteUnavailable 远程缓存连接失败后的暂停期内直接返回该错误
+var ErrRemoteUnavailable = errors.New("远程缓存暂不可用")
+
+// RedisOptions 远程缓存连接参数
+type RedisOptions struct {
+	Addr        string        // 主机:端口
+	Username    string        // ACL用户名，为空时只用密码认证
+	Password    string        // 密码，为空时不认证
+	DB          int           // 库号
+	TLS         bool          // 是否使用TLS连接
+	Prefix      string        // 键前缀，多个部署共用一个库时用于区分，Clear只删除带该前缀的键
+	Compress    bool          // 是否gzip压缩1KB以上的值
+	PoolSize    int           // 最大连接数，默认10
+	DialTimeout time.Duration // 连接超时，默认2秒
+	IOTimeout   time.Duration // 单个命令的读写超时，默认2秒
+}
+
+// ParseRedisURL 解析远程缓存地址：redis://[[用户名]:密码@]主机[:端口][/库号]，rediss://使用TLS
+func ParseRedisURL(raw string) (RedisOptions, error) {
+	var opts RedisOptions
+	u, err := url.Parse(raw)
+	if err != nil {
+		return opts, fmt.Errorf("远程缓存地址无效: %w", err)
+	}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		opts.TLS = true
+	default:
+		return opts, fmt.Errorf("远程缓存地址只支持redis://和rediss://: %s", u.Redacted())
+	}
+	if u.Hostname() == "" {
+		return opts, fmt.Errorf("远程缓存地址缺少主机: %s", u.Redacted())
+	}
+	opts.Addr = u.Host
+	if u.Port() == "" {
+		opts.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("远程缓存库号无效: %s", db)
+		}
+		opts.DB = n
+	}
+	return opts, nil
+}
+
+// RedisCache 使用Redis协议（Redis、KeyDB、Valkey等）的远程缓存
+// 可以替代本地磁盘缓存作为第二级，也可以在磁盘缓存之后作为多个实例共享的第三级。
+// 值的前9字节记录压缩标志和写入时间，过期由服务端按TTL处理。
+type RedisCache struct {
+	opts      RedisOptions
+	idle      chan *redisConn
+	slots     chan struct{} // 限制同时使用的连接数
+	downUntil int64         // 暂停访问的截止时间（UnixNano）
+	closed    int32
+}
+
+// NewRedisCache 创建远程缓存，连接在首次使用时建立
+func NewRedisCache(opts RedisOptions) *RedisCache {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 2 * time.Second
+	}
+	if opts.IOTimeout <= 0 {
+		opts.IOTimeout = 2 * time.Second
+	}
+	return &RedisCache{
+		opts:  opts,
+		idle:  make(chan *redisConn, opts.PoolSize),
+		slots: make(chan struct{}, opts.PoolSize),
+	}
+}
+
+// Ping 检查远程缓存是否可用
+func (c *RedisCache) Ping() error {
+	_, err := c.do("PING")
+	return err
+}
+
+// Set 写入缓存，ttl小于等于0时不过期
+func (c *RedisCache) Set(key string, data []byte, ttl time.Duration) error {
+	value := c.encode(data, time.Now())
+	if ttl <= 0 {
+		_, err := c.do("SET", c.opts.Prefix+key, value)
+		return err
+	}
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	_, err := c.do("SET", c.opts.Prefix+key, value, "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+// Get 读取缓存
+func (c *RedisCache) Get(key string) ([]byte, bool, error) {
+	data, _, hit, err := c.GetWithTimestamp(key)
+	return data, hit, err
+}
+
+// GetWithTimestamp 读取缓存及其写入时间
+func (c *RedisCache) GetWithTimestamp(key string) ([]byte, time.Time, bool, error) {
+	reply, err := c.do("GET", c.opts.Prefix+key)
+	if err != nil || reply == nil {
+		return nil, time.Time{}, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, time.Time{}, false, fmt.Errorf("远程缓存返回了意外的类型: %T", reply)
+	}
+	data, modified, err := decodeRedisEntry(value)
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("远程缓存项%s无效: %w", key, err)
+	}
+	return data, modified, true, nil
+}
+
+// GetLastModified 获取缓存项的写入时间，只读取值的头部
+func (c *RedisCache) GetLastModified(key string) (time.Time, bool) {
+	reply, err := c.do("GETRANGE", c.opts.Prefix+key, "0", strconv.Itoa(redisEntryHeaderSize-1))
+	if err != nil {
+		return time.Time{}, false
+	}
+	header, ok := reply.([]byte)
+	if !ok || len(header) < redisEntryHeaderSize {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(header[1:redisEntryHeaderSize]))), true
+}
+
+// Delete 删除缓存
+func (c *RedisCache) Delete(key string) error {
+	_, err := c.do("DEL", c.opts.Prefix+key)
+	return err
+}
+
+// Clear 删除带前缀的所有键，前缀为空时删除库中的所有键
+func (c *RedisCache) Clear() error {
+	return c.scan(func(keys []interface{}) error {
+		args := make([]interface{}, 0, len(keys)+1)
+		args = append(args, "DEL")
+		args = append(args, keys...)
+		_, err := c.do(args...)
+		return err
+	})
+}
+
+// Size 远程缓存的占用由服务端统计，这里返回0
+func (c *RedisCache) Size() int64 {
+	return 0
+}
+
+// Close 关闭空闲连接，之后的读写返回错误
+func (c *RedisCache) Close() error {
+	atomic.StoreInt32(&c.closed, 1)
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.close()
+		default:
+			return nil
+		}
+	}
+}
+
+// encode 生成存储的值：标志位、写入时间和（可能压缩的）数据
+func (c *RedisCache) encode(data []byte, modified time.Time) []byte {
+	var flags byte
+	payload := data
+	if c.opts.Compress && len(data) >= redisCompressMinBytes {
+		if compressed, err := gzipBytes(data); err == nil && len(compressed) < len(data) {
+			payload = compressed
+			flags |= redisFlagGzip
+		}
+	}
+	value := make([]byte, redisEntryHeaderSize+len(payload))
+	value[0] = flags
+	binary.BigEndian.PutUint64(value[1:redisEntryHeaderSize], uint64(modified.UnixNano()))
+	copy(value[redisEntryHeaderSize:], payload)
+	return value
+}
+
+// decodeRedisEntry 解析存储的值
+func decodeRedisEntry(value []byte) ([]byte, time.Time, error) {
+	if len(value) < redisEntryHeaderSize {
+		return nil, time.Time{}, fmt.Errorf("长度不足")
+	}
+	modified := time.Unix(0, int64(binary.BigEndian.Uint64(value[1:redisEntryHeaderSize])))
+	payload := value[redisEntryHeaderSize:]
+	if value[0]&redisFlagGzip == 0 {
+		return payload, modified, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return data, modified, nil
+}
+
+// gzipBytes gzip压缩数据
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeRedisPattern 转义SCAN MATCH中的通配符
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// do 从连接池取连接执行命令
+func (c *RedisCache) do(args ...interface{}) (interface{}, error) {
+	conn, err := c.getConn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(c.opts.IOTimeout, args...)
+	// 服务端返回的错误不影响连接，其他错误后连接的状态未知，直接关闭
+	var replyErr redisError
+	c.putConn(conn, err != nil && !errors.As(err, &replyErr))
+	return reply, err
+}
+
+// getConn 获取空闲连接或新建连接
+func (c *RedisCache) getConn() (*redisConn, error) {
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return nil, fmt.Errorf("远程缓存已关闭")
+	}
+	if time.Now().UnixNano() < atomic.LoadInt64(&c.downUntil) {
+		return nil, ErrRemoteUnavailable
+	}
+
+	timer := time.NewTimer(c.opts.IOTimeout)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+	case <-timer.C:
+		return nil, fmt.Errorf("等待远程缓存连接超时")
+	}
+
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+	conn, err := c.dial()
+	if err != nil {
+		<-c.slots
+		c.markDown(err)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// putConn 归还连接，broken为true时关闭连接
+func (c *RedisCache) putConn(conn *redisConn, broken bool) {
+	if broken || atomic.LoadInt32(&c.closed) == 1 {
+		conn.close()
+	} else {
+		select {
+		case c.idle <- conn:
+		default:
+			conn.close()
+		}
+	}
+	<-c.slots
+}
+
+// markDown 连接失败后在一段时间内不再访问，期间只记录一次日志
+func (c *RedisCache) markDown(err error) {
+	now := time.Now().UnixNano()
+	previous := atomic.LoadInt64(&c.downUntil)
+	if atomic.CompareAndSwapInt64(&c.downUntil, previous, now+int64(redisRetryInterval)) && previous < now {
+		fmt.Printf("[远程缓存] 连接%s失败，%v内不再访问: %v\n", c.opts.Addr, redisRetryInterval, err)
+	}
+}
+
+// dial 建立连接并完成认证和选库
+func (c *RedisCache) dial() (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: c.opts.DialTimeout}
+	var netConn net.Conn
+	var err error
+	if c.opts.TLS {
+		host, _, _ := net.SplitHostPort(c.opts.Addr)
+		netConn, err = tls.DialWithDialer(dialer, "tcp", c.opts.Addr, &tls.Config{ServerName: host})
+	} else {
+		netConn, err = dialer.Dial("tcp", c.opts.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{
+		conn: netConn,
+		r:    bufio.NewReader(netConn),
+		w:    bufio.NewWriter(netConn),
+	}
+	if c.opts.Password != "" {
+		args := []interface{}{"AUTH", c.opts.Password}
+		if c.opts.Username != "" {
+			args = []interface{}{"AUTH", c.opts.Username, c.opts.Password}
+		}
+		if _, err := conn.do(c.opts.IOTimeout, args...); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("远程缓存认证失败: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := conn.do(c.opts.IOTimeout, "SELECT", strconv.Itoa(c.opts.DB)); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("远程缓存选择库%d失败: %w", c.opts.DB, err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError 服务端返回的错误
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// redisConn 一个Redis协议（RESP）连接
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// do 发送命令并读取回复
+// 回复类型：简单字符串为string，整数为int64，批量字符串为[]byte（不存在时为nil），数组为[]interface{}
+func (rc *redisConn) do(timeout time.Duration, args ...interface{}) (interface{}, error) {
+	if err := rc.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(rc.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var data []byte
+		switch v := arg.(type) {
+		case string:
+			data = []byte(v)
+		case []byte:
+			data = v
+		default:
+			return nil, fmt.Errorf("不支持的命令参数类型: %T", arg)
+		}
+		fmt.Fprintf(rc.w, "$%d\r\n", len(data))
+		rc.w.Write(data)
+		rc.w.WriteString("\r\n")
+	}
+	if err := rc.w.Flush(); err != nil {
+		return nil, err
+	}
+	return rc.readReply()
+}
+
+// readReply 读取一个回复
+func (rc *redisConn) readReply() (interface{}, error) {
+	line, err := rc.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("远程缓存协议错误: %q", line)
+	}
+	body := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("远程缓存协议错误: %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rc.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("远程缓存协议错误: %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := rc.readReply()
+			// 数组中的错误作为元素返回，读完整个回复以保持连接可用
+			var replyErr redisError
+			if errors.As(err, &replyErr) {
+				items[i] = replyErr
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("远程缓存协议错误: %q", line)
+	}
+}
+
+// close 关闭连接
+func (rc *redisConn) close() {
+	rc.conn.Close()
+}
@@ -0,0 +1,605 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+)
+
+// fakeRedis 进程内的Redis协议服务端，支持RedisCache用到的命令
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu  sync.Mutex
+	dbs map[int]map[string]fakeEntry
+}
+
+type fakeEntry struct {
+	value  []byte
+	expiry time.Time // 为零表示不过期
+}
+
+// newFakeRedis 启动服务端，测试结束时关闭
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	s := &fakeRedis{ln: ln, password: password, dbs: make(map[int]map[string]fakeEntry)}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// raw 读取库中存储的原始值
+func (s *fakeRedis) raw(db int, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.dbs[db][key]
+	return entry.value, ok
+}
+
+// put 直接写入一个键
+func (s *fakeRedis) put(db int, key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dbs[db] == nil {
+		s.dbs[db] = make(map[string]fakeEntry)
+	}
+	s.dbs[db][key] = fakeEntry{value: value}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	db := 0
+	authed := s.password == ""
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "AUTH":
+			if args[len(args)-1] == s.password {
+				authed = true
+				w.WriteString("+OK\r\n")
+			} else {
+				w.WriteString("-WRONGPASS invalid password\r\n")
+			}
+		case !authed:
+			w.WriteString("-NOAUTH Authentication required.\r\n")
+		case name == "SELECT":
+			db, _ = strconv.Atoi(args[1])
+			w.WriteString("+OK\r\n")
+		default:
+			s.exec(w, db, name, args[1:])
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) exec(w *bufio.Writer, db int, name string, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dbs[db] == nil {
+		s.dbs[db] = make(map[string]fakeEntry)
+	}
+	keys := s.dbs[db]
+	get := func(key string) (fakeEntry, bool) {
+		entry, ok := keys[key]
+		if ok && !entry.expiry.IsZero() && time.Now().After(entry.expiry) {
+			delete(keys, key)
+			return fakeEntry{}, false
+		}
+		return entry, ok
+	}
+
+	switch name {
+	case "PING":
+		w.WriteString("+PONG\r\n")
+	case "SET":
+		entry := fakeEntry{value: []byte(args[1])}
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			entry.expiry = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		keys[args[0]] = entry
+		w.WriteString("+OK\r\n")
+	case "GET":
+		if entry, ok := get(args[0]); ok {
+			writeBulk(w, entry.value)
+		} else {
+			w.WriteString("$-1\r\n")
+		}
+	case "GETRANGE":
+		entry, _ := get(args[0])
+		start, _ := strconv.Atoi(args[1])
+		end, _ := strconv.Atoi(args[2])
+		value := entry.value
+		if end >= len(value) {
+			end = len(value) - 1
+		}
+		if start > end {
+			writeBulk(w, nil)
+		} else {
+			writeBulk(w, value[start:end+1])
+		}
+	case "STRLEN":
+		entry, _ := get(args[0])
+		fmt.Fprintf(w, ":%d\r\n", len(entry.value))
+	case "PTTL":
+		entry, ok := get(args[0])
+		switch {
+		case !ok:
+			w.WriteString(":-2\r\n")
+		case entry.expiry.IsZero():
+			w.WriteString(":-1\r\n")
+		default:
+			fmt.Fprintf(w, ":%d\r\n", time.Until(entry.expiry).Milliseconds())
+		}
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if _, ok := get(key); ok {
+				delete(keys, key)
+				deleted++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", deleted)
+	case "SCAN":
+		// 一次返回所有匹配的键
+		pattern := "*"
+		for i := 1; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == "MATCH" {
+				pattern = args[i+1]
+			}
+		}
+		var matched []string
+		for key := range keys {
+			if ok, _ := path.Match(pattern, key); ok {
+				matched = append(matched, key)
+			}
+		}
+		w.WriteString("*2\r\n")
+		writeBulk(w, []byte("0"))
+		fmt.Fprintf(w, "*%d\r\n", len(matched))
+		for _, key := range matched {
+			writeBulk(w, []byte(key))
+		}
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", name)
+	}
+}
+
+// readCommand 读取一个RESP数组形式的命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("意外的命令: %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func writeBulk(w *bufio.Writer, data []byte) {
+	fmt.Fprintf(w, "$%d\r\n", len(data))
+	w.Write(data)
+	w.WriteString("\r\n")
+}
+
+func TestRedisCacheRoundTrip(t *testing.T) {
+	server := newFakeRedis(t, "")
+	c := NewRedisCache(RedisOptions{Addr: server.addr(), Prefix: "pansou:"})
+	defer c.Close()
+
+	if err := c.Ping(); err != nil {
+		t.Fatalf("Ping失败: %v", err)
+	}
+	if _, hit, err := c.Get("missing"); hit || err != nil {
+		t.Fatalf("不存在的键: hit=%v err=%v", hit, err)
+	}
+
+	before := time.Now()
+	if err := c.Set("k1", []byte("hello"), time.Minute); err != nil {
+		t.Fatalf("Set失败: %v", err)
+	}
+	if _, ok := server.raw(0, "pansou:k1"); !ok {
+		t.Fatal("键没有带前缀写入")
+	}
+	data, hit, err := c.Get("k1")
+	if err != nil || !hit || string(data) != "hello" {
+		t.Fatalf("Get = %q, %v, %v", data, hit, err)
+	}
+	modified, ok := c.GetLastModified("k1")
+	if !ok || modified.Before(before.Add(-time.Second)) || modified.After(time.Now()) {
+		t.Fatalf("GetLastModified = %v, %v", modified, ok)
+	}
+
+	if err := c.Delete("k1"); err != nil {
+		t.Fatalf("Delete失败: %v", err)
+	}
+	if _, hit, _ := c.Get("k1"); hit {
+		t.Fatal("删除后仍然命中")
+	}
+}
+
+func TestRedisCacheCompression(t *testing.T) {
+	server := newFakeRedis(t, "")
+	c := NewRedisCache(RedisOptions{Addr: server.addr(), Compress: true})
+	defer c.Close()
+
+	large := bytes.Repeat([]byte("pansou-cache-"), 1000)
+	small := []byte("small value")
+	if err := c.Set("large", large, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("small", small, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := server.raw(0, "large")
+	if raw[0]&redisFlagGzip == 0 || len(raw) >= len(large) {
+		t.Fatalf("大值没有压缩: 存储%d字节，原始%d字节", len(raw), len(large))
+	}
+	raw, _ = server.raw(0, "small")
+	if raw[0]&redisFlagGzip != 0 {
+		t.Fatal("小于1KB的值不应压缩")
+	}
+
+	for key, want := range map[string][]byte{"large": large, "small": small} {
+		got, hit, err := c.Get(key)
+		if err != nil || !hit || !bytes.Equal(got, want) {
+			t.Fatalf("%s读取结果不一致: hit=%v err=%v", key, hit, err)
+		}
+	}
+
+	// 未启用压缩的实例也能读取压缩的值
+	plain := NewRedisCache(RedisOptions{Addr: server.addr()})
+	defer plain.Close()
+	if got, hit, err := plain.Get("large"); err != nil || !hit || !bytes.Equal(got, large) {
+		t.Fatalf("未启用压缩的实例读取失败: hit=%v err=%v", hit, err)
+	}
+}
+
+func TestRedisCacheTTL(t *testing.T) {
+	server := newFakeRedis(t, "")
+	c := NewRedisCache(RedisOptions{Addr: server.addr()})
+	defer c.Close()
+
+	if err := c.Set("short", []byte("v"), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("forever", []byte("v"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, hit, _ := c.Get("short"); !hit {
+		t.Fatal("未过期的键没有命中")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, hit, _ := c.Get("short"); hit {
+		t.Fatal("过期的键仍然命中")
+	}
+	if _, hit, _ := c.Get("forever"); !hit {
+		t.Fatal("ttl为0的键不应过期")
+	}
+}
+
+func TestRedisCacheClear(t *testing.T) {
+	server := newFakeRedis(t, "")
+	server.put(0, "other:key", []byte("keep"))
+	c := NewRedisCache(RedisOptions{Addr: server.addr(), Prefix: "pan*sou:"})
+	defer c.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := c.Set(fmt.Sprintf("k%d", i), []byte("v"), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear失败: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, hit, _ := c.Get(fmt.Sprintf("k%d", i)); hit {
+			t.Fatalf("k%d没有被清除", i)
+		}
+	}
+	if _, ok := server.raw(0, "other:key"); !ok {
+		t.Fatal("Clear删除了不带前缀的键")
+	}
+}
+
+func TestRedisCacheAuthAndDB(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	wrong := NewRedisCache(RedisOptions{Addr: server.addr(), Password: "wrong"})
+	defer wrong.Close()
+	if err := wrong.Ping(); err == nil {
+		t.Fatal("密码错误时应返回错误")
+	}
+
+	opts, err := ParseRedisURL("redis://:secret@" + server.addr() + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewRedisCache(opts)
+	defer c.Close()
+	if err := c.Set("k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("认证后写入失败: %v", err)
+	}
+	if _, ok := server.raw(2, "k"); !ok {
+		t.Fatal("没有写入指定的库")
+	}
+	if _, ok := server.raw(0, "k"); ok {
+		t.Fatal("写入了默认库")
+	}
+}
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	c := NewRedisCache(RedisOptions{Addr: addr, DialTimeout: 200 * time.Millisecond})
+	defer c.Close()
+	if _, _, err := c.Get("k"); err == nil {
+		t.Fatal("连接失败时应返回错误")
+	}
+	// 暂停期内不再尝试连接
+	if _, _, err := c.Get("k"); !errors.Is(err, ErrRemoteUnavailable) {
+		t.Fatalf("暂停期内应返回ErrRemoteUnavailable，得到%v", err)
+	}
+}
+
+func TestParseRedisURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    RedisOptions
+		wantErr bool
+	}{
+		{raw: "redis://localhost", want: RedisOptions{Addr: "localhost:6379"}},
+		{raw: "redis://:pw@10.0.0.1:6380/3", want: RedisOptions{Addr: "10.0.0.1:6380", Password: "pw", DB: 3}},
+		{raw: "rediss://user:pw@cache.example.com", want: RedisOptions{Addr: "cache.example.com:6379", Username: "user", Password: "pw", TLS: true}},
+		{raw: "http://localhost", wantErr: true},
+		{raw: "redis://localhost/abc", wantErr: true},
+		{raw: "redis:///0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRedisURL(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 应返回错误", tt.raw)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: 得到%+v, %v，期望%+v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+// 多个实例共用远程缓存：一个实例写入的最终结果，其他实例在本地缓存未命中时可以读到
+func TestEnhancedTwoLevelCacheSharedRemote(t *testing.T) {
+	if config.AppConfig == nil {
+		config.AppConfig = &config.Config{CacheTTLMinutes: 60}
+	}
+	server := newFakeRedis(t, "")
+	newInstance := func(remoteAsL2 bool) *EnhancedTwoLevelCache {
+		remote := NewRedisCache(RedisOptions{Addr: server.addr(), Prefix: "pansou:", Compress: true})
+		t.Cleanup(func() { remote.Close() })
+		if remoteAsL2 {
+			return newEnhancedTwoLevelCache(NewShardedMemoryCache(100, 10), remote, nil)
+		}
+		disk, err := NewOptimizedShardedDiskCache(t.TempDir(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newEnhancedTwoLevelCache(NewShardedMemoryCache(100, 10), disk, remote)
+	}
+
+	results := []model.SearchResult{{
+		UniqueID: "labi-1",
+		Title:    "凡人修仙传",
+		Datetime: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		Links:    []model.Link{{Type: "quark", URL: "https://pan.quark.cn/s/abc"}},
+	}}
+
+	for _, tt := range []struct {
+		name       string
+		remoteAsL2 bool
+	}{{"l3", false}, {"l2", true}} {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := newInstance(tt.remoteAsL2), newInstance(tt.remoteAsL2)
+			key := "shared-" + tt.name
+
+			data, err := a.GetSerializer().Serialize(results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.SetBothLevels(key, data, time.Minute); err != nil {
+				t.Fatalf("写入失败: %v", err)
+			}
+			// 不完整的结果只保存在本实例的内存中
+			if err := a.SetWithFinalFlag(key+"-partial", data, time.Minute, false); err != nil {
+				t.Fatal(err)
+			}
+
+			got, hit, err := b.Get(key)
+			if err != nil || !hit {
+				t.Fatalf("其他实例没有读到共享结果: hit=%v err=%v", hit, err)
+			}
+			var decoded []model.SearchResult
+			if err := b.GetSerializer().Deserialize(got, &decoded); err != nil {
+				t.Fatalf("反序列化失败: %v", err)
+			}
+			if len(decoded) != 1 || decoded[0].Title != results[0].Title || !decoded[0].Datetime.Equal(results[0].Datetime) {
+				t.Fatalf("读到的结果不一致: %+v", decoded)
+			}
+			if _, hit, _ := b.Get(key + "-partial"); hit {
+				t.Fatal("不完整的结果不应写入共享缓存")
+			}
+
+			if err := a.Delete(key); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := server.raw(0, "pansou:"+key); ok {
+				t.Fatal("删除后远程缓存中仍有该键")
+			}
+		})
+	}
+}
+
+// 本实例只有不完整的结果时，其他实例之后写入共享缓存的最终结果优先
+func TestEnhancedTwoLevelCachePrefersNewerRemoteFinal(t *testing.T) {
+	if config.AppConfig == nil {
+		config.AppConfig = &config.Config{CacheTTLMinutes: 60}
+	}
+	server := newFakeRedis(t, "")
+	newInstance := func() *EnhancedTwoLevelCache {
+		remote := NewRedisCache(RedisOptions{Addr: server.addr(), Prefix: "pansou:"})
+		t.Cleanup(func() { remote.Close() })
+		disk, err := NewOptimizedShardedDiskCache(t.TempDir(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newEnhancedTwoLevelCache(NewShardedMemoryCache(100, 10), disk, remote)
+	}
+	a, b := newInstance(), newInstance()
+
+	b.SetMemoryOnly("k", []byte("partial"), time.Minute)
+	if data, hit, _ := b.Get("k"); !hit || string(data) != "partial" {
+		t.Fatalf("远程缓存中没有更新的结果时应返回本地结果: %q", data)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := a.SetBothLevels("k", []byte("final"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if data, hit, _ := b.Get("k"); !hit || string(data) != "final" {
+		t.Fatalf("应返回其他实例写入的最终结果，实际: %q", data)
+	}
+
+	// 本地的最终结果不再检查远程缓存
+	b.SetBothLevels("k", []byte("local-final"), time.Minute)
+	writer := NewRedisCache(RedisOptions{Addr: server.addr()})
+	defer writer.Close()
+	server.put(0, "pansou:k", writer.encode([]byte("remote"), time.Now().Add(time.Hour)))
+	if data, _, _ := b.Get("k"); string(data) != "local-final" {
+		t.Fatalf("本地为最终结果时应直接返回，实际: %q", data)
+	}
+}
+
+func TestEnhancedTwoLevelCacheInspect(t *testing.T) {
+	server := newFakeRedis(t, "")
+	remote := NewRedisCache(RedisOptions{Addr: server.addr(), Prefix: "pansou:"})
+	defer remote.Close()
+	disk, err := NewOptimizedShardedDiskCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newEnhancedTwoLevelCache(NewShardedMemoryCache(100, 10), disk, remote)
+
+	if err := c.SetBothLevels("k", []byte("value"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	c.SetMemoryOnly("memory-only", []byte("v"), time.Hour)
+
+	entries := c.Inspect("k")
+	if len(entries) != 3 {
+		t.Fatalf("应在三层中都存在，实际: %+v", entries)
+	}
+	for i, layer := range []string{model.CacheLayerMemory, model.CacheLayerDisk, model.CacheLayerRemote} {
+		entry := entries[i]
+		if entry.Layer != layer {
+			t.Fatalf("第%d层应为%s，实际为%s", i, layer, entry.Layer)
+		}
+		if entry.TTLSeconds <= 3500 || entry.TTLSeconds > 3600 || entry.LastModified.IsZero() {
+			t.Fatalf("%s层的有效期或写入时间不正确: %+v", layer, entry)
+		}
+	}
+	if entries[0].Size != len("value") || entries[2].Size != redisEntryHeaderSize+len("value") {
+		t.Fatalf("大小不正确: %+v", entries)
+	}
+	if got := c.Inspect("missing"); len(got) != 0 {
+		t.Fatalf("不存在的键不应返回任何层: %+v", got)
+	}
+
+	keys := c.Keys()
+	if len(keys) != 2 || keys[0] != "k" || keys[1] != "memory-only" {
+		t.Fatalf("Keys结果不正确: %v", keys)
+	}
+
+	stats := c.Stats()
+	if len(stats) != 3 || stats[0].Items != 2 || stats[1].Items != 1 || stats[2].Items != 1 {
+		t.Fatalf("统计不正确: %+v", stats)
+	}
+	if stats[1].Bytes != int64(len("value")) || len(stats[1].Shards) == 0 {
+		t.Fatalf("磁盘统计不正确: %+v", stats[1])
+	}
+
+	c.Delete("k")
+	if data, ok := c.Peek("memory-only"); !ok || string(data) != "v" {
+		t.Fatal("Peek没有读到内存中的键")
+	}
+	if _, ok := c.Peek("k"); ok {
+		t.Fatal("删除后Peek仍然命中")
+	}
+}
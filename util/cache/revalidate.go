@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"time"
+)
+
+// Freshness 缓存命中时的新鲜度
+type Freshness struct {
+	Age   time.Duration // 距写入缓存的时长
+	Stale bool          // 已超过CACHE_TTL，正在后台刷新
+}
+
+// GetWithRevalidate 读取缓存并检查新鲜度（stale-while-revalidate）
+// 写入时间未超过softTTL的缓存直接返回；超过softTTL但仍在缓存中（未超过CACHE_TTL+CACHE_STALE_TTL）时
+// 同样立即返回并标记为过期，同时在后台调用refresh刷新，同一个键同时只有一次刷新
+// softTTL<=0时不检查新鲜度；refresh返回之前同一个键不会再次触发刷新，refresh为nil时只标记不刷新
+func (c *EnhancedTwoLevelCache) GetWithRevalidate(key string, softTTL time.Duration, refresh func()) ([]byte, Freshness, bool) {
+	data, lastModified, hit := c.get(key)
+	if !hit {
+		return nil, Freshness{}, false
+	}
+
+	var freshness Freshness
+	if !lastModified.IsZero() {
+		freshness.Age = time.Since(lastModified)
+	}
+	if softTTL <= 0 || freshness.Age < softTTL {
+		return data, freshness, true
+	}
+
+	freshness.Stale = true
+	if refresh != nil {
+		c.revalidate(key, refresh)
+	}
+	return data, freshness, true
+}
+
+// revalidate 在后台执行refresh，该键已有刷新在进行时直接返回
+func (c *EnhancedTwoLevelCache) revalidate(key string, refresh func()) {
+	if _, running := c.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer c.refreshing.Delete(key)
+		refresh()
+	}()
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pansou/config"
+)
+
+// 超过softTTL的缓存仍然返回并标记为过期，同时只触发一次后台刷新
+func TestEnhancedTwoLevelCacheRevalidate(t *testing.T) {
+	if config.AppConfig == nil {
+		config.AppConfig = &config.Config{CacheTTLMinutes: 60}
+	}
+	disk, err := NewOptimizedShardedDiskCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newEnhancedTwoLevelCache(NewShardedMemoryCache(100, 10), disk, nil)
+
+	var refreshes int32
+	release := make(chan struct{})
+	done := make(chan struct{}, 2)
+	refresh := func() {
+		atomic.AddInt32(&refreshes, 1)
+		<-release
+		done <- struct{}{}
+	}
+
+	c.memory.SetWithTimestamp("fresh", []byte("a"), time.Hour, time.Now().Add(-time.Minute))
+	data, freshness, hit := c.GetWithRevalidate("fresh", 10*time.Minute, refresh)
+	if !hit || string(data) != "a" || freshness.Stale || freshness.Age < time.Minute {
+		t.Fatalf("未过期的缓存: hit=%v data=%q freshness=%+v", hit, data, freshness)
+	}
+
+	c.memory.SetWithTimestamp("stale", []byte("b"), time.Hour, time.Now().Add(-20*time.Minute))
+	for i := 0; i < 3; i++ {
+		data, freshness, hit = c.GetWithRevalidate("stale", 10*time.Minute, refresh)
+		if !hit || string(data) != "b" || !freshness.Stale {
+			t.Fatalf("过期的缓存应立即返回并标记为过期: hit=%v data=%q freshness=%+v", hit, data, freshness)
+		}
+	}
+	close(release)
+	<-done
+	if n := atomic.LoadInt32(&refreshes); n != 1 {
+		t.Fatalf("刷新进行中时不应重复刷新，实际刷新%d次", n)
+	}
+
+	// 上一次刷新结束后，再次读到过期缓存时重新刷新
+	deadline := time.Now().Add(time.Second)
+	for _, running := c.refreshing.Load("stale"); running && time.Now().Before(deadline); _, running = c.refreshing.Load("stale") {
+		time.Sleep(time.Millisecond)
+	}
+	c.GetWithRevalidate("stale", 10*time.Minute, refresh)
+	<-done
+	if n := atomic.LoadInt32(&refreshes); n != 2 {
+		t.Fatalf("上一次刷新结束后应再次刷新，实际刷新%d次", n)
+	}
+
+	// softTTL<=0时不检查新鲜度
+	if _, freshness, _ := c.GetWithRevalidate("stale", 0, refresh); freshness.Stale {
+		t.Fatal("softTTL为0时不应标记为过期")
+	}
+	if _, _, hit := c.GetWithRevalidate("missing", 10*time.Minute, refresh); hit {
+		t.Fatal("不存在的键不应命中")
+	}
+}
@@ -160,6 +160,15 @@ func (c *ShardedDiskCache) StartCleanupTask() {
 	startGlobalCleanupTask()
 }
 
+// Size 所有分片当前占用的字节数
+func (c *ShardedDiskCache) Size() int64 {
+	var size int64
+	for _, shard := range c.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
 // GetShards 获取所有分片（用于测试和调试）
 func (c *ShardedDiskCache) GetShards() []*DiskCache {
 	return c.shards
@@ -45,7 +45,7 @@ type ShardedMemoryCache struct {
 	maxSize   int64
 	itemsPerShard int
 	sizePerShard  int64
-	diskCache     *ShardedDiskCache // 磁盘缓存引用
+	diskCache     Cache             // 磁盘缓存引用
 	diskCacheMutex sync.RWMutex     // 磁盘缓存引用的保护锁
 }
 
@@ -302,6 +302,15 @@ func (c *ShardedMemoryCache) Clear() {
 	wg.Wait()
 }
 
+// Size 所有分片当前占用的字节数
+func (c *ShardedMemoryCache) Size() int64 {
+	var size int64
+	for _, shard := range c.shards {
+		size += atomic.LoadInt64(&shard.currSize)
+	}
+	return size
+}
+
 // 启动全局清理任务（单例模式）
 func startGlobalCleanupTask() {
 	globalCleanupOnce.Do(func() {
@@ -335,15 +344,15 @@ func (c *ShardedMemoryCache) StartCleanupTask() {
 	startGlobalCleanupTask()
 }
 
-// SetDiskCacheReference 设置磁盘缓存引用
-func (c *ShardedMemoryCache) SetDiskCacheReference(diskCache *ShardedDiskCache) {
+// SetDiskCacheReference 设置磁盘缓存引用（使用远程缓存作为第二级时为远程缓存）
+func (c *ShardedMemoryCache) SetDiskCacheReference(diskCache Cache) {
 	c.diskCacheMutex.Lock()
 	defer c.diskCacheMutex.Unlock()
 	c.diskCache = diskCache
 }
 
 // getDiskCacheReference 获取磁盘缓存引用
-func (c *ShardedMemoryCache) getDiskCacheReference() *ShardedDiskCache {
+func (c *ShardedMemoryCache) getDiskCacheReference() Cache {
 	c.diskCacheMutex.RLock()
 	defer c.diskCacheMutex.RUnlock()
 	return c.diskCache
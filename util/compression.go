@@ -40,6 +40,12 @@ func GzipMiddleware() gin.HandlerFunc {
 			return
 		}
 		
+		// 流式响应（SSE）需要逐条刷新，不能整体缓冲压缩
+		if strings.HasSuffix(c.Request.URL.Path, "/stream") {
+			c.Next()
+			return
+		}
+		
 		// 检查客户端是否支持gzip
 		if !strings.Contains(c.Request.Header.Get("Accept-Encoding"), "gzip") {
 			c.Next()
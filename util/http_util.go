@@ -18,6 +18,21 @@ var httpClient *http.Client
 
 // InitHTTPClient 初始化HTTP客户端
 func InitHTTPClient() {
+	proxyURL := ""
+	if config.AppConfig.UseProxy {
+		proxyURL = config.AppConfig.ProxyURL
+	}
+
+	// 创建客户端
+	httpClient = &http.Client{
+		Transport: NewTransport(proxyURL),
+		Timeout:   time.Duration(60) * time.Second,
+	}
+}
+
+// NewTransport 创建HTTP传输配置，proxyURL为空时不使用代理
+// 支持socks5://和http(s)://代理，地址无效时忽略代理
+func NewTransport(proxyURL string) *http.Transport {
 	// 创建传输配置
 	transport := &http.Transport{
 		// 启用HTTP/2
@@ -45,13 +60,13 @@ func InitHTTPClient() {
 	}
 
 	// 如果配置了代理，设置代理
-	if config.AppConfig.UseProxy {
-		proxyURL, err := url.Parse(config.AppConfig.ProxyURL)
+	if proxyURL != "" {
+		parsedURL, err := url.Parse(proxyURL)
 		if err == nil {
 			// 根据代理类型设置不同的处理方式
-			if proxyURL.Scheme == "socks5" {
+			if parsedURL.Scheme == "socks5" {
 				// 创建SOCKS5代理拨号器
-				dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
+				dialer, err := proxy.FromURL(parsedURL, proxy.Direct)
 				if err == nil {
 					transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
 						return dialer.Dial(network, addr)
@@ -59,16 +74,12 @@ func InitHTTPClient() {
 				}
 			} else {
 				// HTTP/HTTPS代理
-				transport.Proxy = http.ProxyURL(proxyURL)
+				transport.Proxy = http.ProxyURL(parsedURL)
 			}
 		}
 	}
 
-	// 创建客户端
-	httpClient = &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(60) * time.Second,
-	}
+	return transport
 }
 
 // GetHTTPClient 获取HTTP客户端
@@ -79,6 +90,11 @@ func GetHTTPClient() *http.Client {
 	return httpClient
 }
 
+// SetHTTPClient 替换全局HTTP客户端，用于测试时录制和回放请求
+func SetHTTPClient(client *http.Client) {
+	httpClient = client
+}
+
 // FetchHTML 获取HTML内容
 func FetchHTML(targetURL string) (string, error) {
 	// 使用优化后的HTTP客户端
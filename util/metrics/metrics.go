@@ -0,0 +1,325 @@
+// Package metrics 轻量的Prometheus指标实现，输出text exposition格式
+// 只实现项目需要的计数器、直方图和按需读取的仪表，避免引入完整的客户端库
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus text格式的Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets 默认的耗时直方图分桶（秒）
+var DefBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60}
+
+// Default 默认注册表，New*函数创建的指标都注册在这里
+var Default = NewRegistry()
+
+// collector 可输出的指标
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+// NewRegistry 创建注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register 注册指标，按注册顺序输出
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mu.Unlock()
+}
+
+// WriteText 以Prometheus text格式输出所有指标
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// desc 指标描述
+type desc struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+}
+
+// writeHeader 输出HELP和TYPE行
+func (d *desc) writeHeader(w *bufio.Writer) {
+	w.WriteString("# HELP " + d.name + " " + escapeHelp(d.help) + "\n")
+	w.WriteString("# TYPE " + d.name + " " + d.typ + "\n")
+}
+
+// writeSample 输出一行样本，extra为附加标签（如直方图的le）
+func (d *desc) writeSample(w *bufio.Writer, suffix string, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(d.name + suffix)
+	if len(d.labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, name := range d.labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(name + `="` + escapeLabel(labelValues[i]) + `"`)
+		}
+		if extraName != "" {
+			if len(d.labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec 创建并注册计数器
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{name: name, help: help, typ: "counter", labelNames: labelNames},
+		values: make(map[string]*counterValue),
+	}
+	Default.register(c)
+	return c
+}
+
+// Inc 计数加1，labelValues与创建时的标签名一一对应
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加v
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	c.mu.Lock()
+	value, exists := c.values[key]
+	if !exists {
+		value = &counterValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = value
+	}
+	value.value += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		c.writeSample(w, "", value.labelValues, "", "", value.value)
+	}
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64 // 各分桶的累计计数
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec 创建并注册直方图，buckets需升序
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, typ: "histogram", labelNames: labelNames},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	Default.register(h)
+	return h
+}
+
+// Observe 记录一个观测值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	h.mu.Lock()
+	value, exists := h.values[key]
+	if !exists {
+		value = &histogramValue{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = value
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			value.counts[i]++
+		}
+	}
+	value.count++
+	value.sum += v
+	h.mu.Unlock()
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.values) {
+		value := h.values[key]
+		for i, bound := range h.buckets {
+			h.writeSample(w, "_bucket", value.labelValues, "le", formatFloat(bound), float64(value.counts[i]))
+		}
+		h.writeSample(w, "_bucket", value.labelValues, "le", "+Inf", float64(value.count))
+		h.writeSample(w, "_sum", value.labelValues, "", "", value.sum)
+		h.writeSample(w, "_count", value.labelValues, "", "", float64(value.count))
+	}
+}
+
+// GaugeVec 带标签的仪表
+type GaugeVec struct {
+	CounterVec
+}
+
+// NewGaugeVec 创建并注册仪表
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{CounterVec{
+		desc:   desc{name: name, help: help, typ: "gauge", labelNames: labelNames},
+		values: make(map[string]*counterValue),
+	}}
+	Default.register(g)
+	return g
+}
+
+// Set 设置当前值
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	g.mu.Lock()
+	value, exists := g.values[key]
+	if !exists {
+		value = &counterValue{labelValues: append([]string(nil), labelValues...)}
+		g.values[key] = value
+	}
+	value.value = v
+	g.mu.Unlock()
+}
+
+// funcCollector 抓取时才读取数值的指标，用于导出已有的统计数据
+type funcCollector struct {
+	desc
+	collect func(emit func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc 创建并注册仪表，抓取时调用fn读取当前值
+func NewGaugeFunc(name, help string, fn func() float64) {
+	NewGaugeVecFunc(name, help, nil, func(emit func(float64, ...string)) {
+		emit(fn())
+	})
+}
+
+// NewCounterFunc 创建并注册计数器，抓取时调用fn读取当前值（fn的返回值需单调递增）
+func NewCounterFunc(name, help string, fn func() float64) {
+	NewCounterVecFunc(name, help, nil, func(emit func(float64, ...string)) {
+		emit(fn())
+	})
+}
+
+// NewGaugeVecFunc 创建并注册带标签的仪表，抓取时调用collect，collect对每组标签调用一次emit
+func NewGaugeVecFunc(name, help string, labelNames []string, collect func(emit func(value float64, labelValues ...string))) {
+	Default.register(&funcCollector{
+		desc:    desc{name: name, help: help, typ: "gauge", labelNames: labelNames},
+		collect: collect,
+	})
+}
+
+// NewCounterVecFunc 创建并注册带标签的计数器，用法同NewGaugeVecFunc
+func NewCounterVecFunc(name, help string, labelNames []string, collect func(emit func(value float64, labelValues ...string))) {
+	Default.register(&funcCollector{
+		desc:    desc{name: name, help: help, typ: "counter", labelNames: labelNames},
+		collect: collect,
+	})
+}
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	f.writeHeader(w)
+	f.collect(func(value float64, labelValues ...string) {
+		f.writeSample(w, "", labelValues, "", "", value)
+	})
+}
+
+// labelKey 标签值组合的键
+func labelKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// sortedKeys 排序后的键，保证输出顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFloat 格式化数值
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabel 转义标签值
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// escapeHelp 转义HELP文本
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
@@ -69,9 +69,18 @@ func (p *WorkerPool) startWorkers() {
 						return
 					}
 					
-					// 执行任务并发送结果
+					// 已取消时不再执行排队中的任务
+					if p.ctx.Err() != nil {
+						return
+					}
+					
+					// 执行任务并发送结果，取消后GetResults不再接收结果，丢弃以免阻塞Close
 					result := task()
-					p.results <- result
+					select {
+					case p.results <- result:
+					case <-p.ctx.Done():
+						return
+					}
 					
 				case <-p.ctx.Done():
 					return
@@ -145,6 +154,32 @@ func ExecuteBatch(tasks []Task, maxWorkers int) []interface{} {
 
 // ExecuteBatchWithTimeout 批量执行任务，带有超时控制，并返回结果
 func ExecuteBatchWithTimeout(tasks []Task, maxWorkers int, timeout time.Duration) []interface{} {
+	// 创建带超时的上下文
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return executeBatch(ctx, tasks, maxWorkers)
+}
+
+// ContextTask 可以取消的工作任务，ctx在超时或调用方取消时结束
+type ContextTask func(ctx context.Context) interface{}
+
+// ExecuteBatchWithContext 批量执行可以取消的任务，ctx取消或超时后不再等待未完成的任务，并通知它们中止
+// 返回已完成任务的结果
+func ExecuteBatchWithContext(ctx context.Context, tasks []ContextTask, maxWorkers int, timeout time.Duration) []interface{} {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wrapped := make([]Task, len(tasks))
+	for i, task := range tasks {
+		task := task
+		wrapped[i] = func() interface{} { return task(ctx) }
+	}
+	return executeBatch(ctx, wrapped, maxWorkers)
+}
+
+// executeBatch 在ctx结束前执行任务并收集结果
+func executeBatch(ctx context.Context, tasks []Task, maxWorkers int) []interface{} {
 	if len(tasks) == 0 {
 		return []interface{}{}
 	}
@@ -154,10 +189,6 @@ func ExecuteBatchWithTimeout(tasks []Task, maxWorkers int, timeout time.Duration
 		maxWorkers = len(tasks)
 	}
 	
-	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	
 	// 创建工作池
 	pool := NewWorkerPoolWithContext(ctx, maxWorkers)
 	defer pool.Close()
@@ -0,0 +1,367 @@
+// Package query 解析搜索查询语法，并在本地对搜索结果应用过滤条件
+//
+// 支持的语法：
+//
+//	"完整短语"            短语匹配
+//	-排除词 / -"排除短语"   排除包含该词的结果
+//	A OR B / A | B        任一匹配即可
+//	after:2024-01-01      发布时间不早于该日期（支持 2024、2024-01、2024-01-01）
+//	before:2024-06-01     发布时间早于该日期
+//	source:plugin:labi    只保留指定来源，source:tg 表示全部TG频道，可重复（任一匹配）
+//	-source:tg:xxx        排除指定来源
+//	type:quark            只保留指定网盘类型，可重复（任一匹配），-type:xxx 排除
+//	has:password          只保留带提取码的链接，-has:password 只保留不带提取码的链接
+//
+// 普通关键词、短语和每个OR组的第一个候选词组成上游关键词，发送给TG频道和插件，
+// 其余条件只在本地过滤。
+package query
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"pansou/model"
+)
+
+// Query 解析后的搜索查询
+type Query struct {
+	Raw     string // 原始查询
+	Keyword string // 发送给上游（TG频道、插件）的普通关键词
+
+	Groups   [][]string // 文本条件：组之间为AND，组内为OR，均已转为小写
+	Excluded []string   // 排除词，已转为小写
+
+	After  time.Time // 不早于该时间，零值表示不限
+	Before time.Time // 早于该时间，零值表示不限
+
+	IncludeSources []string // 只保留的来源，已转为小写
+	ExcludeSources []string // 排除的来源，已转为小写
+	IncludeTypes   []string // 只保留的网盘类型，已转为小写
+	ExcludeTypes   []string // 排除的网盘类型，已转为小写
+
+	HasPassword *bool // 是否要求带提取码，nil表示不限
+
+	plain bool // 是否为不含任何查询语法的普通关键词
+}
+
+// token 词法单元
+type token struct {
+	text    string
+	negated bool // 带-前缀
+	quoted  bool // 带引号的短语
+	or      bool // OR运算符
+}
+
+// Parse 解析查询字符串
+func Parse(raw string) *Query {
+	q := &Query{Raw: raw, plain: true}
+	tokens := tokenize(raw)
+
+	var keywordParts []string
+	var pendingGroup []string // 正在构建的OR组（原始大小写）
+	expectOr := false         // 上一个token为OR运算符
+
+	flushGroup := func() {
+		if len(pendingGroup) == 0 {
+			return
+		}
+		keywordParts = append(keywordParts, pendingGroup[0])
+		group := make([]string, 0, len(pendingGroup))
+		for _, term := range pendingGroup {
+			group = append(group, strings.ToLower(term))
+		}
+		q.Groups = append(q.Groups, group)
+		pendingGroup = nil
+	}
+
+	for _, tok := range tokens {
+		if tok.or {
+			q.plain = false
+			expectOr = len(pendingGroup) > 0
+			continue
+		}
+		if tok.quoted || tok.negated {
+			q.plain = false
+		}
+
+		if !tok.quoted && q.applyOperator(tok) {
+			q.plain = false
+			expectOr = false
+			continue
+		}
+
+		if tok.negated {
+			if tok.text != "" {
+				q.Excluded = append(q.Excluded, strings.ToLower(tok.text))
+			}
+			expectOr = false
+			continue
+		}
+		if tok.text == "" {
+			continue
+		}
+
+		if !expectOr {
+			flushGroup()
+		}
+		pendingGroup = append(pendingGroup, tok.text)
+		expectOr = false
+	}
+	flushGroup()
+
+	if q.plain {
+		// 普通关键词保持原样，行为与未引入查询语法时一致
+		q.Keyword = strings.TrimSpace(raw)
+	} else {
+		q.Keyword = strings.Join(keywordParts, " ")
+	}
+	return q
+}
+
+// applyOperator 处理 key:value 形式的过滤条件，不是已知运算符时返回false
+func (q *Query) applyOperator(tok token) bool {
+	index := strings.Index(tok.text, ":")
+	if index <= 0 {
+		return false
+	}
+	key := strings.ToLower(tok.text[:index])
+	value := strings.ToLower(strings.TrimSpace(tok.text[index+1:]))
+
+	switch key {
+	case "after", "before":
+		date, ok := parseDate(value)
+		if !ok || tok.negated {
+			return false
+		}
+		if key == "after" {
+			q.After = date
+		} else {
+			// before:2024 表示早于2024年，before:2024-06 表示早于2024年6月
+			q.Before = date
+		}
+	case "source":
+		if value == "" {
+			return false
+		}
+		if tok.negated {
+			q.ExcludeSources = append(q.ExcludeSources, value)
+		} else {
+			q.IncludeSources = append(q.IncludeSources, value)
+		}
+	case "type":
+		if value == "" {
+			return false
+		}
+		if tok.negated {
+			q.ExcludeTypes = append(q.ExcludeTypes, value)
+		} else {
+			q.IncludeTypes = append(q.IncludeTypes, value)
+		}
+	case "has":
+		if value != "password" && value != "pwd" {
+			return false
+		}
+		hasPassword := !tok.negated
+		q.HasPassword = &hasPassword
+	default:
+		return false
+	}
+	return true
+}
+
+// IsPlain 查询是否为不含任何语法的普通关键词
+func (q *Query) IsPlain() bool {
+	return q.plain
+}
+
+// HasTextFilter 是否包含需要在本地匹配的文本条件
+func (q *Query) HasTextFilter() bool {
+	return !q.plain && (len(q.Groups) > 0 || len(q.Excluded) > 0)
+}
+
+// MatchResult 判断搜索结果是否满足结果级条件：时间、来源，checkText为true时还检查文本
+// 文本条件同时匹配标题和内容，排除词只检查标题（TG消息内容常包含多个资源）
+func (q *Query) MatchResult(result model.SearchResult, source string, checkText bool) bool {
+	if q.plain {
+		return true
+	}
+
+	if !q.After.IsZero() || !q.Before.IsZero() {
+		// 没有发布时间的结果无法判断，直接排除
+		if result.Datetime.IsZero() {
+			return false
+		}
+		if !q.After.IsZero() && result.Datetime.Before(q.After) {
+			return false
+		}
+		if !q.Before.IsZero() && !result.Datetime.Before(q.Before) {
+			return false
+		}
+	}
+
+	if !q.MatchSource(source) {
+		return false
+	}
+	if !checkText {
+		return true
+	}
+
+	lowerTitle := strings.ToLower(result.Title)
+	lowerContent := strings.ToLower(result.Content)
+	for _, group := range q.Groups {
+		if !containsAny(lowerTitle, group) && !containsAny(lowerContent, group) {
+			return false
+		}
+	}
+	return !containsAny(lowerTitle, q.Excluded)
+}
+
+// MatchSource 判断来源是否满足source条件
+// 条件值不含冒号时按类别匹配，如source:tg匹配全部TG频道
+func (q *Query) MatchSource(source string) bool {
+	lowerSource := strings.ToLower(source)
+	if len(q.IncludeSources) > 0 && !matchAnySource(lowerSource, q.IncludeSources) {
+		return false
+	}
+	return !matchAnySource(lowerSource, q.ExcludeSources)
+}
+
+// HasLinkFilter 是否包含网盘类型或提取码条件
+func (q *Query) HasLinkFilter() bool {
+	return len(q.IncludeTypes) > 0 || len(q.ExcludeTypes) > 0 || q.HasPassword != nil
+}
+
+// MatchLink 判断链接是否满足链接级条件：网盘类型、提取码
+func (q *Query) MatchLink(linkType string, password string) bool {
+	if q.plain {
+		return true
+	}
+
+	lowerType := strings.ToLower(linkType)
+	if len(q.IncludeTypes) > 0 && !containsString(q.IncludeTypes, lowerType) {
+		return false
+	}
+	if containsString(q.ExcludeTypes, lowerType) {
+		return false
+	}
+
+	return q.HasPassword == nil || (password != "") == *q.HasPassword
+}
+
+// MatchText 判断文本是否满足全部文本条件且不含排除词
+func (q *Query) MatchText(text string) bool {
+	if q.plain {
+		return true
+	}
+	lowerText := strings.ToLower(text)
+	for _, group := range q.Groups {
+		if !containsAny(lowerText, group) {
+			return false
+		}
+	}
+	return !containsAny(lowerText, q.Excluded)
+}
+
+// tokenize 将查询拆分为词法单元，支持引号短语和-前缀
+func tokenize(raw string) []token {
+	var tokens []token
+	runes := []rune(raw)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		tok := token{}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			tok.negated = true
+			i++
+		}
+
+		if isQuote(runes[i]) {
+			closing := closingQuote(runes[i])
+			i++
+			start := i
+			for i < len(runes) && runes[i] != closing {
+				i++
+			}
+			tok.text = strings.TrimSpace(string(runes[start:i]))
+			tok.quoted = true
+			if i < len(runes) {
+				i++ // 跳过结束引号
+			}
+			tokens = append(tokens, tok)
+			continue
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		tok.text = string(runes[start:i])
+
+		if !tok.negated && (tok.text == "OR" || tok.text == "|") {
+			tok = token{or: true}
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// isQuote 是否为短语起始引号（支持中文引号）
+func isQuote(r rune) bool {
+	return r == '"' || r == '“'
+}
+
+// closingQuote 返回与起始引号对应的结束引号
+func closingQuote(r rune) rune {
+	if r == '“' {
+		return '”'
+	}
+	return '"'
+}
+
+// parseDate 解析日期，返回该日期的起始时间（使用本地时区）
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// containsAny 文本是否包含任一词
+func containsAny(text string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString 切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnySource 来源是否匹配任一条件，条件不含冒号时按类别前缀匹配
+func matchAnySource(source string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if source == pattern {
+			return true
+		}
+		if !strings.Contains(pattern, ":") && strings.HasPrefix(source, pattern+":") {
+			return true
+		}
+	}
+	return false
+}
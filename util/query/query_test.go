@@ -0,0 +1,133 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"pansou/model"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		raw            string
+		keyword        string
+		plain          bool
+		groups         [][]string
+		excluded       []string
+		includeSources []string
+		excludeSources []string
+		includeTypes   []string
+		excludeTypes   []string
+	}{
+		{raw: " 速度与激情 ", keyword: "速度与激情", plain: true, groups: [][]string{{"速度与激情"}}},
+		{raw: "A OR B", keyword: "A", groups: [][]string{{"a", "b"}}},
+		{raw: "三体 A | B 4K", keyword: "三体 A 4K", groups: [][]string{{"三体"}, {"a", "b"}, {"4k"}}},
+		{raw: `"Three Body" 4K`, keyword: "Three Body 4K", groups: [][]string{{"three body"}, {"4k"}}},
+		{raw: `三体 -"x y" -预告`, keyword: "三体", groups: [][]string{{"三体"}}, excluded: []string{"x y", "预告"}},
+		{raw: "三体 source:tg -source:plugin:labi", keyword: "三体", groups: [][]string{{"三体"}},
+			includeSources: []string{"tg"}, excludeSources: []string{"plugin:labi"}},
+		{raw: "三体 type:Quark -type:baidu", keyword: "三体", groups: [][]string{{"三体"}},
+			includeTypes: []string{"quark"}, excludeTypes: []string{"baidu"}},
+		{raw: "-foo source:tg after:2024", keyword: "", excluded: []string{"foo"}, includeSources: []string{"tg"}},
+		{raw: "三体 unknown:value", keyword: "三体 unknown:value", plain: true},
+	}
+	for _, c := range cases {
+		q := Parse(c.raw)
+		if q.Keyword != c.keyword {
+			t.Errorf("Parse(%q).Keyword = %q, want %q", c.raw, q.Keyword, c.keyword)
+		}
+		if q.IsPlain() != c.plain {
+			t.Errorf("Parse(%q).IsPlain() = %v, want %v", c.raw, q.IsPlain(), c.plain)
+		}
+		if !c.plain && !reflect.DeepEqual(q.Groups, c.groups) {
+			t.Errorf("Parse(%q).Groups = %q, want %q", c.raw, q.Groups, c.groups)
+		}
+		if !reflect.DeepEqual(q.Excluded, c.excluded) {
+			t.Errorf("Parse(%q).Excluded = %q, want %q", c.raw, q.Excluded, c.excluded)
+		}
+		if !reflect.DeepEqual(q.IncludeSources, c.includeSources) || !reflect.DeepEqual(q.ExcludeSources, c.excludeSources) {
+			t.Errorf("Parse(%q) sources = %q / %q, want %q / %q", c.raw, q.IncludeSources, q.ExcludeSources, c.includeSources, c.excludeSources)
+		}
+		if !reflect.DeepEqual(q.IncludeTypes, c.includeTypes) || !reflect.DeepEqual(q.ExcludeTypes, c.excludeTypes) {
+			t.Errorf("Parse(%q) types = %q / %q, want %q / %q", c.raw, q.IncludeTypes, q.ExcludeTypes, c.includeTypes, c.excludeTypes)
+		}
+	}
+}
+
+func TestMatchResultDateBoundaries(t *testing.T) {
+	at := func(value string) time.Time {
+		date, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return date
+	}
+
+	cases := []struct {
+		raw      string
+		datetime time.Time
+		want     bool
+	}{
+		{"三体 after:2024", at("2024-01-01 00:00:00"), true},
+		{"三体 after:2024", at("2023-12-31 23:59:59"), false},
+		{"三体 before:2024-06", at("2024-05-31 23:59:59"), true},
+		{"三体 before:2024-06", at("2024-06-01 00:00:00"), false},
+		{"三体 after:2024-01-15 before:2024-02", at("2024-01-15 00:00:00"), true},
+		{"三体 after:2024-01-15 before:2024-02", at("2024-02-01 00:00:00"), false},
+		{"三体 after:2024", time.Time{}, false},
+		{"三体", time.Time{}, true},
+	}
+	for _, c := range cases {
+		result := model.SearchResult{Title: "三体", Datetime: c.datetime}
+		if got := Parse(c.raw).MatchResult(result, "tg:channel", true); got != c.want {
+			t.Errorf("Parse(%q).MatchResult(%v) = %v, want %v", c.raw, c.datetime, got, c.want)
+		}
+	}
+}
+
+func TestMatchResultSourceAndText(t *testing.T) {
+	cases := []struct {
+		raw    string
+		title  string
+		source string
+		want   bool
+	}{
+		{"三体 source:tg", "三体", "tg:tgsearchers3", true},
+		{"三体 source:tg", "三体", "plugin:labi", false},
+		{"三体 -source:plugin:labi", "三体", "plugin:labi", false},
+		{"三体 -source:plugin:labi", "三体", "plugin:jikepan", true},
+		{`三体 -"x y"`, "三体 x y 合集", "tg:a", false},
+		{`三体 -"x y"`, "三体 x 合集", "tg:a", true},
+		{"A OR B", "只有b", "tg:a", true},
+		{"A OR B", "都没有", "tg:a", false},
+	}
+	for _, c := range cases {
+		result := model.SearchResult{Title: c.title}
+		if got := Parse(c.raw).MatchResult(result, c.source, true); got != c.want {
+			t.Errorf("Parse(%q).MatchResult(%q, %q) = %v, want %v", c.raw, c.title, c.source, got, c.want)
+		}
+	}
+}
+
+func TestMatchLink(t *testing.T) {
+	cases := []struct {
+		raw      string
+		linkType string
+		password string
+		want     bool
+	}{
+		{"三体 type:quark", "quark", "", true},
+		{"三体 type:quark", "baidu", "", false},
+		{"三体 -type:baidu", "Baidu", "", false},
+		{"三体 -type:baidu", "quark", "", true},
+		{"三体 has:password", "baidu", "abcd", true},
+		{"三体 has:password", "baidu", "", false},
+		{"三体 -has:password", "baidu", "", true},
+	}
+	for _, c := range cases {
+		if got := Parse(c.raw).MatchLink(c.linkType, c.password); got != c.want {
+			t.Errorf("Parse(%q).MatchLink(%q, %q) = %v, want %v", c.raw, c.linkType, c.password, got, c.want)
+		}
+	}
+}
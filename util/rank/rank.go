@@ -0,0 +1,151 @@
+// Package rank 计算搜索结果与关键词的文本相关性
+//
+// 分词前先做全角转半角、转小写和繁体转简体，
+// 英文和数字按连续字母数字切分为词，
+// 中日韩文字按相邻两字切分为二元词（单独一个字时保留单字），其余字符视为分隔符。
+// 相关性采用BM25，语料为本次搜索返回的全部结果，标题中的词频按TitleWeight加权。
+package rank
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"pansou/util/textmatch"
+)
+
+// BM25参数
+const (
+	K1          = 1.2  // 词频饱和参数
+	B           = 0.75 // 文档长度归一化参数
+	TitleWeight = 2.0  // 标题中词频的权重，正文为1
+)
+
+// Document 参与相关性计算的文档
+type Document struct {
+	Title   string
+	Content string
+}
+
+// Tokenize 将文本切分为词
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch len(cjk) {
+		case 0:
+			return
+		case 1:
+			tokens = append(tokens, string(cjk))
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range textmatch.Fold(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// isCJK 是否为按二元切分的中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// BM25 计算每个文档与查询的BM25得分，返回值与docs一一对应
+// 查询中重复的词只计算一次，查询没有可用的词时全部为0
+func BM25(query string, docs []Document) []float64 {
+	scores := make([]float64, len(docs))
+	terms := uniqueTerms(Tokenize(query))
+	if len(terms) == 0 || len(docs) == 0 {
+		return scores
+	}
+
+	// 统计每个文档的加权词频和长度
+	freqs := make([]map[string]float64, len(docs))
+	lengths := make([]float64, len(docs))
+	totalLength := 0.0
+	for i, doc := range docs {
+		freq := make(map[string]float64)
+		for _, token := range Tokenize(doc.Title) {
+			freq[token] += TitleWeight
+			lengths[i] += TitleWeight
+		}
+		for _, token := range Tokenize(doc.Content) {
+			freq[token]++
+			lengths[i]++
+		}
+		freqs[i] = freq
+		totalLength += lengths[i]
+	}
+	avgLength := totalLength / float64(len(docs))
+	if avgLength == 0 {
+		return scores
+	}
+
+	n := float64(len(docs))
+	for _, term := range terms {
+		df := 0.0
+		for _, freq := range freqs {
+			if freq[term] > 0 {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for i, freq := range freqs {
+			tf := freq[term]
+			if tf == 0 {
+				continue
+			}
+			norm := K1 * (1 - B + B*lengths[i]/avgLength)
+			scores[i] += idf * tf * (K1 + 1) / (tf + norm)
+		}
+	}
+	return scores
+}
+
+// uniqueTerms 去除重复的词，保持原有顺序
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	terms := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		terms = append(terms, token)
+	}
+	return terms
+}
@@ -0,0 +1,49 @@
+package rank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"三体", []string{"三体"}},
+		{"三体 第二季", []string{"三体", "第二", "二季"}},
+		{"Three-Body 4K", []string{"three", "body", "4k"}},
+		{"刘慈欣《三体》全集.mp4", []string{"刘慈", "慈欣", "三体", "全集", "mp4"}},
+		{"剧", []string{"剧"}},
+		{"權力的遊戲 ＳＥＡＳＯＮ", []string{"权力", "力的", "的游", "游戏", "season"}},
+		{"", nil},
+	}
+	for _, c := range cases {
+		if got := Tokenize(c.text); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Tokenize(%q) = %v, want %v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestBM25PrefersExactTitle(t *testing.T) {
+	docs := []Document{
+		{Title: "2024最新热门电视剧合集 流浪地球 繁花 漫长的季节", Content: "全网最全，每日更新"},
+		{Title: "三体", Content: "刘慈欣原著改编 全30集"},
+		{Title: "三体+流浪地球+球状闪电 刘慈欣作品合集", Content: ""},
+	}
+	scores := BM25("三体", docs)
+
+	if scores[0] != 0 {
+		t.Errorf("unrelated document scored %v, want 0", scores[0])
+	}
+	if scores[1] <= scores[2] {
+		t.Errorf("exact title scored %v, compilation %v, want exact title higher", scores[1], scores[2])
+	}
+}
+
+func TestBM25EmptyQuery(t *testing.T) {
+	scores := BM25("  ", []Document{{Title: "三体"}})
+	if len(scores) != 1 || scores[0] != 0 {
+		t.Errorf("BM25 with empty query = %v, want [0]", scores)
+	}
+}
@@ -0,0 +1,118 @@
+// Package ratelimit 令牌桶限流
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket 令牌桶，容量为capacity，每interval补充capacity个令牌（匀速补充）
+type TokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // 每秒补充的令牌数
+	tokens   float64
+	last     time.Time
+}
+
+// NewTokenBucket 创建令牌桶，初始为满
+func NewTokenBucket(capacity int, interval time.Duration) *TokenBucket {
+	return &TokenBucket{
+		capacity: float64(capacity),
+		rate:     float64(capacity) / interval.Seconds(),
+		tokens:   float64(capacity),
+		last:     time.Now(),
+	}
+}
+
+// Allow 尝试取出一个令牌，失败时返回需要等待的时间
+func (b *TokenBucket) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, wait
+}
+
+// idle 令牌桶是否已补满（长时间未使用）
+func (b *TokenBucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.capacity
+}
+
+// KeyedLimiter 按键（API Key、客户端IP等）分别限流，每个键一个令牌桶
+// capacity<=0时不限流
+type KeyedLimiter struct {
+	capacity int
+	interval time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*TokenBucket
+	lastSweep time.Time
+}
+
+// NewKeyedLimiter 创建按键限流器，每个键每interval最多capacity次
+func NewKeyedLimiter(capacity int, interval time.Duration) *KeyedLimiter {
+	return &KeyedLimiter{
+		capacity:  capacity,
+		interval:  interval,
+		buckets:   make(map[string]*TokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// SetCapacity 修改每个键每interval的次数上限，已有的令牌桶全部重置
+func (l *KeyedLimiter) SetCapacity(capacity int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.capacity = capacity
+	l.buckets = make(map[string]*TokenBucket)
+}
+
+// Allow 尝试为指定键取出一个令牌，失败时返回需要等待的时间
+func (l *KeyedLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	if l.capacity <= 0 {
+		l.mu.Unlock()
+		return true, 0
+	}
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = NewTokenBucket(l.capacity, l.interval)
+		l.buckets[key] = bucket
+	}
+	l.sweepLocked()
+	l.mu.Unlock()
+
+	return bucket.Allow()
+}
+
+// sweepLocked 定期清理已补满的令牌桶，避免键数量无限增长，调用方需持有l.mu
+func (l *KeyedLimiter) sweepLocked() {
+	now := time.Now()
+	if now.Sub(l.lastSweep) < l.interval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if bucket.idle(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package schema 根据Go类型的json标签生成JSON Schema
+// 用于MCP工具参数定义和OpenAPI文档，保证文档与实际请求/响应结构一致
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generator JSON Schema生成器
+// refPrefix非空时，具名结构体生成为组件并以$ref引用（如OpenAPI的"#/components/schemas/"），
+// 为空时全部内联展开
+type Generator struct {
+	refPrefix    string
+	descriptions map[reflect.Type]map[string]string
+	components   map[string]interface{}
+}
+
+// NewGenerator 创建生成器
+func NewGenerator(refPrefix string) *Generator {
+	return &Generator{
+		refPrefix:    refPrefix,
+		descriptions: make(map[reflect.Type]map[string]string),
+		components:   make(map[string]interface{}),
+	}
+}
+
+// Describe 为结构体字段设置说明，键为json字段名
+func (g *Generator) Describe(v interface{}, descriptions map[string]string) *Generator {
+	g.descriptions[indirect(reflect.TypeOf(v))] = descriptions
+	return g
+}
+
+// Components 返回已生成的组件
+func (g *Generator) Components() map[string]interface{} {
+	return g.components
+}
+
+// Schema 生成类型的Schema，具名结构体按refPrefix决定引用或内联
+func (g *Generator) Schema(v interface{}) map[string]interface{} {
+	return g.schemaFor(reflect.TypeOf(v))
+}
+
+// Object 内联生成结构体的Schema（即使设置了refPrefix）
+func (g *Generator) Object(v interface{}) map[string]interface{} {
+	return g.objectSchema(indirect(reflect.TypeOf(v)))
+}
+
+// schemaFor 将Go类型映射为Schema
+func (g *Generator) schemaFor(t reflect.Type) map[string]interface{} {
+	t = indirect(t)
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = g.schemaFor(t.Elem())
+		}
+		return g.named(t, schema)
+	case reflect.Struct:
+		if g.refPrefix == "" || t.Name() == "" {
+			return g.objectSchema(t)
+		}
+		if _, exists := g.components[t.Name()]; !exists {
+			// 先占位，避免自引用类型无限递归
+			g.components[t.Name()] = map[string]interface{}{}
+			g.components[t.Name()] = g.objectSchema(t)
+		}
+		return map[string]interface{}{"$ref": g.refPrefix + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// named 具名的非结构体类型（如model.MergedLinks）同样生成组件
+func (g *Generator) named(t reflect.Type, schema map[string]interface{}) map[string]interface{} {
+	if g.refPrefix == "" || t.Name() == "" {
+		return schema
+	}
+	g.components[t.Name()] = schema
+	return map[string]interface{}{"$ref": g.refPrefix + t.Name()}
+}
+
+// objectSchema 根据json标签生成结构体的Schema，binding:"required"的字段为必填
+func (g *Generator) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	descriptions := g.descriptions[t]
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		property := g.schemaFor(field.Type)
+		if description, ok := descriptions[name]; ok {
+			if _, isRef := property["$ref"]; isRef {
+				// $ref不能带同级属性，用allOf包装
+				property = map[string]interface{}{"allOf": []interface{}{property}}
+			} else {
+				property = copySchema(property)
+			}
+			property["description"] = description
+		}
+		properties[name] = property
+
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// indirect 去掉指针
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// copySchema 浅拷贝Schema，避免修改共享的组件
+func copySchema(schema map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(schema)+1)
+	for key, value := range schema {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,89 @@
+// Package singleflight 合并相同键的并发调用
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// call 一次正在执行的调用
+type call[T any] struct {
+	done    chan struct{}
+	val     T
+	err     error
+	waiters int // 仍在等待结果的调用方数
+	dups    int // 加入等待的调用方数，不含发起者
+	cancel  context.CancelFunc
+}
+
+// Group 合并相同键的并发调用：同一时间每个键只执行一次fn，其余调用方等待并共享其结果
+// 零值可以直接使用
+type Group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// Do 执行fn并返回其结果，相同键的fn正在执行时不再执行，等待并返回同一个结果，shared表示结果由多个调用方共享
+// fn收到的ctx保留发起者ctx中的值，但不随某个调用方取消，只有所有调用方都已取消时才取消；
+// 调用方自己的ctx取消时立即返回ctx.Err()，不影响其他调用方
+// 共享的结果不能修改
+func (g *Group[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (v T, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+	c, ok := g.calls[key]
+	if ok {
+		c.waiters++
+		c.dups++
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call[T]{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = c
+		go g.run(callCtx, key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err, c.dups > 0
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero T
+		return zero, ctx.Err(), false
+	}
+}
+
+// InFlight 正在执行的调用数
+func (g *Group[T]) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.calls)
+}
+
+// run 执行fn，完成后移除该调用并通知所有等待的调用方
+func (g *Group[T]) run(ctx context.Context, key string, c *call[T], fn func(ctx context.Context) (T, error)) {
+	defer c.cancel()
+	c.val, c.err = fn(ctx)
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// leave 调用方取消等待，最后一个调用方离开时取消fn，之后的调用重新执行
+func (g *Group[T]) leave(key string, c *call[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	c.cancel()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,142 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 相同键的并发调用只执行一次，所有调用方得到同一个结果
+func TestGroupDo(t *testing.T) {
+	var g Group[int]
+	var calls int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	var sharedCount int32
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do(context.Background(), "key", fn)
+			if v != 42 || err != nil {
+				t.Errorf("得到%d, %v", v, err)
+			}
+			if shared {
+				atomic.AddInt32(&sharedCount, 1)
+			}
+		}()
+	}
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		c := g.calls["key"]
+		return c != nil && c.waiters == n
+	})
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("fn执行了%d次，期望1次", calls)
+	}
+	if sharedCount != n {
+		t.Fatalf("%d个调用方的结果标记为共享，期望%d个", sharedCount, n)
+	}
+	if g.InFlight() != 0 {
+		t.Fatal("完成后应移除调用")
+	}
+
+	// 完成后再次调用重新执行
+	release = make(chan struct{})
+	close(release)
+	if _, _, shared := g.Do(context.Background(), "key", fn); shared || calls != 2 {
+		t.Fatalf("完成后应重新执行: calls=%d shared=%v", calls, shared)
+	}
+}
+
+// 调用方取消时立即返回，其他调用方继续等待；所有调用方都取消后才取消fn
+func TestGroupDoCancel(t *testing.T) {
+	var g Group[string]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	canceled := make(chan struct{})
+	fn := func(ctx context.Context) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return "ok", nil
+		case <-ctx.Done():
+			close(canceled)
+			return "", ctx.Err()
+		}
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	second := make(chan string, 1)
+	go func() {
+		_, err, _ := g.Do(ctx1, "key", fn)
+		first <- err
+	}()
+	<-started
+	go func() {
+		v, _, _ := g.Do(ctx2, "key", fn)
+		second <- v
+	}()
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.calls["key"].waiters == 2
+	})
+
+	// 发起者取消后，另一个调用方仍然得到结果
+	cancel1()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("取消的调用方应返回context.Canceled，得到%v", err)
+	}
+	close(release)
+	if v := <-second; v != "ok" {
+		t.Fatalf("其他调用方应得到结果，得到%q", v)
+	}
+	cancel2()
+
+	// 所有调用方都取消后fn收到取消
+	started = make(chan struct{})
+	release = make(chan struct{})
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		g.Do(ctx3, "key", fn)
+		close(done)
+	}()
+	<-started
+	cancel3()
+	<-done
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("所有调用方取消后fn应收到取消")
+	}
+}
+
+// waitFor 等待条件成立，最多1秒
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待超时")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,134 @@
+package textmatch
+
+import "strings"
+
+// minRunesPerEdit 每允许一处编辑所需的关键词最少字符数，避免短关键词模糊匹配到无关文本
+const minRunesPerEdit = 4
+
+// Options 匹配选项
+type Options struct {
+	Pinyin      bool // 是否允许用全拼或拼音首字母匹配中文，如 quanlideyouxi、qldyx 匹配 权力的游戏
+	MaxDistance int  // 允许的最大编辑距离，0表示不启用模糊匹配；实际允许的距离还受关键词长度限制
+}
+
+// Matcher 关键词匹配器
+// 关键词按空白拆分为多个词，文本需包含全部的词（可分布在不同文本中）才算匹配
+type Matcher struct {
+	terms   []string
+	options Options
+}
+
+// NewMatcher 创建关键词匹配器
+func NewMatcher(keyword string, options Options) *Matcher {
+	m := &Matcher{options: options}
+	for _, field := range strings.Fields(normalizeNumbering(Fold(keyword))) {
+		if term := stripPunctuation(field); term != "" {
+			m.terms = append(m.terms, term)
+		}
+	}
+	return m
+}
+
+// Empty 关键词规范化后是否为空，为空时任何文本都匹配
+func (m *Matcher) Empty() bool {
+	return len(m.terms) == 0
+}
+
+// Match 判断文本是否包含关键词中的全部词
+func (m *Matcher) Match(texts ...string) bool {
+	if len(m.terms) == 0 {
+		return true
+	}
+
+	normalized := make([]string, len(texts))
+	for i, text := range texts {
+		normalized[i] = Normalize(text)
+	}
+
+	var fulls, initials []string
+	for _, term := range m.terms {
+		if containsAny(normalized, term) {
+			continue
+		}
+
+		if m.options.Pinyin && isPinyinTerm(term) {
+			if fulls == nil {
+				fulls = make([]string, len(normalized))
+				initials = make([]string, len(normalized))
+				for i, text := range normalized {
+					fulls[i], initials[i] = toPinyin(text)
+				}
+			}
+			if containsAny(fulls, term) || containsAny(initials, term) {
+				continue
+			}
+		}
+
+		if distance := m.allowedDistance(term); distance > 0 && fuzzyContainsAny(normalized, term, distance) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// allowedDistance 词允许的最大编辑距离
+func (m *Matcher) allowedDistance(term string) int {
+	distance := len([]rune(term)) / minRunesPerEdit
+	if distance > m.options.MaxDistance {
+		distance = m.options.MaxDistance
+	}
+	return distance
+}
+
+// containsAny 任一文本是否包含该词
+func containsAny(texts []string, term string) bool {
+	for _, text := range texts {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyContainsAny 任一文本中是否存在与该词编辑距离不超过maxDistance的片段
+func fuzzyContainsAny(texts []string, term string, maxDistance int) bool {
+	pattern := []rune(term)
+	for _, text := range texts {
+		if SubstringDistance(pattern, []rune(text)) <= maxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// SubstringDistance 计算pattern与text中最相近片段的编辑距离（插入、删除、替换各计1）
+func SubstringDistance(pattern []rune, text []rune) int {
+	if len(pattern) == 0 {
+		return 0
+	}
+
+	// previous[i]为pattern前i个字符与以当前位置结尾的片段的最小编辑距离，片段可从text任意位置开始
+	previous := make([]int, len(pattern)+1)
+	current := make([]int, len(pattern)+1)
+	for i := range previous {
+		previous[i] = i
+	}
+	best := previous[len(pattern)]
+
+	for _, r := range text {
+		current[0] = 0
+		for i := 1; i <= len(pattern); i++ {
+			cost := 1
+			if pattern[i-1] == r {
+				cost = 0
+			}
+			current[i] = min(previous[i-1]+cost, previous[i]+1, current[i-1]+1)
+		}
+		if current[len(pattern)] < best {
+			best = current[len(pattern)]
+		}
+		previous, current = current, previous
+	}
+	return best
+}
@@ -0,0 +1,129 @@
+// Package textmatch 规范化中文文本，并按关键词对文本做容错匹配
+//
+// 规范化依次执行：全角转半角、转小写、繁体转简体、季/集编号统一，最后去除标点、符号和空白。
+// 季/集编号统一为 s数字、e数字：第二季、第2季、S02、Season 2 均为 s2，
+// 第三集、第3话、E03、EP03、Episode 3 均为 e3，S02E03 为 s2e3。
+// 关键词和被匹配的文本使用相同的规范化，因此 權力的遊戲 与 权力的游戏、
+// 复仇者联盟 4 与 复仇者联盟4、三体 第二季 与 Three.Body.S02 都可以匹配。
+package textmatch
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// traditionalToSimplified 繁体字到简体字的映射
+	traditionalToSimplified = make(map[rune]rune)
+
+	// 第N季、第N集等中文编号
+	chineseNumberedPattern = regexp.MustCompile(`第\s*([0-9零〇一二两三四五六七八九十百]+)\s*(季|集|话|期)`)
+	// S02E03
+	seasonEpisodePattern = regexp.MustCompile(`\bs0*(\d{1,3})\s*e0*(\d{1,4})\b`)
+	// S02、Season 2
+	seasonPattern = regexp.MustCompile(`\b(?:season\s*|s)0*(\d{1,3})\b`)
+	// E03、EP03、Episode 3
+	episodePattern = regexp.MustCompile(`\b(?:episode\s*|ep\s*|e)0*(\d{1,4})\b`)
+)
+
+func init() {
+	simplified := []rune(simplifiedChars)
+	for i, r := range []rune(traditionalChars) {
+		traditionalToSimplified[r] = simplified[i]
+	}
+}
+
+// Normalize 返回文本规范化后的形式
+func Normalize(text string) string {
+	return stripPunctuation(normalizeNumbering(Fold(text)))
+}
+
+// Fold 全角转半角、转小写并将繁体字转为简体字，保留标点和空白
+func Fold(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			r = ' '
+		case r >= '！' && r <= '～':
+			r -= 0xfee0
+		}
+		if simplified, ok := traditionalToSimplified[r]; ok {
+			return simplified
+		}
+		return unicode.ToLower(r)
+	}, text)
+}
+
+// normalizeNumbering 将季/集编号统一为 s数字、e数字，text需已经过Fold处理
+func normalizeNumbering(text string) string {
+	if strings.Contains(text, "第") {
+		text = chineseNumberedPattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := chineseNumberedPattern.FindStringSubmatch(match)
+			number, ok := parseNumber(groups[1])
+			if !ok {
+				return match
+			}
+			if groups[2] == "季" {
+				return " s" + strconv.Itoa(number) + " "
+			}
+			return " e" + strconv.Itoa(number) + " "
+		})
+	}
+
+	if strings.IndexFunc(text, isASCIIDigit) < 0 {
+		return text
+	}
+	text = seasonEpisodePattern.ReplaceAllString(text, "s${1}e${2}")
+	text = seasonPattern.ReplaceAllString(text, "s${1}")
+	return episodePattern.ReplaceAllString(text, "e${1}")
+}
+
+// stripPunctuation 去除标点、符号和空白，只保留字母和数字
+func stripPunctuation(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// parseNumber 解析阿拉伯数字或一百九十九以内的中文数字
+func parseNumber(text string) (int, bool) {
+	if number, err := strconv.Atoi(text); err == nil {
+		return number, true
+	}
+
+	digits := map[rune]int{'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	total, current := 0, 0
+	for _, r := range text {
+		switch r {
+		case '百':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 100
+			current = 0
+		case '十':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+		default:
+			digit, ok := digits[r]
+			if !ok {
+				return 0, false
+			}
+			current = digit
+		}
+	}
+	return total + current, true
+}
+
+// isASCIIDigit 是否为阿拉伯数字
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
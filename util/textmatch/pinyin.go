@@ -0,0 +1,54 @@
+package textmatch
+
+import "strings"
+
+// hanziPinyin 汉字到拼音的映射
+var hanziPinyin = make(map[rune]string)
+
+func init() {
+	for _, entry := range pinyinSyllables {
+		for _, r := range entry.chars {
+			hanziPinyin[r] = entry.syllable
+		}
+	}
+}
+
+// toPinyin 将规范化后的文本转为全拼和拼音首字母，不在拼音表中的字符原样保留
+func toPinyin(text string) (full string, initials string) {
+	var fullBuilder, initialsBuilder strings.Builder
+	for _, r := range text {
+		if syllable, ok := hanziPinyin[r]; ok {
+			fullBuilder.WriteString(syllable)
+			initialsBuilder.WriteByte(syllable[0])
+			continue
+		}
+		fullBuilder.WriteRune(r)
+		initialsBuilder.WriteRune(r)
+	}
+	return fullBuilder.String(), initialsBuilder.String()
+}
+
+// minPinyinTermLen 拼音输入的最少字母数
+// hd、tv、mp这类两个字母的词作为首字母几乎能匹配任意中文标题，不按拼音匹配
+const minPinyinTermLen = 3
+
+// asciiTerms 资源标题中常见的英文标记，按原文匹配，不作为拼音首字母
+var asciiTerms = map[string]bool{
+	"hdr": true, "sdr": true, "web": true, "dvd": true, "rip": true, "mkv": true,
+	"avi": true, "mov": true, "flv": true, "aac": true, "dts": true, "avc": true,
+	"cam": true, "iso": true, "zip": true, "rar": true, "pdf": true, "epub": true,
+	"hevc": true, "bdrip": true, "webrip": true, "remux": true, "bluray": true,
+}
+
+// isPinyinTerm 是否可能是拼音输入：至少minPinyinTermLen个小写英文字母、不含其他字符，且不是常见的英文标记
+func isPinyinTerm(term string) bool {
+	if len(term) < minPinyinTermLen || asciiTerms[term] {
+		return false
+	}
+	for i := 0; i < len(term); i++ {
+		if term[i] < 'a' || term[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
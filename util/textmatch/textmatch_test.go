@@ -0,0 +1,94 @@
+package textmatch
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"權力的遊戲", "权力的游戏"},
+		{"复仇者联盟 4", "复仇者联盟4"},
+		{"ＡＢＣ１２３　测试", "abc123测试"},
+		{"【三体】第二季", "三体s2"},
+		{"三体 第2季 第十二集", "三体s2e12"},
+		{"Three.Body.S02E03.1080p", "threebodys2e31080p"},
+		{"Season 2 Episode 03", "s2e3"},
+		{"EP05", "e5"},
+		{"第一百零五期", "e105"},
+		{"PS5", "ps5"},
+	}
+	for _, c := range cases {
+		if got := Normalize(c.text); got != c.want {
+			t.Errorf("Normalize(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	cases := []struct {
+		keyword string
+		options Options
+		text    string
+		want    bool
+	}{
+		{"权力的游戏", Options{}, "權力的遊戲 第八季", true},
+		{"复仇者联盟4", Options{}, "复仇者联盟 4：终局之战", true},
+		{"复仇者联盟 4", Options{}, "复仇者联盟4", true},
+		{"三体 第二季", Options{}, "Three Body 三体 S02 4K", true},
+		{"三体 第二季", Options{}, "三体 第一季", false},
+		{"season 2", Options{}, "绝命毒师 第二季", true},
+		{"流浪地球", Options{}, "三体", false},
+		{"！！", Options{}, "任意文本", true},
+		{"quanlideyouxi", Options{}, "权力的游戏", false},
+		{"quanlideyouxi", Options{Pinyin: true}, "权力的游戏", true},
+		{"qldyx", Options{Pinyin: true}, "權力的遊戲", true},
+		{"qldyx", Options{Pinyin: true}, "三体", false},
+		{"santi", Options{Pinyin: true}, "三体 第二季", true},
+		{"hd", Options{Pinyin: true}, "好的电影", false},
+		{"mp", Options{Pinyin: true}, "美片合集", false},
+		{"dy", Options{Pinyin: true}, "电影大全", false},
+		{"hdr", Options{Pinyin: true}, "好的人", false},
+		{"hdr", Options{Pinyin: true}, "流浪地球 4K HDR", true},
+		{"web", Options{Pinyin: true}, "我爱北京", false},
+		{"lvse", Options{Pinyin: true}, "绿色星球", true},
+		{"权利的游戏", Options{MaxDistance: 1}, "权力的游戏 全八季", true},
+		{"权利的游戏", Options{}, "权力的游戏 全八季", false},
+		{"三休", Options{MaxDistance: 1}, "三体", false},
+		{"avengrs", Options{MaxDistance: 2}, "The Avengers", true},
+	}
+	for _, c := range cases {
+		if got := NewMatcher(c.keyword, c.options).Match(c.text); got != c.want {
+			t.Errorf("NewMatcher(%q, %+v).Match(%q) = %v, want %v", c.keyword, c.options, c.text, got, c.want)
+		}
+	}
+}
+
+func TestMatcherMultipleTexts(t *testing.T) {
+	m := NewMatcher("三体 4K", Options{})
+	if !m.Match("三体", "4K HDR 全30集") {
+		t.Error("terms split across title and content should match")
+	}
+	if m.Match("三体", "1080P") {
+		t.Error("missing term should not match")
+	}
+}
+
+func TestSubstringDistance(t *testing.T) {
+	cases := []struct {
+		pattern string
+		text    string
+		want    int
+	}{
+		{"abc", "xxabcxx", 0},
+		{"abc", "xxabxx", 1},
+		{"abc", "", 3},
+		{"", "abc", 0},
+		{"kitten", "sitting", 2},
+	}
+	for _, c := range cases {
+		if got := SubstringDistance([]rune(c.pattern), []rune(c.text)); got != c.want {
+			t.Errorf("SubstringDistance(%q, %q) = %d, want %d", c.pattern, c.text, got, c.want)
+		}
+	}
+}